@@ -0,0 +1,428 @@
+// Package analyzer watches received NDI streams for the ways a feed dies silently: black or frozen pictures, silent or
+// clipping audio channels, and video arriving at the wrong rate or not at all.
+//
+// Conditions must last a while before they are reported, and be gone a while before their end is, so a flapping
+// feed raises a single pair of events. Events come on a channel or through a callback, and can be logged.
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/benitogf/gondi"
+)
+
+// How long Run waits for a frame before checking for stalled video and its context
+const runPollMs = 100
+
+// Kind of condition an Event reports
+type EventType int
+
+const (
+	// Nearly every pixel of the picture is black
+	Black EventType = iota
+	// The picture does not change
+	Freeze
+	// A channel is under the silence level
+	Silence
+	// A channel reaches full scale
+	Clipping
+	// Video arrives at another rate than its frame rate, or stopped
+	FrameRate
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Black:
+		return "black"
+	case Freeze:
+		return "freeze"
+	case Silence:
+		return "silence"
+	case Clipping:
+		return "clipping"
+	case FrameRate:
+		return "frame rate"
+	}
+
+	return "unknown"
+}
+
+// A condition starting or ending
+type Event struct {
+	Type EventType
+
+	// True when the condition started, false when it ended
+	Active bool
+
+	// When the condition started, or ended, on the local clock for every event so video and audio events compare
+	Time time.Time
+
+	// Time on the clock of the sender, for video events of frames with a timestamp, zero otherwise
+	SenderTime time.Time
+
+	// How long the condition lasted so far for a start, in total for an end
+	Duration time.Duration
+
+	// Audio channel of Silence and Clipping events, -1 for video events
+	Channel int
+
+	// Measured and expected frames per second of FrameRate events, the measured rate is 0 when video stopped
+	FrameRate float64
+	Expected  float64
+}
+
+func (e Event) String() string {
+	state := "ended"
+	if e.Active {
+		state = "started"
+	}
+	s := fmt.Sprintf("%s %s at %s after %s", e.Type, state, e.Time.Format("15:04:05.000"), e.Duration.Round(time.Millisecond))
+	if e.Channel >= 0 {
+		s += fmt.Sprintf(" on channel %d", e.Channel)
+	}
+	if e.Type == FrameRate && e.Active {
+		s += fmt.Sprintf(", %.2f fps instead of %.2f", e.FrameRate, e.Expected)
+	}
+
+	return s
+}
+
+// A condition that must hold for a while to start, and be gone for a while to end
+type condition struct {
+	raw, active  bool
+	since, start time.Time
+}
+
+// Update the condition with what a frame from start to end shows, returning whether the condition started or ended
+func (c *condition) update(raw bool, start time.Time, end time.Time, enter time.Duration, exit time.Duration) bool {
+	if raw != c.raw {
+		c.raw, c.since = raw, start
+	}
+	switch {
+	case !c.active && c.raw && end.Sub(c.since) >= enter:
+		c.active, c.start = true, c.since
+		return true
+	case c.active && !c.raw && end.Sub(c.since) >= exit:
+		c.active = false
+		return true
+	}
+
+	return false
+}
+
+// The event of a condition that just started or ended
+func (c *condition) event(t EventType, channel int, end time.Time) Event {
+	if c.active {
+		return Event{Type: t, Active: true, Time: c.start, Duration: end.Sub(c.start), Channel: channel}
+	}
+	return Event{Type: t, Time: c.since, Duration: c.since.Sub(c.start), Channel: channel}
+}
+
+// Analyzer finds black, frozen, silent and clipping streams, and video at the wrong rate. Give it the frames of a
+// receiver with Video and Audio, or have Run capture them. Video and audio can be analyzed from different goroutines.
+//
+// The Events channel holds 64 events, further events are dropped and logged until it is read. It is never closed.
+type Analyzer struct {
+	options Options
+	events  chan Event
+	now     func() time.Time
+
+	mu    sync.Mutex
+	video videoState
+	audio audioState
+}
+
+type videoState struct {
+	// Time of the last frame on the clock of the sender, and when it arrived on the local clock. Without a timestamp
+	// both are the arrival time.
+	last    time.Time
+	arrived time.Time
+	stamped bool
+
+	width    int
+	height   int
+	previous []float64
+
+	// Frame times over the frame rate window, since the frame rate last changed
+	times    []time.Time
+	started  time.Time
+	expected float64
+
+	black, freeze, frameRate condition
+}
+
+type audioState struct {
+	origin     time.Time
+	sampleRate int
+	samples    int64
+	channels   []audioChannel
+}
+
+type audioChannel struct {
+	silence, clipping condition
+	run               int
+}
+
+// Create an analyzer, see the Option functions for what it looks for
+func New(options ...Option) *Analyzer {
+	a := &Analyzer{
+		options: defaultOptions(),
+		events:  make(chan Event, 64),
+		now:     time.Now,
+	}
+	for _, option := range options {
+		option(&a.options)
+	}
+
+	return a
+}
+
+// Channel the events are sent on when there is no callback
+func (a *Analyzer) Events() <-chan Event {
+	return a.events
+}
+
+// Forget everything about the stream, like when the receiver connects to another source
+func (a *Analyzer) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.video, a.audio = videoState{}, audioState{}
+}
+
+func (a *Analyzer) emit(events []Event) {
+	for _, event := range events {
+		if a.options.Logger != nil {
+			a.options.Logger.Printf("analyzer: %s", event)
+		}
+		if a.options.Callback != nil {
+			a.options.Callback(event)
+			continue
+		}
+		select {
+		case a.events <- event:
+		default:
+			if a.options.Logger != nil {
+				a.options.Logger.Printf("analyzer: events channel full, dropped %s", event)
+			}
+		}
+	}
+}
+
+// Analyze a video frame. Durations are measured with the frame timestamps, or the current time for frames without
+// one, and events are stamped with the time frames arrived.
+func (a *Analyzer) Video(vf *gondi.VideoFrameV2) {
+	arrived := a.now()
+	at := arrived
+	stamped := vf.Timestamp > 0 && vf.Timestamp != math.MaxInt64
+	if stamped {
+		at = time.Unix(0, vf.Timestamp*100)
+	}
+	luma := sampleLuma(vf)
+
+	a.mu.Lock()
+	events := a.analyzeVideo(vf, at, luma)
+	a.video.arrived, a.video.stamped = arrived, stamped
+	a.video.localEvents(events)
+	a.mu.Unlock()
+
+	a.emit(events)
+}
+
+func (a *Analyzer) analyzeVideo(vf *gondi.VideoFrameV2, at time.Time, luma []float64) []Event {
+	o, v := &a.options, &a.video
+	var events []Event
+
+	black, frozen := false, false
+	if luma != nil {
+		dark := 0
+		for _, y := range luma {
+			if y < o.BlackLevel {
+				dark++
+			}
+		}
+		black = float64(dark) >= 0.98*float64(len(luma))
+
+		if v.previous != nil && int(vf.Xres) == v.width && int(vf.Yres) == v.height && len(luma) == len(v.previous) {
+			var difference float64
+			for i, y := range luma {
+				difference += math.Abs(y - v.previous[i])
+			}
+			frozen = difference/float64(len(luma)) < o.FreezeThreshold
+		}
+	}
+	v.previous, v.width, v.height = luma, int(vf.Xres), int(vf.Yres)
+
+	if v.black.update(black, at, at, o.BlackDuration, o.Recovery) {
+		events = append(events, v.black.event(Black, -1, at))
+	}
+	// A picture is frozen since the frame it is the same as
+	since := at
+	if frozen {
+		since = v.last
+	}
+	if v.freeze.update(frozen, since, at, o.FreezeDuration, o.Recovery) {
+		events = append(events, v.freeze.event(Freeze, -1, at))
+	}
+
+	// The rate is measured once there is a full window of frames at the same frame rate
+	expected := 0.0
+	if vf.FrameRateN > 0 && vf.FrameRateD > 0 {
+		expected = float64(vf.FrameRateN) / float64(vf.FrameRateD)
+	}
+	if expected != v.expected {
+		v.expected, v.times, v.started = expected, nil, at
+	}
+	v.last = at
+	v.times = append(v.times, at)
+	for len(v.times) > 2 && at.Sub(v.times[1]) >= o.FrameRateWindow {
+		v.times = v.times[1:]
+	}
+	if expected > 0 && at.Sub(v.started) >= o.FrameRateWindow && len(v.times) > 1 {
+		measured := float64(len(v.times)-1) / at.Sub(v.times[0]).Seconds()
+		if event, ok := a.updateFrameRate(at, measured); ok {
+			events = append(events, event)
+		}
+	}
+
+	return events
+}
+
+// Update the frame rate condition, with hysteresis on the tolerance
+func (a *Analyzer) updateFrameRate(at time.Time, measured float64) (Event, bool) {
+	v := &a.video
+	tolerance := a.options.FrameRateTolerance
+	if v.frameRate.active {
+		tolerance /= 2
+	}
+	deviates := math.Abs(measured-v.expected) > tolerance*v.expected
+	if !v.frameRate.update(deviates, at, at, 0, a.options.Recovery) {
+		return Event{}, false
+	}
+
+	event := v.frameRate.event(FrameRate, -1, at)
+	event.FrameRate, event.Expected = measured, v.expected
+
+	return event, true
+}
+
+// Report video that stopped coming for longer than the frame rate window. The wait is measured on the local clock,
+// as the sender clock can be anything.
+func (a *Analyzer) checkStalled() {
+	now := a.now()
+
+	a.mu.Lock()
+	var events []Event
+	v := &a.video
+	if waited := now.Sub(v.arrived); v.expected > 0 && !v.arrived.IsZero() && waited >= a.options.FrameRateWindow {
+		if event, ok := a.updateFrameRate(v.last.Add(waited), 0); ok {
+			events = append(events, event)
+		}
+	}
+	v.localEvents(events)
+	a.mu.Unlock()
+
+	a.emit(events)
+}
+
+// Move video events from the sender clock to the local one, with the offset of the last frame
+func (v *videoState) localEvents(events []Event) {
+	for i := range events {
+		if v.stamped {
+			events[i].SenderTime = events[i].Time
+		}
+		events[i].Time = events[i].Time.Add(v.arrived.Sub(v.last))
+	}
+}
+
+// Analyze a received audio frame, honoring its channel stride
+func (a *Analyzer) Audio(af *gondi.AudioFrameV2) error {
+	return a.AudioBuffer(gondi.AudioBufferFromV2(af))
+}
+
+// Analyze audio in any format. Audio time is counted in samples from the local time of the first buffer, so gaps in
+// the audio are not seen. The analysis starts over when the sample rate or the number of channels changes.
+func (a *Analyzer) AudioBuffer(buf *gondi.AudioBuffer) error {
+	if err := buf.Validate(); err != nil {
+		return err
+	}
+	if buf.SampleRate <= 0 {
+		return errors.New("unable to analyze audio without a sample rate")
+	}
+
+	a.mu.Lock()
+	events := a.analyzeAudio(buf)
+	a.mu.Unlock()
+
+	a.emit(events)
+
+	return nil
+}
+
+func (a *Analyzer) analyzeAudio(buf *gondi.AudioBuffer) []Event {
+	o, s := &a.options, &a.audio
+	if buf.SampleRate != s.sampleRate || buf.NumChannels != len(s.channels) {
+		*s = audioState{origin: a.now(), sampleRate: buf.SampleRate, channels: make([]audioChannel, buf.NumChannels)}
+	}
+
+	sampleTime := func(samples int64) time.Time {
+		return s.origin.Add(time.Duration(samples * int64(time.Second) / int64(s.sampleRate)))
+	}
+	start, end := sampleTime(s.samples), sampleTime(s.samples+int64(buf.NumSamples))
+	s.samples += int64(buf.NumSamples)
+
+	silence := math.Pow(10, o.SilenceLevel/20)
+	clipping := math.Pow(10, o.ClippingLevel/20)
+	var events []Event
+	for ch := range s.channels {
+		c := &s.channels[ch]
+		peak, clipped := 0.0, false
+		for i := 0; i < buf.NumSamples; i++ {
+			sample := math.Abs(float64(buf.Sample(ch, i)))
+			peak = max(peak, sample)
+			if sample >= clipping {
+				c.run++
+				clipped = clipped || c.run >= o.ClippingSamples
+			} else {
+				c.run = 0
+			}
+		}
+
+		if c.silence.update(peak < silence, start, end, o.SilenceDuration, o.Recovery) {
+			events = append(events, c.silence.event(Silence, ch, end))
+		}
+		if c.clipping.update(clipped, start, end, 0, o.Recovery) {
+			events = append(events, c.clipping.event(Clipping, ch, end))
+		}
+	}
+
+	return events
+}
+
+// Capture the video and audio of a receiver and analyze it until the context is done, returning its error. Nothing
+// else should capture from the receiver meanwhile, create one with the bandwidth of what should be analyzed.
+func (a *Analyzer) Run(ctx context.Context, recv *gondi.RecvInstance) error {
+	vf, af := &gondi.VideoFrameV2{}, &gondi.AudioFrameV2{}
+	for ctx.Err() == nil {
+		switch recv.CaptureV2(vf, af, nil, runPollMs) {
+		case gondi.FrameTypeVideo:
+			a.Video(vf)
+			recv.FreeVideoV2(vf)
+		case gondi.FrameTypeAudio:
+			if err := a.Audio(af); err != nil && a.options.Logger != nil {
+				a.options.Logger.Printf("analyzer: %v", err)
+			}
+			recv.FreeAudioV2(af)
+			a.checkStalled()
+		default:
+			a.checkStalled()
+		}
+	}
+
+	return ctx.Err()
+}
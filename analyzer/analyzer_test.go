@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/benitogf/gondi"
+)
+
+var epoch = time.Unix(1700000000, 0)
+
+// A BGRA frame of one gray level, at a time after the epoch
+func grayFrame(level byte, at time.Duration) *gondi.VideoFrameV2 {
+	data := make([]byte, 64*36*4)
+	for i := range data {
+		data[i] = level
+	}
+	vf := gondi.NewVideoFrameV2()
+	vf.FourCC = gondi.FourCCTypeBGRA
+	vf.Xres, vf.Yres, vf.LineStride = 64, 36, 64*4
+	vf.FrameRateN, vf.FrameRateD = 25, 1
+	vf.Data = &data[0]
+	vf.Timestamp = epoch.Add(at).UnixNano() / 100
+	return vf
+}
+
+// Collect the events of an analyzer
+func collect(options ...Option) (*Analyzer, *[]Event) {
+	events := &[]Event{}
+	a := New(append(options, WithCallback(func(e Event) { *events = append(*events, e) }))...)
+	a.now = func() time.Time { return epoch }
+	return a, events
+}
+
+func TestBlackAndFreeze(t *testing.T) {
+	a, events := collect(WithFreeze(0.002, 5*time.Second))
+
+	// Frames arrive an hour after their timestamp, events are on the local clock
+	local := epoch.Add(time.Hour)
+	play := func(level byte, frame int) {
+		at := time.Duration(frame) * 40 * time.Millisecond
+		a.now = func() time.Time { return local.Add(at) }
+		a.Video(grayFrame(level, at))
+	}
+
+	// 3s of black, then 3s of pictures changing every frame
+	frame := 0
+	for ; frame < 75; frame++ {
+		play(0, frame)
+	}
+	for ; frame < 150; frame++ {
+		play(byte(100+frame%2*50), frame)
+	}
+
+	want := []Event{
+		{Type: Black, Active: true, Time: local, SenderTime: epoch, Duration: 2 * time.Second, Channel: -1},
+		{Type: Black, Time: local.Add(3 * time.Second), SenderTime: epoch.Add(3 * time.Second), Duration: 3 * time.Second, Channel: -1},
+	}
+	if len(*events) != len(want) {
+		t.Fatalf("events are %v, want %v", *events, want)
+	}
+	for i := range want {
+		if (*events)[i] != want[i] {
+			t.Errorf("event %d is %v, want %v", i, (*events)[i], want[i])
+		}
+	}
+
+	// The same picture for longer than the freeze duration
+	for ; frame < 300; frame++ {
+		play(100, frame)
+	}
+	if last := (*events)[len(*events)-1]; last.Type != Freeze || !last.Active || last.Time != local.Add(6*time.Second) {
+		t.Errorf("last event is %v, want a freeze from 6s", last)
+	}
+}
+
+func TestFrameRate(t *testing.T) {
+	a, events := collect(WithBlack(0.1, time.Hour), WithFreeze(0, time.Hour))
+
+	// Frames of a 25 fps stream arriving every 50ms
+	for frame := 0; frame < 60; frame++ {
+		a.Video(grayFrame(0, time.Duration(frame)*50*time.Millisecond))
+	}
+	if len(*events) != 1 || (*events)[0].Type != FrameRate || math.Abs((*events)[0].FrameRate-20) > 0.5 || (*events)[0].Expected != 25 {
+		t.Fatalf("events are %v, want 20 fps instead of 25", *events)
+	}
+
+	// Back at the right rate, then stopped
+	for frame := 0; frame < 100; frame++ {
+		a.Video(grayFrame(0, 3*time.Second+time.Duration(frame)*40*time.Millisecond))
+	}
+	if len(*events) != 2 || (*events)[1].Active {
+		t.Fatalf("events are %v, want the frame rate back", *events)
+	}
+	a.now = func() time.Time { return epoch.Add(10 * time.Second) }
+	a.checkStalled()
+	if len(*events) != 3 || !(*events)[2].Active || (*events)[2].FrameRate != 0 {
+		t.Fatalf("events are %v, want stopped video", *events)
+	}
+}
+
+func TestStalledSkewedClock(t *testing.T) {
+	a, events := collect(WithBlack(0.1, time.Hour), WithFreeze(0, time.Hour))
+
+	// The sender clock is an hour behind the local one, frames keep coming at the right rate
+	a.now = func() time.Time { return epoch.Add(time.Hour) }
+	for frame := 0; frame < 100; frame++ {
+		a.Video(grayFrame(0, time.Duration(frame)*40*time.Millisecond))
+		a.checkStalled()
+	}
+	if len(*events) != 0 {
+		t.Fatalf("events are %v, want none while frames arrive", *events)
+	}
+
+	// Stopped for the frame rate window on the local clock
+	now := epoch.Add(time.Hour + 2*time.Second)
+	a.now = func() time.Time { return now }
+	a.checkStalled()
+	if len(*events) != 1 || !(*events)[0].Active || (*events)[0].FrameRate != 0 || !(*events)[0].Time.Equal(now) {
+		t.Fatalf("events are %v, want stopped video at %v", *events, now)
+	}
+	if sender := epoch.Add(99*40*time.Millisecond + 2*time.Second); !(*events)[0].SenderTime.Equal(sender) {
+		t.Errorf("sender time is %v, want %v", (*events)[0].SenderTime, sender)
+	}
+}
+
+func TestAudio(t *testing.T) {
+	a, events := collect(WithSilence(-60, time.Second), WithRecovery(100*time.Millisecond))
+
+	// A tone on channel 0 and nothing on channel 1, in buffers of 10ms
+	for b := 0; b < 200; b++ {
+		buf := gondi.NewAudioBuffer(gondi.AudioFormatInt16Interleaved, 48000, 2, 480)
+		for i := 0; i < 480; i++ {
+			buf.SetSample(0, i, float32(0.5*math.Sin(float64(i)/10)))
+		}
+		// Clipping for 50ms at 1s
+		if b >= 100 && b < 105 {
+			buf.SetSample(0, 10, 1)
+			buf.SetSample(0, 11, 1)
+			buf.SetSample(0, 12, 1)
+		}
+		if err := a.AudioBuffer(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []Event{
+		{Type: Silence, Active: true, Time: epoch, Duration: time.Second, Channel: 1},
+		{Type: Clipping, Active: true, Time: epoch.Add(time.Second), Duration: 10 * time.Millisecond, Channel: 0},
+		{Type: Clipping, Time: epoch.Add(1050 * time.Millisecond), Duration: 50 * time.Millisecond, Channel: 0},
+	}
+	if len(*events) != len(want) {
+		t.Fatalf("events are %v, want %v", *events, want)
+	}
+	for i := range want {
+		if (*events)[i] != want[i] {
+			t.Errorf("event %d is %v, want %v", i, (*events)[i], want[i])
+		}
+	}
+}
+
+// Use the loopback backend for the rest of the test, subpackage tests start without a backend so it is reset after
+func useLoopback(t *testing.T) {
+	gondi.UseBackend(gondi.NewLoopbackBackend())
+	t.Cleanup(func() { gondi.UseBackend(nil) })
+}
+
+func TestRun(t *testing.T) {
+	useLoopback(t)
+
+	sender, err := gondi.NewSendInstance("feed", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Destroy()
+	finder, err := gondi.NewFindInstance(true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer finder.Destroy()
+	finder.WaitForSources(1000)
+	recv, err := gondi.NewRecvInstance(&gondi.NewRecvInstanceSettings{SourceToConnectTo: finder.GetCurrentSources()[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recv.Destroy()
+
+	a := New(WithBlack(0.1, 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- a.Run(ctx, recv) }()
+
+	sender.SendVideoFrame(grayFrame(0, 0))
+	select {
+	case event := <-a.Events():
+		if event.Type != Black || !event.Active {
+			t.Errorf("event is %v, want black", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("no event")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/convert"
+)
+
+// Size of the grid of pixels black and freeze detection look at, enough to see a clock ticking in a corner
+const (
+	gridWidth  = 160
+	gridHeight = 90
+)
+
+// Get the luma from 0 to 1 of a grid of pixels of the frame, nil for FourCCs that can't be read
+func sampleLuma(vf *gondi.VideoFrameV2) []float64 {
+	frame := vf.ConvertFrame()
+	if frame.Data == nil {
+		return nil
+	}
+	stride := frame.Stride
+	if stride == 0 {
+		stride = convert.DefaultStride(frame.FourCC, frame.Width)
+	}
+
+	// YCbCr luma is video range
+	video := func(y float64) float64 {
+		return min(max((y-16)/219, 0), 1)
+	}
+	var luma func(x, y int) float64
+	switch frame.FourCC {
+	case convert.FourCCUYVY, convert.FourCCUYVA:
+		luma = func(x, y int) float64 { return video(float64(frame.Data[y*stride+x*2+1])) }
+	case convert.FourCCNV12, convert.FourCCI420, convert.FourCCYV12:
+		luma = func(x, y int) float64 { return video(float64(frame.Data[y*stride+x])) }
+	case convert.FourCCP216, convert.FourCCPA16:
+		luma = func(x, y int) float64 {
+			i := y*stride + x*2
+			return video(float64(uint16(frame.Data[i])|uint16(frame.Data[i+1])<<8) / 256)
+		}
+	case convert.FourCCBGRA, convert.FourCCBGRX:
+		luma = func(x, y int) float64 {
+			p := frame.Data[y*stride+x*4:]
+			return (0.2126*float64(p[2]) + 0.7152*float64(p[1]) + 0.0722*float64(p[0])) / 255
+		}
+	case convert.FourCCRGBA, convert.FourCCRGBX:
+		luma = func(x, y int) float64 {
+			p := frame.Data[y*stride+x*4:]
+			return (0.2126*float64(p[0]) + 0.7152*float64(p[1]) + 0.0722*float64(p[2])) / 255
+		}
+	default:
+		return nil
+	}
+
+	columns, rows := min(frame.Width, gridWidth), min(frame.Height, gridHeight)
+	samples := make([]float64, 0, columns*rows)
+	for row := 0; row < rows; row++ {
+		y := (row*2 + 1) * frame.Height / (rows * 2)
+		for column := 0; column < columns; column++ {
+			samples = append(samples, luma((column*2+1)*frame.Width/(columns*2), y))
+		}
+	}
+
+	return samples
+}
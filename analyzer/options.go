@@ -0,0 +1,122 @@
+package analyzer
+
+import (
+	"time"
+
+	"github.com/benitogf/gondi"
+)
+
+// Configuration of an Analyzer, see the Option functions for the defaults
+type Options struct {
+	// Luma from 0 to 1 under which a pixel is black, and how long a picture must be black to raise an event
+	BlackLevel    float64
+	BlackDuration time.Duration
+
+	// Mean luma difference from 0 to 1 under which two frames are the same, and how long the picture must not change
+	// to raise an event
+	FreezeThreshold float64
+	FreezeDuration  time.Duration
+
+	// Peak level in dBFS under which a channel is silent, and how long it must be silent to raise an event
+	SilenceLevel    float64
+	SilenceDuration time.Duration
+
+	// Level in dBFS a channel clips at, and how many samples in a row must reach it
+	ClippingLevel   float64
+	ClippingSamples int
+
+	// Relative deviation from the frame rate of the frames that raises an event, and the time the rate is measured on.
+	// Video stopping altogether also raises an event, after the window.
+	FrameRateTolerance float64
+	FrameRateWindow    time.Duration
+
+	// How long a condition must be gone before its end is reported, so flapping raises a single event
+	Recovery time.Duration
+
+	// Called with every event instead of sending it on the Events channel, from the goroutine analyzing frames
+	Callback func(Event)
+
+	// Logs every event, nil to discard them
+	Logger gondi.Logger
+}
+
+// Options for New
+type Option func(*Options)
+
+// Report pictures where nearly every pixel is under level for duration, 0.1 and 2s by default
+func WithBlack(level float64, duration time.Duration) Option {
+	return func(o *Options) {
+		o.BlackLevel = level
+		o.BlackDuration = duration
+	}
+}
+
+// Report pictures that change less than threshold for duration, 0.002 and 5s by default
+func WithFreeze(threshold float64, duration time.Duration) Option {
+	return func(o *Options) {
+		o.FreezeThreshold = threshold
+		o.FreezeDuration = duration
+	}
+}
+
+// Report channels peaking under level dBFS for duration, -60 dBFS and 5s by default
+func WithSilence(level float64, duration time.Duration) Option {
+	return func(o *Options) {
+		o.SilenceLevel = level
+		o.SilenceDuration = duration
+	}
+}
+
+// Report channels with samples in a row at level dBFS or above, -0.1 dBFS and 3 samples by default
+func WithClipping(level float64, samples int) Option {
+	return func(o *Options) {
+		o.ClippingLevel = level
+		o.ClippingSamples = samples
+	}
+}
+
+// Report frame rates more than tolerance away from the one of the frames, measured over window. 0.05 and 2s by
+// default.
+func WithFrameRate(tolerance float64, window time.Duration) Option {
+	return func(o *Options) {
+		o.FrameRateTolerance = tolerance
+		o.FrameRateWindow = window
+	}
+}
+
+// Wait this long before reporting the end of a condition, 500ms by default
+func WithRecovery(recovery time.Duration) Option {
+	return func(o *Options) {
+		o.Recovery = recovery
+	}
+}
+
+// Pass the events to the callback instead of the Events channel
+func WithCallback(callback func(Event)) Option {
+	return func(o *Options) {
+		o.Callback = callback
+	}
+}
+
+// Log every event
+func WithLogger(logger gondi.Logger) Option {
+	return func(o *Options) {
+		o.Logger = logger
+	}
+}
+
+func defaultOptions() Options {
+	return Options{
+		BlackLevel:         0.1,
+		BlackDuration:      2 * time.Second,
+		FreezeThreshold:    0.002,
+		FreezeDuration:     5 * time.Second,
+		SilenceLevel:       -60,
+		SilenceDuration:    5 * time.Second,
+		ClippingLevel:      -0.1,
+		ClippingSamples:    3,
+		FrameRateTolerance: 0.05,
+		FrameRateWindow:    2 * time.Second,
+		Recovery:           500 * time.Millisecond,
+	}
+}
@@ -0,0 +1,69 @@
+package gondi
+
+import "unsafe"
+
+// Pure Go version of NDIlib_util_audio_from_interleaved_32f_v2, the Data of dst must be preallocated.
+func audioFromInterleaved32f(src *AudioFrameV2, dst *AudioFrameV2) {
+	if dst.ChannelStride == 0 {
+		dst.ChannelStride = src.NumSamples * 4
+	}
+	dst.SampleRate, dst.NumChannels, dst.NumSamples, dst.Timecode = src.SampleRate, src.NumChannels, src.NumSamples, src.Timecode
+	if src.Data == nil || dst.Data == nil || src.NumChannels <= 0 || src.NumSamples <= 0 {
+		return
+	}
+
+	stride := dst.ChannelStride / 4
+	deinterleave32f(
+		unsafe.Slice(src.Data, src.NumChannels*src.NumSamples),
+		unsafe.Slice(dst.Data, (src.NumChannels-1)*stride+src.NumSamples),
+		src.NumChannels, src.NumSamples, stride,
+	)
+}
+
+// Pure Go version of NDIlib_util_audio_to_interleaved_32f_v2, the Data of dst must be preallocated.
+func audioToInterleaved32f(src *AudioFrameV2, dst *AudioFrameV2) {
+	dst.SampleRate, dst.NumChannels, dst.NumSamples, dst.Timecode = src.SampleRate, src.NumChannels, src.NumSamples, src.Timecode
+	if src.Data == nil || dst.Data == nil || src.NumChannels <= 0 || src.NumSamples <= 0 {
+		return
+	}
+
+	stride := src.ChannelStride / 4
+	if stride == 0 {
+		stride = src.NumSamples
+	}
+	planar := unsafe.Slice(src.Data, (src.NumChannels-1)*stride+src.NumSamples)
+	interleaved := unsafe.Slice(dst.Data, src.NumChannels*src.NumSamples)
+	for ch := int32(0); ch < src.NumChannels; ch++ {
+		for i := int32(0); i < src.NumSamples; i++ {
+			interleaved[i*src.NumChannels+ch] = planar[ch*stride+i]
+		}
+	}
+}
+
+// Copy planar float audio with the given channel stride in bytes into a tightly packed buffer.
+func copyPlanarAudio(src *float32, numChannels int32, numSamples int32, channelStride int32) []float32 {
+	data := make([]float32, max(numChannels*numSamples, 0))
+	if src == nil || len(data) == 0 {
+		return data
+	}
+
+	stride := channelStride / 4
+	if stride == 0 {
+		stride = numSamples
+	}
+	planar := unsafe.Slice(src, (numChannels-1)*stride+numSamples)
+	for ch := int32(0); ch < numChannels; ch++ {
+		copy(data[ch*numSamples:(ch+1)*numSamples], planar[ch*stride:ch*stride+numSamples])
+	}
+
+	return data
+}
+
+// Convert interleaved samples to planar ones, with stride being the distance between channels in samples.
+func deinterleave32f(interleaved []float32, planar []float32, numChannels int32, numSamples int32, stride int32) {
+	for ch := int32(0); ch < numChannels; ch++ {
+		for i := int32(0); i < numSamples; i++ {
+			planar[ch*stride+i] = interleaved[i*numChannels+ch]
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package gondi
+
+import (
+	"errors"
+	"math"
+	"unsafe"
+)
+
+// Sample format and layout of an AudioBuffer
+type AudioFormat int
+
+const (
+	// float32 samples, one channel after the other, which is what NDI sends and receives
+	AudioFormatFloat32Planar AudioFormat = iota
+
+	// float32 samples, the channels of each sample next to each other
+	AudioFormatFloat32Interleaved
+
+	// int16 samples, the channels of each sample next to each other
+	AudioFormatInt16Interleaved
+
+	// int32 samples, the channels of each sample next to each other
+	AudioFormatInt32Interleaved
+)
+
+// AudioBuffer is audio owned by Go, in any of the AudioFormats. The samples are kept in the slice matching the format,
+// the others are nil. Integer samples are converted to float32 with full scale at 1.0, like NDI does.
+//
+// Buffers are converted from and to AudioFrameV2 and AudioFrameV3 in Go, so they work without the NDI library.
+type AudioBuffer struct {
+	Format      AudioFormat
+	SampleRate  int
+	NumChannels int
+	NumSamples  int
+
+	// Timecode in 100ns intervals, SendTimecodeSynthesize to have NDI generate it
+	Timecode int64
+
+	// Distance between the channels of planar audio in samples, NumSamples when 0. Unused for interleaved audio.
+	ChannelStride int
+
+	Float32 []float32
+	Int16   []int16
+	Int32   []int32
+}
+
+// Allocate a new audio buffer, silent
+func NewAudioBuffer(format AudioFormat, sampleRate int, numChannels int, numSamples int) *AudioBuffer {
+	b := &AudioBuffer{
+		Format:      format,
+		SampleRate:  sampleRate,
+		NumChannels: max(numChannels, 0),
+		NumSamples:  max(numSamples, 0),
+		Timecode:    SendTimecodeSynthesize,
+	}
+
+	size := b.NumChannels * b.NumSamples
+	switch format {
+	case AudioFormatInt16Interleaved:
+		b.Int16 = make([]int16, size)
+	case AudioFormatInt32Interleaved:
+		b.Int32 = make([]int32, size)
+	default:
+		b.Float32 = make([]float32, size)
+	}
+
+	return b
+}
+
+// Copy the planar float32 audio of a frame into a buffer, honoring the channel stride of the frame
+func AudioBufferFromV2(af *AudioFrameV2) *AudioBuffer {
+	b := &AudioBuffer{
+		Format:      AudioFormatFloat32Planar,
+		SampleRate:  int(af.SampleRate),
+		NumChannels: int(max(af.NumChannels, 0)),
+		NumSamples:  int(max(af.NumSamples, 0)),
+		Timecode:    af.Timecode,
+	}
+	b.Float32 = copyPlanarAudio(af.Data, af.NumChannels, af.NumSamples, af.ChannelStride)
+
+	return b
+}
+
+// Copy the audio of a frame into a buffer, honoring the channel stride of the frame. Only planar float32
+// (FourCCAudioTypeFLTP) frames are supported, a frame without FourCC is taken as such.
+func AudioBufferFromV3(af *AudioFrameV3) (*AudioBuffer, error) {
+	if af.FourCC != FourCCAudioTypeFLTP && af.FourCC != (FourCCAudioType{}) {
+		return nil, errors.New("unable to copy compressed audio")
+	}
+
+	return AudioBufferFromV2(&AudioFrameV2{
+		SampleRate:    af.SampleRate,
+		NumChannels:   af.NumChannels,
+		NumSamples:    af.NumSamples,
+		Timecode:      af.Timecode,
+		Data:          (*float32)(unsafe.Pointer(af.Data)),
+		ChannelStride: af.ChannelStride,
+	}), nil
+}
+
+// Check that the format is known and that the samples fit in its slice
+func (b *AudioBuffer) Validate() error {
+	if b.NumChannels < 0 || b.NumSamples < 0 {
+		return errors.New("unable to use an audio buffer with a negative size")
+	}
+	if b.Format == AudioFormatFloat32Planar && b.ChannelStride != 0 && b.ChannelStride < b.NumSamples {
+		return errors.New("unable to use an audio buffer with a channel stride smaller than its samples")
+	}
+
+	var length int
+	switch b.Format {
+	case AudioFormatFloat32Planar, AudioFormatFloat32Interleaved:
+		length = len(b.Float32)
+	case AudioFormatInt16Interleaved:
+		length = len(b.Int16)
+	case AudioFormatInt32Interleaved:
+		length = len(b.Int32)
+	default:
+		return errors.New("unable to use an audio buffer of an unknown format")
+	}
+	if b.NumChannels > 0 && b.NumSamples > 0 && length < b.index(b.NumChannels-1, b.NumSamples-1)+1 {
+		return errors.New("unable to use an audio buffer shorter than its channels and samples")
+	}
+
+	return nil
+}
+
+func (b *AudioBuffer) stride() int {
+	if b.ChannelStride == 0 {
+		return b.NumSamples
+	}
+	return b.ChannelStride
+}
+
+// Get the index of a sample in the slice of the format
+func (b *AudioBuffer) index(channel int, sample int) int {
+	if b.Format == AudioFormatFloat32Planar {
+		return channel*b.stride() + sample
+	}
+	return sample*b.NumChannels + channel
+}
+
+// Get a sample of a channel as float32, whatever the format
+func (b *AudioBuffer) Sample(channel int, sample int) float32 {
+	i := b.index(channel, sample)
+	switch b.Format {
+	case AudioFormatInt16Interleaved:
+		return float32(b.Int16[i]) / 32768
+	case AudioFormatInt32Interleaved:
+		return float32(float64(b.Int32[i]) / 2147483648)
+	}
+	return b.Float32[i]
+}
+
+// Set a sample of a channel from a float32, whatever the format. Integer samples are clipped to full scale.
+func (b *AudioBuffer) SetSample(channel int, sample int, value float32) {
+	i := b.index(channel, sample)
+	switch b.Format {
+	case AudioFormatInt16Interleaved:
+		b.Int16[i] = int16(clipSample(float64(value)*32768, math.MinInt16, math.MaxInt16))
+	case AudioFormatInt32Interleaved:
+		b.Int32[i] = int32(clipSample(float64(value)*2147483648, math.MinInt32, math.MaxInt32))
+	default:
+		b.Float32[i] = value
+	}
+}
+
+func clipSample(v float64, lowest float64, highest float64) float64 {
+	return math.Max(lowest, math.Min(highest, math.Round(v)))
+}
+
+// Get a copy of the buffer in another format, planar audio is tightly packed in the copy
+func (b *AudioBuffer) Convert(format AudioFormat) (*AudioBuffer, error) {
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	dst := NewAudioBuffer(format, b.SampleRate, b.NumChannels, b.NumSamples)
+	dst.Timecode = b.Timecode
+	if err := dst.Validate(); err != nil {
+		return nil, err
+	}
+	for ch := 0; ch < b.NumChannels; ch++ {
+		for i := 0; i < b.NumSamples; i++ {
+			dst.SetSample(ch, i, b.Sample(ch, i))
+		}
+	}
+
+	return dst, nil
+}
+
+// Get the buffer as planar float32, the buffer itself when it already is
+func (b *AudioBuffer) Planar() (*AudioBuffer, error) {
+	if b.Format == AudioFormatFloat32Planar {
+		return b, b.Validate()
+	}
+	return b.Convert(AudioFormatFloat32Planar)
+}
+
+// Get a frame of the buffer. The frame uses the samples of a planar float32 buffer without copying them, so the
+// buffer must be kept while the frame is used. Other formats are converted to a new planar buffer.
+func (b *AudioBuffer) FrameV2() (*AudioFrameV2, error) {
+	planar, err := b.Planar()
+	if err != nil {
+		return nil, err
+	}
+
+	af := NewAudioFrameV2()
+	af.SampleRate = int32(planar.SampleRate)
+	af.NumChannels = int32(planar.NumChannels)
+	af.NumSamples = int32(planar.NumSamples)
+	af.Timecode = planar.Timecode
+	af.ChannelStride = int32(planar.stride() * 4)
+	if len(planar.Float32) > 0 {
+		af.Data = &planar.Float32[0]
+	}
+
+	return af, nil
+}
+
+// Get a planar float32 (FourCCAudioTypeFLTP) frame of the buffer, see FrameV2
+func (b *AudioBuffer) FrameV3() (*AudioFrameV3, error) {
+	v2, err := b.FrameV2()
+	if err != nil {
+		return nil, err
+	}
+
+	af := NewAudioFrameV3()
+	af.SampleRate, af.NumChannels, af.NumSamples, af.Timecode = v2.SampleRate, v2.NumChannels, v2.NumSamples, v2.Timecode
+	af.ChannelStride = v2.ChannelStride
+	af.Data = (*byte)(unsafe.Pointer(v2.Data))
+
+	return af, nil
+}
+
+// Send the audio of a buffer in any format. It is converted to planar float32 in Go when needed, so this works with
+// every version of the NDI library. This call is syncronous and will block until the frame has been sent, if you
+// specified clockAudio=true in NewNDISendInstance().
+func (p *SendInstance) SendAudio(buf *AudioBuffer) error {
+	af, err := buf.FrameV2()
+	if err != nil {
+		return err
+	}
+	p.SendAudioFrame(af)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package gondi
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAudioBufferFormats(t *testing.T) {
+	src := NewAudioBuffer(AudioFormatFloat32Planar, 48000, 2, 3)
+	copy(src.Float32, []float32{0, 0.5, -0.25, 1, -1, 0.125})
+
+	for _, format := range []AudioFormat{AudioFormatFloat32Interleaved, AudioFormatInt16Interleaved, AudioFormatInt32Interleaved} {
+		converted, err := src.Convert(format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		back, err := converted.Convert(AudioFormatFloat32Planar)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range src.Float32 {
+			// Full scale is clipped to the largest integer
+			if got := back.Float32[i]; got != want && !(want == 1 && got > 0.9999) {
+				t.Errorf("format %d: sample %d is %v, want %v", format, i, got, want)
+			}
+		}
+	}
+
+	interleaved, _ := src.Convert(AudioFormatInt16Interleaved)
+	if want := []int16{0, 32767, 16384, -32768, -8192, 4096}; !slices.Equal(interleaved.Int16, want) {
+		t.Errorf("int16 samples are %v, want %v", interleaved.Int16, want)
+	}
+
+	if _, err := (&AudioBuffer{NumChannels: 2, NumSamples: 4, Float32: make([]float32, 7)}).Convert(AudioFormatInt16Interleaved); err == nil {
+		t.Error("short buffer converted")
+	}
+}
+
+func TestAudioFrameStride(t *testing.T) {
+	// Two channels of three samples, each channel padded to four samples
+	data := []float32{1, 2, 3, 99, 4, 5, 6, 99}
+	af := &AudioFrameV2{SampleRate: 48000, NumChannels: 2, NumSamples: 3, Data: &data[0], ChannelStride: 16}
+
+	if got := af.GetArray(); len(got) != 6 || got[3] != 4 || got[5] != 6 {
+		t.Errorf("array is %v", got)
+	}
+	if got := af.GetInterleavedArray(); len(got) != 6 || got[1] != 4 || got[4] != 3 {
+		t.Errorf("interleaved array is %v", got)
+	}
+
+	// Tightly packed channels are not copied
+	packed := &AudioFrameV2{SampleRate: 48000, NumChannels: 2, NumSamples: 4, Data: &data[0], ChannelStride: 16}
+	if got := packed.GetArray(); &got[0] != &data[0] || len(got) != 8 {
+		t.Error("array of packed channels is a copy")
+	}
+	if got := packed.CopyArray(); &got[0] == &data[0] || got[4] != 4 {
+		t.Errorf("copied array is %v", got)
+	}
+
+	af.SetArray([]float32{-1, -2, -3, -4, -5, -6})
+	if data[3] != 99 || data[4] != -4 || data[7] != 99 {
+		t.Errorf("SetArray wrote %v", data)
+	}
+
+	buf := AudioBufferFromV2(af)
+	if buf.NumChannels != 2 || buf.NumSamples != 3 || buf.Float32[3] != -4 {
+		t.Errorf("buffer is %+v", buf)
+	}
+	buf.ChannelStride = 4
+	buf.Float32 = data
+	frame, err := buf.FrameV3()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if frame.ChannelStride != 16 || frame.Channel(1)[2] != -6 {
+		t.Errorf("frame has stride %d and channel %v", frame.ChannelStride, frame.Channel(1))
+	}
+
+	preallocated := NewAudioFrameV2Preallocated(2, 4)
+	if preallocated.NumChannels != 2 || preallocated.NumSamples != 4 || preallocated.ChannelStride != 16 {
+		t.Errorf("preallocated frame has %d channels of %d samples", preallocated.NumChannels, preallocated.NumSamples)
+	}
+}
+
+func TestSendAudio(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "audio buffers")
+
+	buf := NewAudioBuffer(AudioFormatInt16Interleaved, 48000, 2, 2)
+	copy(buf.Int16, []int16{16384, -16384, 8192, -8192})
+	if err := sender.SendAudio(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	received := NewAudioFrameV2()
+	if ft := receiver.CaptureV2(nil, received, nil, 1000); ft != FrameTypeAudio {
+		t.Fatalf("capture returned %d, want audio", ft)
+	}
+	defer receiver.FreeAudioV2(received)
+
+	want := []float32{0.5, 0.25, -0.5, -0.25}
+	got := received.GetArray()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("received %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package audioproc changes the sample rate and the channel layout of audio on its way from a receiver to a sender.
+//
+// A Processor is made of the two stages, a Matrix routing, muting, duplicating or downmixing channels, then a
+// Resampler. They can also be used on their own. Everything works on gondi.AudioBuffer, in pure Go.
+package audioproc
+
+import (
+	"errors"
+
+	"github.com/benitogf/gondi"
+)
+
+// Configuration of a Processor, see the Option functions
+type Options struct {
+	// Sample rate of the output, 0 to keep the one of the input
+	SampleRate int
+
+	// Quality of the sample rate conversion
+	Quality Quality
+
+	// Channel routing, nil to keep the channels of the input. Ignored when Stereo is set.
+	Matrix Matrix
+
+	// Mix any channel layout Stereo supports down to stereo, picking the matrix from the input
+	Stereo bool
+}
+
+// Options for NewProcessor
+type Option func(*Options)
+
+// Resample the audio to this rate with the given quality
+func WithSampleRate(rate int, quality Quality) Option {
+	return func(o *Options) {
+		o.SampleRate = rate
+		o.Quality = quality
+	}
+}
+
+// Route the channels with this matrix
+func WithMatrix(m Matrix) Option {
+	return func(o *Options) {
+		o.Matrix = m
+	}
+}
+
+// Mix mono, 5.1 and 7.1 audio to stereo, and keep stereo as is
+func WithStereo() Option {
+	return func(o *Options) {
+		o.Stereo = true
+	}
+}
+
+// Processor routes the channels of a stream of audio buffers and resamples them. Like a Resampler it keeps state
+// between buffers, so use one Processor per stream. It is not safe for concurrent use.
+type Processor struct {
+	options   Options
+	resampler *Resampler
+}
+
+// Create a processor, one without options passes the audio through
+func NewProcessor(options ...Option) (*Processor, error) {
+	p := &Processor{}
+	for _, option := range options {
+		option(&p.options)
+	}
+
+	if p.options.SampleRate < 0 {
+		return nil, errors.New("unable to resample to a negative rate")
+	}
+	if p.options.SampleRate > 0 {
+		resampler, err := NewResampler(p.options.SampleRate, p.options.Quality)
+		if err != nil {
+			return nil, err
+		}
+		p.resampler = resampler
+	}
+
+	return p, nil
+}
+
+// Forget the end of the previous buffer, for when the next buffer doesn't follow it
+func (p *Processor) Reset() {
+	if p.resampler != nil {
+		p.resampler.Reset()
+	}
+}
+
+// Process a buffer, returning planar float32 audio. The channels are routed before resampling, so downmixing
+// resamples fewer channels.
+func (p *Processor) Process(buf *gondi.AudioBuffer) (*gondi.AudioBuffer, error) {
+	out, err := buf.Planar()
+	if err != nil {
+		return nil, err
+	}
+
+	m := p.options.Matrix
+	if p.options.Stereo {
+		if m, err = Stereo(buf.NumChannels); err != nil {
+			return nil, err
+		}
+	}
+	if m != nil {
+		if out, err = m.Apply(out); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.resampler != nil {
+		return p.resampler.Process(out)
+	}
+
+	return out, nil
+}
+
+// Process a received frame, see Process. The frame can be freed as soon as this returns.
+func (p *Processor) ProcessFrame(af *gondi.AudioFrameV2) (*gondi.AudioBuffer, error) {
+	return p.Process(gondi.AudioBufferFromV2(af))
+}
@@ -0,0 +1,146 @@
+package audioproc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/benitogf/gondi"
+)
+
+// Resample a tone in chunks, returning the output samples of the first channel
+func resampleTone(t *testing.T, r *Resampler, rate int, frequency float64, chunk int, chunks int) []float32 {
+	t.Helper()
+	var out []float32
+	for c := 0; c < chunks; c++ {
+		buf := gondi.NewAudioBuffer(gondi.AudioFormatFloat32Interleaved, rate, 2, chunk)
+		for i := 0; i < chunk; i++ {
+			v := float32(math.Sin(2 * math.Pi * frequency * float64(c*chunk+i) / float64(rate)))
+			buf.SetSample(0, i, v)
+			buf.SetSample(1, i, -v)
+		}
+		resampled, err := r.Process(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resampled.SampleRate != r.Rate() || resampled.NumChannels != 2 {
+			t.Fatalf("resampled to %d channels at %d Hz", resampled.NumChannels, resampled.SampleRate)
+		}
+		out = append(out, resampled.Float32[:resampled.NumSamples]...)
+	}
+	return out
+}
+
+func rms(samples []float32) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestResampler(t *testing.T) {
+	for _, quality := range []Quality{QualityLow, QualityMedium, QualityHigh} {
+		r, err := NewResampler(48000, quality)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The output follows the tone across chunks, after the silence the kernel starts with
+		out := resampleTone(t, r, 44100, 1000, 441, 20)
+		if want := 8820 * 48000 / 44100; len(out) < want-80 || len(out) > want {
+			t.Errorf("quality %d: %d samples out, want about %d", quality, len(out), want)
+		}
+		tolerance := 0.002
+		if quality == QualityLow {
+			tolerance = 0.02
+		}
+		for n := 100; n < len(out); n++ {
+			want := math.Sin(2 * math.Pi * 1000 * float64(n) / 48000)
+			if math.Abs(float64(out[n])-want) > tolerance {
+				t.Fatalf("quality %d: sample %d is %v, want %v", quality, n, out[n], want)
+			}
+		}
+	}
+
+	// Downsampling filters what the output rate can't hold
+	r, _ := NewResampler(22050, QualityHigh)
+	if level := rms(resampleTone(t, r, 48000, 16000, 480, 20)[200:]); level > 0.01 {
+		t.Errorf("16 kHz tone is at %f after downsampling to 22.05 kHz", level)
+	}
+	r, _ = NewResampler(22050, QualityHigh)
+	if level := rms(resampleTone(t, r, 48000, 5000, 480, 20)[200:]); math.Abs(level-math.Sqrt2/2) > 0.01 {
+		t.Errorf("5 kHz tone is at %f after downsampling to 22.05 kHz", level)
+	}
+
+	if _, err := NewResampler(0, QualityMedium); err == nil {
+		t.Error("resampler to 0 Hz created")
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	buf := gondi.NewAudioBuffer(gondi.AudioFormatFloat32Planar, 48000, 6, 1)
+	copy(buf.Float32, []float32{0.1, 0.2, 0.3, 0.4, 0.5, 0.6})
+
+	swapped, err := Remap(6, 1, 0, -1, 4, 4).Apply(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := swapped.Float32; len(got) != 5 || got[0] != 0.2 || got[1] != 0.1 || got[2] != 0 || got[3] != 0.5 || got[4] != 0.5 {
+		t.Errorf("remapped to %v", got)
+	}
+
+	stereo, err := Downmix51().Apply(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	left, right := 0.1+minus3dB*0.3+minus3dB*0.5, 0.2+minus3dB*0.3+minus3dB*0.6
+	if math.Abs(float64(stereo.Float32[0])-left) > 1e-6 || math.Abs(float64(stereo.Float32[1])-right) > 1e-6 {
+		t.Errorf("downmixed to %v, want %f %f", stereo.Float32, left, right)
+	}
+
+	for _, row := range Downmix71().Normalize() {
+		var sum float32
+		for _, gain := range row {
+			sum += gain
+		}
+		if math.Abs(float64(sum)-1) > 1e-6 {
+			t.Errorf("normalized gains add up to %f", sum)
+		}
+	}
+
+	if _, err := Downmix71().Apply(buf); err == nil {
+		t.Error("6 channels routed with a 7.1 matrix")
+	}
+	if _, err := Stereo(3); err == nil {
+		t.Error("3 channels downmixed")
+	}
+}
+
+func TestProcessor(t *testing.T) {
+	p, err := NewProcessor(WithSampleRate(48000, QualityMedium), WithStereo())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 5.1 at 44.1 kHz with only the center channel
+	buf := gondi.NewAudioBuffer(gondi.AudioFormatInt16Interleaved, 44100, 6, 4410)
+	for i := 0; i < buf.NumSamples; i++ {
+		buf.SetSample(2, i, 0.5)
+	}
+	out, err := p.Process(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.SampleRate != 48000 || out.NumChannels != 2 || out.Format != gondi.AudioFormatFloat32Planar {
+		t.Fatalf("processed to %d channels at %d Hz", out.NumChannels, out.SampleRate)
+	}
+	if got := out.Sample(1, out.NumSamples/2); math.Abs(float64(got)-0.5*minus3dB) > 0.001 {
+		t.Errorf("center is at %f in the right channel, want %f", got, 0.5*minus3dB)
+	}
+
+	// Without options the audio goes through
+	p, _ = NewProcessor()
+	if out, err := p.Process(buf); err != nil || out.NumChannels != 6 || out.SampleRate != 44100 {
+		t.Errorf("passed through %+v, %v", out, err)
+	}
+}
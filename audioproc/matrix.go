@@ -0,0 +1,127 @@
+package audioproc
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/benitogf/gondi"
+)
+
+// Matrix routes input channels to output channels: output channel o is the sum of every input channel i times
+// m[o][i]. Every row must have one gain per input channel.
+type Matrix [][]float32
+
+// Gain of -3 dB, what the center and surround channels are mixed at in a downmix
+const minus3dB = math.Sqrt2 / 2
+
+// Route each channel to itself
+func Identity(channels int) Matrix {
+	m := make(Matrix, channels)
+	for o := range m {
+		m[o] = make([]float32, channels)
+		m[o][o] = 1
+	}
+	return m
+}
+
+// Pick an input channel for each output channel, -1 for silence. An input can be used by several outputs, or by none.
+// Remap(2, 1, 0) swaps left and right, Remap(1, 0, 0) makes stereo out of mono.
+func Remap(inputs int, sources ...int) Matrix {
+	m := make(Matrix, len(sources))
+	for o, i := range sources {
+		m[o] = make([]float32, inputs)
+		if i >= 0 && i < inputs {
+			m[o][i] = 1
+		}
+	}
+	return m
+}
+
+// Downmix 5.1 in the L, R, C, LFE, Ls, Rs order to stereo, as ITU-R BS.775 does: the center and the surrounds are
+// mixed at -3 dB and the LFE is dropped. Loud programs may clip, see Normalize.
+func Downmix51() Matrix {
+	return Matrix{
+		{1, 0, minus3dB, 0, minus3dB, 0},
+		{0, 1, minus3dB, 0, 0, minus3dB},
+	}
+}
+
+// Downmix 7.1 in the L, R, C, LFE, Ls, Rs, Lrs, Rrs order to stereo, like Downmix51 with the rear surrounds mixed
+// as the side ones.
+func Downmix71() Matrix {
+	return Matrix{
+		{1, 0, minus3dB, 0, minus3dB, 0, minus3dB, 0},
+		{0, 1, minus3dB, 0, 0, minus3dB, 0, minus3dB},
+	}
+}
+
+// Get the matrix mixing this many channels down to stereo: mono is duplicated, stereo kept, and 5.1 and 7.1 downmixed.
+func Stereo(inputs int) (Matrix, error) {
+	switch inputs {
+	case 1:
+		return Remap(1, 0, 0), nil
+	case 2:
+		return Identity(2), nil
+	case 6:
+		return Downmix51(), nil
+	case 8:
+		return Downmix71(), nil
+	}
+	return nil, fmt.Errorf("unable to downmix %d channels to stereo", inputs)
+}
+
+// The number of input channels of the matrix
+func (m Matrix) Inputs() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+// Get a copy of the matrix where no output can go over full scale, by scaling down the rows whose gains add up to more
+// than one.
+func (m Matrix) Normalize() Matrix {
+	normalized := make(Matrix, len(m))
+	for o, row := range m {
+		var sum float32
+		for _, gain := range row {
+			sum += float32(math.Abs(float64(gain)))
+		}
+		normalized[o] = make([]float32, len(row))
+		for i, gain := range row {
+			if sum > 1 {
+				gain /= sum
+			}
+			normalized[o][i] = gain
+		}
+	}
+	return normalized
+}
+
+// Route the channels of a buffer, returning planar float32 audio with one channel per row of the matrix
+func (m Matrix) Apply(buf *gondi.AudioBuffer) (*gondi.AudioBuffer, error) {
+	for o, row := range m {
+		if len(row) != buf.NumChannels {
+			return nil, fmt.Errorf("unable to route %d channels with %d gains for output %d", buf.NumChannels, len(row), o)
+		}
+	}
+	if err := buf.Validate(); err != nil {
+		return nil, err
+	}
+
+	out := gondi.NewAudioBuffer(gondi.AudioFormatFloat32Planar, buf.SampleRate, len(m), buf.NumSamples)
+	out.Timecode = buf.Timecode
+	for o, row := range m {
+		samples := out.Float32[o*buf.NumSamples : (o+1)*buf.NumSamples]
+		for i, gain := range row {
+			if gain == 0 {
+				continue
+			}
+			for s := range samples {
+				samples[s] += gain * buf.Sample(i, s)
+			}
+		}
+	}
+
+	return out, nil
+}
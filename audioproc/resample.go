@@ -0,0 +1,178 @@
+package audioproc
+
+import (
+	"errors"
+	"math"
+
+	"github.com/benitogf/gondi"
+)
+
+// Quality of the sample rate conversion, trading CPU for less aliasing
+type Quality int
+
+const (
+	// Windowed sinc over 16 input samples, good for most program audio. The default.
+	QualityMedium Quality = iota
+
+	// Linear interpolation, cheap but audibly aliasing on high frequencies
+	QualityLow
+
+	// Windowed sinc over 64 input samples, for music and mastering
+	QualityHigh
+)
+
+// Points of the kernel table per input sample of the kernel half width
+const kernelResolution = 256
+
+// Half width of the kernel in input samples when upsampling, and its table
+func kernel(quality Quality) (int, []float64) {
+	half := 8
+	switch quality {
+	case QualityLow:
+		half = 1
+	case QualityHigh:
+		half = 32
+	}
+
+	table := make([]float64, half*kernelResolution+2)
+	for i := range table {
+		u := float64(i) / float64(len(table)-2)
+		if u >= 1 {
+			continue
+		}
+		if quality == QualityLow {
+			table[i] = 1 - u
+			continue
+		}
+		// Blackman windowed sinc, u is the distance from the center over the half width
+		x := u * float64(half)
+		sinc := 1.0
+		if x != 0 {
+			sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		table[i] = sinc * (0.42 + 0.5*math.Cos(math.Pi*u) + 0.08*math.Cos(2*math.Pi*u))
+	}
+
+	return half, table
+}
+
+// Resampler converts the sample rate of a stream of audio buffers. It keeps the end of each buffer to filter the
+// start of the next one, so the output has no clicks between buffers and is delayed by half the kernel.
+//
+// A Resampler is not safe for concurrent use.
+type Resampler struct {
+	rate  int
+	half  int
+	table []float64
+
+	// State of the stream, reset when the input rate or channel count changes
+	inputRate int
+	channels  int
+	width     float64
+	history   [][]float32
+	pos       float64
+}
+
+// Create a resampler converting any sample rate to this one
+func NewResampler(rate int, quality Quality) (*Resampler, error) {
+	if rate <= 0 {
+		return nil, errors.New("unable to resample to a rate that is not positive")
+	}
+	r := &Resampler{rate: rate}
+	r.half, r.table = kernel(quality)
+
+	return r, nil
+}
+
+// The sample rate of the output
+func (r *Resampler) Rate() int {
+	return r.rate
+}
+
+// Forget the end of the previous buffer, for when the next buffer doesn't follow it
+func (r *Resampler) Reset() {
+	r.inputRate, r.channels, r.history = 0, 0, nil
+}
+
+func (r *Resampler) start(inputRate int, channels int) {
+	r.inputRate, r.channels = inputRate, channels
+
+	// The kernel is widened when downsampling so it filters what the output rate can't hold
+	r.width = float64(r.half) * max(1, float64(inputRate)/float64(r.rate))
+	taps := int(math.Ceil(r.width))
+	r.history = make([][]float32, channels)
+	for ch := range r.history {
+		r.history[ch] = make([]float32, taps)
+	}
+	r.pos = float64(taps)
+}
+
+// Resample a buffer to planar float32 audio at the rate of the resampler. A buffer already at that rate is returned
+// as is when planar, and converted otherwise. The output has about NumSamples * rate / SampleRate samples, depending
+// on what is left from the previous buffers.
+func (r *Resampler) Process(buf *gondi.AudioBuffer) (*gondi.AudioBuffer, error) {
+	if buf.SampleRate <= 0 {
+		return nil, errors.New("unable to resample audio without a sample rate")
+	}
+	if buf.SampleRate == r.rate {
+		r.Reset()
+		return buf.Planar()
+	}
+	in, err := buf.Planar()
+	if err != nil {
+		return nil, err
+	}
+	if in.SampleRate != r.inputRate || in.NumChannels != r.channels {
+		r.start(in.SampleRate, in.NumChannels)
+	}
+
+	if in.NumChannels == 0 {
+		return gondi.NewAudioBuffer(gondi.AudioFormatFloat32Planar, r.rate, 0, 0), nil
+	}
+
+	taps := int(math.Ceil(r.width))
+	step := float64(r.inputRate) / float64(r.rate)
+	total := len(r.history[0]) + in.NumSamples
+	count := 0
+	for pos := r.pos; int(pos)+taps < total; pos += step {
+		count++
+	}
+
+	out := gondi.NewAudioBuffer(gondi.AudioFormatFloat32Planar, r.rate, in.NumChannels, count)
+	out.Timecode = in.Timecode
+	scale := float64(len(r.table)-2) / r.width
+	end := r.pos + float64(count)*step
+	drop := min(max(int(end)-taps+1, 0), total)
+	stride := in.ChannelStride
+	if stride == 0 {
+		stride = in.NumSamples
+	}
+
+	for ch := 0; ch < in.NumChannels; ch++ {
+		data := append(r.history[ch], in.Float32[ch*stride:ch*stride+in.NumSamples]...)
+		samples := out.Float32[ch*count : (ch+1)*count]
+		pos := r.pos
+		for i := range samples {
+			center := int(pos)
+			var sum, weights float64
+			for j := center - taps + 1; j <= center+taps; j++ {
+				u := math.Abs(pos-float64(j)) * scale
+				k := int(u)
+				if k >= len(r.table)-2 {
+					continue
+				}
+				w := r.table[k] + (r.table[k+1]-r.table[k])*(u-float64(k))
+				sum += w * float64(data[j])
+				weights += w
+			}
+			if weights != 0 {
+				samples[i] = float32(sum / weights)
+			}
+			pos += step
+		}
+		r.history[ch] = append(r.history[ch][:0], data[drop:]...)
+	}
+	r.pos = end - float64(drop)
+
+	return out, nil
+}
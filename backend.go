@@ -0,0 +1,111 @@
+package gondi
+
+// Backend is the set of NDI operations used by every instance type in this package. InitLibrary installs a backend
+// that calls into the NDI shared library, while UseBackend allows replacing it, for instance with the in-process
+// LoopbackBackend when running tests on machines without the NDI runtime.
+//
+// Instance handles are opaque values chosen by the backend, and a handle of 0 means the creation failed. Methods
+// returning an error return ErrNotSupported when the backend does not implement the feature.
+type Backend interface {
+	// Get the version of the NDI library as string
+	Version() string
+
+	// Report the optional features implemented by the backend
+	Capabilities() SDKCapabilities
+
+	SendCreate(name string, groups string, clockVideo bool, clockAudio bool) uintptr
+	SendDestroy(instance uintptr)
+	SendVideoV2(instance uintptr, frame *VideoFrameV2)
+	SendVideoAsyncV2(instance uintptr, frame *VideoFrameV2)
+	SendAudioV2(instance uintptr, frame *AudioFrameV2)
+	SendAudioV3(instance uintptr, frame *AudioFrameV3) error
+	SendAudioInterleaved16s(instance uintptr, frame *AudioFrameV3) error
+	SendAudioInterleaved32f(instance uintptr, frame *AudioFrameV3) error
+	SendMetadata(instance uintptr, frame *MetadataFrame)
+	SendCapture(instance uintptr, frame *MetadataFrame, timeoutMs uint32) FrameType
+	SendFreeMetadata(instance uintptr, frame *MetadataFrame)
+	SendGetTally(instance uintptr, tally *Tally, timeoutMs uint32) bool
+	SendGetNoConnections(instance uintptr, timeoutMs uint32) (int32, error)
+	SendAddConnectionMetadata(instance uintptr, frame *MetadataFrame)
+	SendClearConnectionMetadata(instance uintptr)
+	SendSetFailover(instance uintptr, source *Source) error
+
+	FindCreateV2(showLocalSources bool, groups string, extraIPs string) uintptr
+	FindDestroy(instance uintptr)
+	FindGetCurrentSources(instance uintptr) []*Source
+	FindWaitForSources(instance uintptr, timeoutMs uint32) bool
+
+	RecvCreateV3(settings *NewRecvInstanceSettings) uintptr
+	RecvDestroy(instance uintptr)
+	RecvConnect(instance uintptr, source *Source)
+	RecvCaptureV2(instance uintptr, vf *VideoFrameV2, af *AudioFrameV2, mf *MetadataFrame, timeoutMs uint32) FrameType
+	RecvCaptureV3(instance uintptr, vf *VideoFrameV2, af *AudioFrameV3, mf *MetadataFrame, timeoutMs uint32) (FrameType, error)
+	RecvFreeVideoV2(instance uintptr, frame *VideoFrameV2)
+	RecvFreeAudioV2(instance uintptr, frame *AudioFrameV2)
+	RecvFreeAudioV3(instance uintptr, frame *AudioFrameV3) error
+	RecvFreeMetadata(instance uintptr, frame *MetadataFrame)
+	RecvGetPerformance(instance uintptr, total *RecvPerformance, dropped *RecvPerformance)
+	RecvSetTally(instance uintptr, tally *Tally) bool
+	RecvSendMetadata(instance uintptr, frame *MetadataFrame) bool
+	RecvAddConnectionMetadata(instance uintptr, frame *MetadataFrame) bool
+	RecvClearConnectionMetadata(instance uintptr)
+	RecvPTZIsSupported(instance uintptr) (bool, error)
+	RecvPTZ(instance uintptr, command PTZCommand) (bool, error)
+
+	FrameSyncCreate(receiver uintptr) (uintptr, error)
+	FrameSyncDestroy(instance uintptr)
+	FrameSyncCaptureVideo(instance uintptr, frame *VideoFrameV2, fieldType FrameFormat)
+	FrameSyncFreeVideo(instance uintptr, frame *VideoFrameV2)
+	FrameSyncCaptureAudio(instance uintptr, frame *AudioFrameV2, sampleRate int32, numChannels int32, numSamples int32)
+	FrameSyncFreeAudio(instance uintptr, frame *AudioFrameV2)
+	FrameSyncAudioQueueDepth(instance uintptr) int32
+
+	RoutingCreate(name string, groups string) (uintptr, error)
+	RoutingDestroy(instance uintptr)
+	RoutingChange(instance uintptr, source *Source) bool
+	RoutingClear(instance uintptr) bool
+
+	AudioFromInterleaved32fV2(src *AudioFrameV2, dst *AudioFrameV2)
+	AudioToInterleaved32fV2(src *AudioFrameV2, dst *AudioFrameV2)
+
+	// Release everything held by the backend, called by Shutdown once every instance is destroyed.
+	Destroy() error
+}
+
+// The backend used by all instances, nil until InitLibrary or UseBackend is called. Guarded by lifecycleMu, read it
+// with currentBackend.
+var backend Backend
+
+// Use the given backend for every instance created from now on. This replaces the need to call InitLibrary, and counts
+// as its first reference, so a single call to Shutdown destroys the backend. Passing nil returns the package to its
+// uninitialized state without destroying anything. Instances created with the previous backend must be destroyed
+// before switching.
+func UseBackend(b Backend) {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	backend = b
+	refs = 0
+	if b != nil {
+		refs = 1
+	}
+}
+
+// Get the backend in use, nil when uninitialized
+func loadBackend() Backend {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	return backend
+}
+
+// Get the backend to call, panicking when uninitialized. Callers take it once, so a concurrent UseBackend or Shutdown
+// doesn't change the backend in the middle of an operation.
+func currentBackend() Backend {
+	b := loadBackend()
+	if b == nil {
+		panic("library not initialized, use gondi.InitLibrary()")
+	}
+
+	return b
+}
@@ -0,0 +1,46 @@
+package gondi
+
+// The optional features of the NDI library in use. Runtimes of different NDI versions export different sets of
+// functions, the features they lack make the related methods return ErrNotSupported.
+type SDKCapabilities struct {
+	// Audio frames in the v3 format can be sent and captured, see SendAudioFrameV3 and CaptureV3
+	AudioV3 bool
+
+	// Interleaved 16 bit integer and 32 bit float audio can be sent, see SendAudioFrame16s and SendAudioFrame32f
+	InterleavedAudio bool
+
+	// The number of receivers connected to a sender can be queried, see GetNumberOfConnections
+	ConnectionCount bool
+
+	// A fail-over source can be set on senders, see SetFailover
+	Failover bool
+
+	// Routing instances can be created, see NewRoutingInstance
+	Routing bool
+
+	// PTZ cameras can be controlled from receivers
+	PTZ bool
+
+	// Frame synchronizers can be created from receivers
+	FrameSync bool
+
+	// Keyboard, video and mouse control of sources is available
+	KVM bool
+
+	// Receivers can be discovered through an NDI discovery server listener
+	Listener bool
+
+	// Receivers can be advertised to an NDI discovery server
+	Advertiser bool
+
+	// Senders can be genlocked to other sources
+	Genlock bool
+
+	// The functions used by this package that the library does not export
+	Missing []string
+}
+
+// Report which optional features are available in the NDI library, or the backend, in use.
+func Capabilities() SDKCapabilities {
+	return currentBackend().Capabilities()
+}
@@ -0,0 +1,136 @@
+package gondi
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// How long the command server waits for metadata before checking its context
+const commandServerPollMs = 100
+
+// A metadata frame a receiver sent upstream to a sender, see RecvInstance.SendMetadata
+type Command struct {
+	// Name of the root element of the frame, used to find the handler
+	Element string
+
+	// The whole frame
+	Data string
+
+	Timecode int64
+}
+
+// CommandHandler handles the commands with a given element. A reply that is not empty is sent to the receivers as
+// metadata. The SDK can't send to a single connection, so every connected receiver gets it. Errors are logged with the
+// logger of the sender.
+type CommandHandler func(command Command) (reply string, err error)
+
+// CommandServer captures the metadata receivers send upstream to a SendInstance, and passes every frame to the
+// handler registered for the name of its root element. It is the base for PTZ emulation, remote control commands and
+// anything else an application source answers to.
+//
+// Frames without a matching handler go to the default handler, or are logged and dropped when there is none.
+type CommandServer struct {
+	sender *SendInstance
+
+	mu       sync.RWMutex
+	handlers map[string]CommandHandler
+	fallback CommandHandler
+}
+
+// Create a command server for a sender. Register the handlers, then call Serve.
+func NewCommandServer(sender *SendInstance) *CommandServer {
+	return &CommandServer{
+		sender:   sender,
+		handlers: map[string]CommandHandler{},
+	}
+}
+
+// The sender the commands are captured from
+func (s *CommandServer) Sender() *SendInstance {
+	return s.sender
+}
+
+// Handle the commands with the given root element. Handlers can be changed while serving, a nil handler removes the
+// element.
+func (s *CommandServer) Handle(element string, handler CommandHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if handler == nil {
+		delete(s.handlers, element)
+		return
+	}
+	s.handlers[element] = handler
+}
+
+// Handle the commands no other handler is registered for
+func (s *CommandServer) HandleDefault(handler CommandHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fallback = handler
+}
+
+// Capture and dispatch commands until the context is done, returning its error. Handlers are called one at a time
+// from the goroutine calling Serve, so a slow handler delays the next commands. The sender must not be destroyed
+// before Serve returned.
+func (s *CommandServer) Serve(ctx context.Context) error {
+	frame := &MetadataFrame{}
+	for ctx.Err() == nil {
+		switch s.sender.Capture(frame, commandServerPollMs) {
+		case FrameTypeMetadata:
+			command := Command{Data: frame.GetData(), Timecode: frame.Timecode}
+			s.sender.FreeMetadata(frame)
+			s.dispatch(command)
+		case FrameTypeError:
+			return errors.New("unable to capture metadata from the sender")
+		}
+	}
+
+	return ctx.Err()
+}
+
+func (s *CommandServer) dispatch(command Command) {
+	element, err := rootElement(command.Data)
+	if err != nil {
+		s.sender.logf("sender %q dropped invalid metadata %q: %v", s.sender.name, command.Data, err)
+		return
+	}
+	command.Element = element
+
+	s.mu.RLock()
+	handler, ok := s.handlers[element]
+	if !ok {
+		handler = s.fallback
+	}
+	s.mu.RUnlock()
+
+	if handler == nil {
+		s.sender.logf("sender %q has no handler for %s", s.sender.name, element)
+		return
+	}
+	reply, err := handler(command)
+	if err != nil {
+		s.sender.logf("sender %q failed to handle %s: %v", s.sender.name, element, err)
+	}
+	if reply != "" {
+		s.sender.SendMetadataFrame(NewMetadataFrame(reply))
+	}
+}
+
+// Get the name of the first element of a metadata frame
+func rootElement(data string) (string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package gondi
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCommandServer(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "commands")
+	logger := &testLogger{}
+	sender.logger = logger
+
+	server := NewCommandServer(sender)
+	server.Handle("ping", func(command Command) (string, error) {
+		if command.Data != `<ping id="7"/>` {
+			return "", errors.New("unexpected ping")
+		}
+		return `<pong id="7"/>`, nil
+	})
+	server.Handle("fail", func(command Command) (string, error) {
+		return "", errors.New("failed on purpose")
+	})
+	unknown := make(chan Command, 1)
+	server.HandleDefault(func(command Command) (string, error) {
+		unknown <- command
+		return "", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- server.Serve(ctx) }()
+
+	for _, data := range []string{`<fail/>`, `<other a="1"/>`, `<ping id="7"/>`} {
+		if !receiver.SendMetadata(NewMetadataFrame(data)) {
+			t.Fatalf("unable to send %s", data)
+		}
+	}
+
+	command := <-unknown
+	if command.Element != "other" || command.Data != `<other a="1"/>` {
+		t.Errorf("default handler got %+v", command)
+	}
+
+	mf := &MetadataFrame{}
+	for {
+		ft := receiver.CaptureV2(nil, nil, mf, 1000)
+		if ft == FrameTypeStatusChange {
+			continue
+		}
+		if ft != FrameTypeMetadata {
+			t.Fatalf("captured %d, want the reply", ft)
+		}
+		break
+	}
+	if reply := mf.GetData(); reply != `<pong id="7"/>` {
+		t.Errorf("got reply %s", reply)
+	}
+	receiver.FreeMetadata(mf)
+
+	cancel()
+	if err := <-served; err != context.Canceled {
+		t.Errorf("Serve returned %v", err)
+	}
+	if len(logger.lines) != 1 {
+		t.Errorf("logged %q, want the failure only", logger.lines)
+	}
+}
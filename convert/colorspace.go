@@ -0,0 +1,116 @@
+package convert
+
+// Matrix used between YCbCr and RGB
+type Matrix int
+
+const (
+	// BT.601 below 720 lines, BT.709 otherwise, as NDI does
+	MatrixAuto Matrix = iota
+	BT601
+	BT709
+)
+
+// Range of the YCbCr codes
+type Range int
+
+const (
+	// Luma from 16 to 235 and chroma from 16 to 240, in 8 bits
+	VideoRange Range = iota
+	// Luma and chroma from 0 to 255, in 8 bits
+	FullRange
+)
+
+// Coefficients of a matrix and range, for 16 bit codes. YCbCr codes are the 8 bit levels shifted by 8, RGB goes from
+// 0 to 65535. Multipliers are fixed point with 16 fractional bits.
+type colorSpace struct {
+	yOff int64
+
+	// YCbCr to RGB
+	ky, crR, cbG, crG, cbB int64
+
+	// RGB to YCbCr
+	yR, yG, yB    int64
+	cbR, cbG2, cb int64
+	cr, crG2, crB int64
+}
+
+func newColorSpace(matrix Matrix, colorRange Range, height int) *colorSpace {
+	if matrix == MatrixAuto {
+		matrix = BT709
+		if height < 720 {
+			matrix = BT601
+		}
+	}
+	kr, kb := 0.2126, 0.0722
+	if matrix == BT601 {
+		kr, kb = 0.299, 0.114
+	}
+	kg := 1 - kr - kb
+
+	yOff, yRange, cRange := 16.0*256, 219.0*256, 224.0*256
+	if colorRange == FullRange {
+		yOff, yRange, cRange = 0, 255*256, 255*256
+	}
+
+	fixed := func(v float64) int64 {
+		if v < 0 {
+			return int64(v*65536 - 0.5)
+		}
+		return int64(v*65536 + 0.5)
+	}
+	toRGB := 65535 / cRange
+	toYUV := cRange / 65535
+
+	return &colorSpace{
+		yOff: int64(yOff),
+
+		ky:  fixed(65535 / yRange),
+		crR: fixed(2 * (1 - kr) * toRGB),
+		cbG: fixed(-2 * kb * (1 - kb) / kg * toRGB),
+		crG: fixed(-2 * kr * (1 - kr) / kg * toRGB),
+		cbB: fixed(2 * (1 - kb) * toRGB),
+
+		yR: fixed(kr * yRange / 65535),
+		yG: fixed(kg * yRange / 65535),
+		yB: fixed(kb * yRange / 65535),
+
+		cbR:  fixed(-kr / (2 * (1 - kb)) * toYUV),
+		cbG2: fixed(-kg / (2 * (1 - kb)) * toYUV),
+		cb:   fixed(0.5 * toYUV),
+		cr:   fixed(0.5 * toYUV),
+		crG2: fixed(-kg / (2 * (1 - kr)) * toYUV),
+		crB:  fixed(-kb / (2 * (1 - kr)) * toYUV),
+	}
+}
+
+func clamp16(v int64) uint16 {
+	if v < 0 {
+		return 0
+	}
+	if v > 65535 {
+		return 65535
+	}
+	return uint16(v)
+}
+
+// Convert a row of Y, Cb, Cr, A pixels to R, G, B, A in place
+func (cs *colorSpace) toRGB(row []uint16) {
+	for i := 0; i+3 < len(row); i += 4 {
+		y := (int64(row[i]) - cs.yOff) * cs.ky
+		cb := int64(row[i+1]) - 32768
+		cr := int64(row[i+2]) - 32768
+		row[i] = clamp16((y + cr*cs.crR + 1<<15) >> 16)
+		row[i+1] = clamp16((y + cb*cs.cbG + cr*cs.crG + 1<<15) >> 16)
+		row[i+2] = clamp16((y + cb*cs.cbB + 1<<15) >> 16)
+	}
+}
+
+// Convert a row of R, G, B, A pixels to Y, Cb, Cr, A in place
+func (cs *colorSpace) toYUV(row []uint16) {
+	for i := 0; i+3 < len(row); i += 4 {
+		r, g, b := int64(row[i]), int64(row[i+1]), int64(row[i+2])
+		row[i] = clamp16(cs.yOff + (r*cs.yR+g*cs.yG+b*cs.yB+1<<15)>>16)
+		row[i+1] = clamp16(32768 + (r*cs.cbR+g*cs.cbG2+b*cs.cb+1<<15)>>16)
+		row[i+2] = clamp16(32768 + (r*cs.cr+g*cs.crG2+b*cs.crB+1<<15)>>16)
+	}
+}
@@ -0,0 +1,122 @@
+// Package convert converts video between the NDI FourCCs and the Go image types.
+//
+// Every conversion goes a pair of lines at a time through 16 bit R, G, B, A or Y, Cb, Cr, A pixels, so any format
+// converts to any other. YCbCr uses the Matrix and Range of the Options, and chroma is averaged when subsampled and
+// repeated when upsampled. Lines are split between goroutines for large frames.
+package convert
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// Options of a conversion, the zero value picks the matrix from the height and uses video range
+type Options struct {
+	Matrix Matrix
+	Range  Range
+
+	// Number of goroutines converting lines, GOMAXPROCS when 0
+	Workers int
+}
+
+// Frames smaller than this many pixels are converted in the calling goroutine
+const parallelPixels = 256 * 256
+
+// Something pixels are read from or written to a line at a time, as 4 uint16 per pixel
+type surface interface {
+	size() (int, int)
+
+	// The color space of the YCbCr pixels, nil for RGB
+	space() *colorSpace
+
+	read(y int, row []uint16)
+
+	// Write the rows starting at the even line y, 2 rows unless it is the last line
+	write(y int, rows [][]uint16)
+}
+
+// Convert a frame to another FourCC, or to another stride. Both frames must have the same size.
+func Convert(dst, src *Frame, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	from, err := newFrameSurface(src, opts)
+	if err != nil {
+		return err
+	}
+	to, err := newFrameSurface(dst, opts)
+	if err != nil {
+		return err
+	}
+
+	if src.FourCC == dst.FourCC && from.stride == to.stride && src.Width == dst.Width && src.Height == dst.Height {
+		size, _ := FrameSize(src.FourCC, src.Width, src.Height, from.stride)
+		copy(dst.Data[:size], src.Data[:size])
+		return nil
+	}
+
+	return run(to, from, opts)
+}
+
+func run(dst, src surface, opts *Options) error {
+	w, h := src.size()
+	if dw, dh := dst.size(); dw != w || dh != h {
+		return fmt.Errorf("%w: %dx%d to %dx%d", ErrSizeMismatch, w, h, dw, dh)
+	}
+	if w <= 0 || h <= 0 {
+		return nil
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if w*h < parallelPixels {
+		workers = 1
+	}
+	pairs := (h + 1) / 2
+	if workers > pairs {
+		workers = pairs
+	}
+	perWorker := (pairs + workers - 1) / workers
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		first, last := i*perWorker*2, min((i+1)*perWorker*2, h)
+		if first >= last {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			convertLines(dst, src, first, last)
+		}()
+	}
+	wg.Wait()
+
+	return nil
+}
+
+func convertLines(dst, src surface, first, last int) {
+	w, _ := src.size()
+	from, to := src.space(), dst.space()
+	rows := [][]uint16{make([]uint16, w*4), make([]uint16, w*4)}
+
+	for y := first; y < last; y += 2 {
+		n := min(2, last-y)
+		for i := 0; i < n; i++ {
+			src.read(y+i, rows[i])
+			switch {
+			case from == nil && to != nil:
+				to.toYUV(rows[i])
+			case from != nil && to == nil:
+				from.toRGB(rows[i])
+			case from != nil && to != nil && *from != *to:
+				from.toRGB(rows[i])
+				to.toYUV(rows[i])
+			}
+		}
+		dst.write(y, rows[:n])
+	}
+}
@@ -0,0 +1,270 @@
+package convert
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+var fourCCs = []FourCC{
+	FourCCUYVY, FourCCUYVA, FourCCP216, FourCCPA16, FourCCNV12, FourCCI420, FourCCYV12,
+	FourCCBGRA, FourCCBGRX, FourCCRGBA, FourCCRGBX,
+}
+
+// An image whose color only changes every 2x2 pixels, so chroma subsampling loses nothing
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{uint8(x / 2 * 37), uint8(y / 2 * 59), uint8((x/2 + y/2) * 23), uint8(255 - x/2*11)})
+		}
+	}
+	return img
+}
+
+func hasAlpha(f FourCC) bool {
+	return f == FourCCUYVA || f == FourCCPA16 || f == FourCCBGRA || f == FourCCRGBA
+}
+
+func diff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, size := range []image.Point{{16, 8}, {7, 5}, {640, 480}} {
+		src := testImage(size.X, size.Y)
+		for _, fourCC := range fourCCs {
+			for _, opts := range []*Options{nil, {Matrix: BT601, Range: FullRange}, {Matrix: BT709, Workers: 3}} {
+				frame, err := NewFrame(fourCC, size.X, size.Y)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := FromImage(frame, src, opts); err != nil {
+					t.Fatal(err)
+				}
+				dst := image.NewNRGBA(src.Rect)
+				if err := ToImage(dst, frame, opts); err != nil {
+					t.Fatal(err)
+				}
+
+				// 8 bit YCbCr can't hold every RGB value
+				tolerance := 0
+				if fourCC.yuv() {
+					tolerance = 3
+				}
+				for i := 0; i < len(src.Pix); i++ {
+					want := src.Pix[i]
+					if i%4 == 3 && !hasAlpha(fourCC) {
+						want = 255
+					}
+					if diff(dst.Pix[i], want) > tolerance {
+						t.Fatalf("%s %v %+v: byte %d is %d, want %d", fourCC, size, opts, i, dst.Pix[i], want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestKnownValues(t *testing.T) {
+	red := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i := 0; i < len(red.Pix); i += 4 {
+		copy(red.Pix[i:], []byte{255, 0, 0, 255})
+	}
+
+	// BT.709 video range red is Y 63, Cb 102, Cr 240
+	frame, _ := NewFrame(FourCCUYVY, 2, 2)
+	if err := FromImage(frame, red, &Options{Matrix: BT709}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{102, 63, 240, 63}; string(frame.Data[:4]) != string(want) {
+		t.Errorf("BT.709 red is %v, want %v", frame.Data[:4], want)
+	}
+
+	// BT.601 video range red is Y 81, Cb 90, Cr 240
+	if err := FromImage(frame, red, &Options{Matrix: BT601}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{90, 81, 240, 81}; string(frame.Data[:4]) != string(want) {
+		t.Errorf("BT.601 red is %v, want %v", frame.Data[:4], want)
+	}
+
+	// Full range BT.601 matches the Go YCbCr conversion
+	for _, c := range []color.RGBA{{255, 0, 0, 255}, {12, 200, 99, 255}, {255, 255, 255, 255}, {0, 0, 0, 255}} {
+		img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+		for i := 0; i < len(img.Pix); i += 4 {
+			copy(img.Pix[i:], []byte{c.R, c.G, c.B, c.A})
+		}
+		if err := FromImage(frame, img, &Options{Matrix: BT601, Range: FullRange}); err != nil {
+			t.Fatal(err)
+		}
+		y, cb, cr := color.RGBToYCbCr(c.R, c.G, c.B)
+		if diff(frame.Data[1], y) > 1 || diff(frame.Data[0], cb) > 1 || diff(frame.Data[2], cr) > 1 {
+			t.Errorf("%v is %v, want %d %d %d", c, frame.Data[:3], cb, y, cr)
+		}
+	}
+}
+
+func TestStrideAndYCbCr(t *testing.T) {
+	src := testImage(10, 6)
+
+	// Padding after each line is skipped
+	padded := &Frame{FourCC: FourCCUYVY, Width: 10, Height: 6, Stride: 32, Data: make([]byte, 32*6)}
+	for i := range padded.Data {
+		padded.Data[i] = 0xAA
+	}
+	if err := FromImage(padded, src, nil); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 6; y++ {
+		if padded.Data[y*32+20] != 0xAA || padded.Data[y*32+31] != 0xAA {
+			t.Fatalf("line %d padding overwritten", y)
+		}
+	}
+	packed, _ := NewFrame(FourCCUYVY, 10, 6)
+	if err := Convert(packed, padded, nil); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < 6; y++ {
+		if string(packed.Data[y*20:y*20+20]) != string(padded.Data[y*32:y*32+20]) {
+			t.Fatalf("line %d differs after removing the padding", y)
+		}
+	}
+
+	// Frames and Go YCbCr images convert both ways
+	for _, ratio := range []image.YCbCrSubsampleRatio{image.YCbCrSubsampleRatio444, image.YCbCrSubsampleRatio422, image.YCbCrSubsampleRatio420} {
+		ycbcr := image.NewYCbCr(src.Rect, ratio)
+		if err := ToImage(ycbcr, packed, nil); err != nil {
+			t.Fatal(err)
+		}
+		back, _ := NewFrame(FourCCUYVY, 10, 6)
+		if err := FromImage(back, ycbcr, nil); err != nil {
+			t.Fatal(err)
+		}
+		for i := range back.Data {
+			if diff(back.Data[i], packed.Data[i]) > 2 {
+				t.Fatalf("%v: byte %d is %d, want %d", ratio, i, back.Data[i], packed.Data[i])
+			}
+		}
+	}
+
+	if err := Convert(packed, &Frame{FourCC: FourCCNV12, Width: 10, Height: 6, Data: make([]byte, 10)}, nil); !errors.Is(err, ErrShortData) {
+		t.Errorf("short frame converted: %v", err)
+	}
+	if err := Convert(packed, &Frame{FourCC: FourCCBGRA, Width: 10, Height: 4, Data: make([]byte, 160)}, nil); !errors.Is(err, ErrSizeMismatch) {
+		t.Errorf("frame of another size converted: %v", err)
+	}
+	if _, err := NewFrame(FourCC{'A', 'B', 'C', 'D'}, 2, 2); !errors.Is(err, ErrUnsupportedFourCC) {
+		t.Errorf("unknown FourCC allocated: %v", err)
+	}
+}
+
+func TestParallel(t *testing.T) {
+	src, _ := NewFrame(FourCCNV12, 1280, 720)
+	for i := range src.Data {
+		src.Data[i] = byte(i * 7)
+	}
+	single := image.NewRGBA(image.Rect(0, 0, 1280, 720))
+	if err := ToImage(single, src, &Options{Workers: 1}); err != nil {
+		t.Fatal(err)
+	}
+	parallel := image.NewRGBA(single.Rect)
+	if err := ToImage(parallel, src, &Options{Workers: 7}); err != nil {
+		t.Fatal(err)
+	}
+	if string(single.Pix) != string(parallel.Pix) {
+		t.Error("parallel conversion differs")
+	}
+}
+
+func TestView(t *testing.T) {
+	src := testImage(8, 4)
+	for _, fourCC := range []FourCC{FourCCRGBA, FourCCBGRA, FourCCBGRX, FourCCUYVY} {
+		frame := &Frame{FourCC: fourCC, Width: 8, Height: 4, Stride: 40}
+		frame.Data = make([]byte, 40*4)
+		view, err := frame.View(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Drawing on the view writes the frame, which converts back to the same picture
+		draw.Draw(view, view.Bounds(), src, image.Point{}, draw.Src)
+		converted, err := NewImage(frame, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 8; x++ {
+				want := color.NRGBAModel.Convert(view.At(x, y)).(color.NRGBA)
+				got := converted.At(x, y).(color.NRGBA)
+				if diff(got.R, want.R) > 1 || diff(got.G, want.G) > 1 || diff(got.B, want.B) > 1 {
+					t.Fatalf("%s (%d, %d) converts to %v, the view has %v", fourCC, x, y, got, want)
+				}
+				if fourCC == FourCCRGBA || fourCC == FourCCBGRA {
+					if src := src.NRGBAAt(x, y); got != src {
+						t.Fatalf("%s (%d, %d) is %v, want %v", fourCC, x, y, got, src)
+					}
+				}
+			}
+		}
+		for y := 0; y < 4; y++ {
+			for _, b := range frame.Data[y*40+32 : y*40+40] {
+				if b != 0 {
+					t.Fatalf("%s line %d padding written", fourCC, y)
+				}
+			}
+		}
+	}
+
+	if _, err := (&Frame{FourCC: FourCCNV12, Width: 2, Height: 2, Data: make([]byte, 6)}).View(nil); err == nil {
+		t.Error("planar frame viewed")
+	}
+}
+
+func benchmarkToImage(b *testing.B, fourCC FourCC) {
+	src, _ := NewFrame(fourCC, 1920, 1080)
+	dst := image.NewNRGBA(image.Rect(0, 0, 1920, 1080))
+	b.SetBytes(int64(len(src.Data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := ToImage(dst, src, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkFromImage(b *testing.B, fourCC FourCC) {
+	src := testImage(1920, 1080)
+	dst, _ := NewFrame(fourCC, 1920, 1080)
+	b.SetBytes(int64(len(src.Pix)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := FromImage(dst, src, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUYVYToNRGBA(b *testing.B) { benchmarkToImage(b, FourCCUYVY) }
+func BenchmarkNV12ToNRGBA(b *testing.B) { benchmarkToImage(b, FourCCNV12) }
+func BenchmarkP216ToNRGBA(b *testing.B) { benchmarkToImage(b, FourCCP216) }
+func BenchmarkBGRAToNRGBA(b *testing.B) { benchmarkToImage(b, FourCCBGRA) }
+func BenchmarkNRGBAToUYVY(b *testing.B) { benchmarkFromImage(b, FourCCUYVY) }
+func BenchmarkNRGBAToI420(b *testing.B) { benchmarkFromImage(b, FourCCI420) }
+
+func BenchmarkUYVYToBGRA(b *testing.B) {
+	src, _ := NewFrame(FourCCUYVY, 1920, 1080)
+	dst, _ := NewFrame(FourCCBGRA, 1920, 1080)
+	b.SetBytes(int64(len(src.Data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := Convert(dst, src, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
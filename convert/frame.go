@@ -0,0 +1,374 @@
+package convert
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// FourCC of a video frame, the same as gondi.FourCCType so values convert directly
+type FourCC [4]byte
+
+var (
+	// 4:2:2 YCbCr, 8 bits, as U0 Y0 V0 Y1
+	FourCCUYVY = FourCC{'U', 'Y', 'V', 'Y'}
+	// UYVY followed by an 8 bit alpha plane, whose stride is half the UYVY stride
+	FourCCUYVA = FourCC{'U', 'Y', 'V', 'A'}
+	// 4:2:2 YCbCr, 16 bits, a Y plane followed by an interleaved CbCr plane with the same stride
+	FourCCP216 = FourCC{'P', '2', '1', '6'}
+	// P216 followed by a 16 bit alpha plane with the same stride
+	FourCCPA16 = FourCC{'P', 'A', '1', '6'}
+	// 4:2:0 YCbCr, 8 bits, a Y plane followed by an interleaved CbCr plane with the same stride
+	FourCCNV12 = FourCC{'N', 'V', '1', '2'}
+	// 4:2:0 YCbCr, 8 bits, Y, Cb and Cr planes, chroma planes have half the stride
+	FourCCI420 = FourCC{'I', '4', '2', '0'}
+	// Like I420, with the Cr plane before the Cb plane
+	FourCCYV12 = FourCC{'Y', 'V', '1', '2'}
+	FourCCBGRA = FourCC{'B', 'G', 'R', 'A'}
+	FourCCBGRX = FourCC{'B', 'G', 'R', 'X'}
+	FourCCRGBA = FourCC{'R', 'G', 'B', 'A'}
+	FourCCRGBX = FourCC{'R', 'G', 'B', 'X'}
+)
+
+func (f FourCC) String() string {
+	return string(f[:])
+}
+
+var (
+	// The FourCC is not one of those of this package
+	ErrUnsupportedFourCC = errors.New("unsupported FourCC")
+
+	// The frame data is smaller than its size, stride and FourCC require
+	ErrShortData = errors.New("frame data too short")
+
+	// The source and destination don't have the same size
+	ErrSizeMismatch = errors.New("frames have different sizes")
+)
+
+// Frame is video data in one of the NDI FourCCs. Stride is the number of bytes per line of the first plane, the
+// default stride of the FourCC is used when it is 0. The planes follow each other in Data as described with each
+// FourCC, which is how NDI lays them out.
+type Frame struct {
+	FourCC        FourCC
+	Width, Height int
+	Stride        int
+	Data          []byte
+}
+
+// Allocate a frame with the default stride
+func NewFrame(fourCC FourCC, width, height int) (*Frame, error) {
+	size, err := FrameSize(fourCC, width, height, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Frame{fourCC, width, height, DefaultStride(fourCC, width), make([]byte, size)}, nil
+}
+
+// Get the stride of a frame without padding. Lines of formats with subsampled chroma are rounded to an even width.
+func DefaultStride(fourCC FourCC, width int) int {
+	even := (width + 1) &^ 1
+	switch fourCC {
+	case FourCCUYVY, FourCCUYVA, FourCCP216, FourCCPA16:
+		return even * 2
+	case FourCCNV12, FourCCI420, FourCCYV12:
+		return even
+	}
+
+	return width * 4
+}
+
+// Get the number of bytes of a frame, 0 for the stride means the default one
+func FrameSize(fourCC FourCC, width, height, stride int) (int, error) {
+	if stride == 0 {
+		stride = DefaultStride(fourCC, width)
+	}
+	chromaLines := (height + 1) / 2
+
+	switch fourCC {
+	case FourCCBGRA, FourCCBGRX, FourCCRGBA, FourCCRGBX, FourCCUYVY:
+		return stride * height, nil
+	case FourCCUYVA:
+		return stride*height + stride/2*height, nil
+	case FourCCP216:
+		return stride * height * 2, nil
+	case FourCCPA16:
+		return stride * height * 3, nil
+	case FourCCNV12:
+		return stride*height + stride*chromaLines, nil
+	case FourCCI420, FourCCYV12:
+		return stride*height + stride/2*chromaLines*2, nil
+	}
+
+	return 0, fmt.Errorf("%w %q", ErrUnsupportedFourCC, fourCC.String())
+}
+
+func (f *Frame) stride() int {
+	if f.Stride == 0 {
+		return DefaultStride(f.FourCC, f.Width)
+	}
+	return f.Stride
+}
+
+func (f *Frame) check() error {
+	size, err := FrameSize(f.FourCC, f.Width, f.Height, f.Stride)
+	if err != nil {
+		return err
+	}
+	if f.Stride != 0 && f.Stride < DefaultStride(f.FourCC, f.Width) {
+		return fmt.Errorf("%w: stride %d is smaller than a %s line of %d pixels", ErrShortData, f.Stride, f.FourCC, f.Width)
+	}
+	if len(f.Data) < size {
+		return fmt.Errorf("%w: %s %dx%d needs %d bytes, got %d", ErrShortData, f.FourCC, f.Width, f.Height, size, len(f.Data))
+	}
+
+	return nil
+}
+
+// Is the FourCC YCbCr
+func (f FourCC) yuv() bool {
+	switch f {
+	case FourCCBGRA, FourCCBGRX, FourCCRGBA, FourCCRGBX:
+		return false
+	}
+	return true
+}
+
+// A Frame read and written a row at a time
+type frameSurface struct {
+	f      *Frame
+	stride int
+	cs     *colorSpace
+}
+
+func newFrameSurface(f *Frame, opts *Options) (*frameSurface, error) {
+	if err := f.check(); err != nil {
+		return nil, err
+	}
+
+	s := &frameSurface{f: f, stride: f.stride()}
+	if f.FourCC.yuv() {
+		s.cs = newColorSpace(opts.Matrix, opts.Range, f.Height)
+	}
+
+	return s, nil
+}
+
+func (s *frameSurface) size() (int, int) {
+	return s.f.Width, s.f.Height
+}
+
+func (s *frameSurface) space() *colorSpace {
+	return s.cs
+}
+
+// Get the bytes of a line of a plane starting at offset
+func (s *frameSurface) line(offset, stride, y int) []byte {
+	return s.f.Data[offset+y*stride : offset+(y+1)*stride]
+}
+
+func (s *frameSurface) read(y int, row []uint16) {
+	w, h, stride := s.f.Width, s.f.Height, s.stride
+	le := binary.LittleEndian
+
+	switch s.f.FourCC {
+	case FourCCBGRA, FourCCBGRX, FourCCRGBA, FourCCRGBX:
+		r, b := 0, 2
+		if s.f.FourCC == FourCCBGRA || s.f.FourCC == FourCCBGRX {
+			r, b = 2, 0
+		}
+		alpha := s.f.FourCC == FourCCBGRA || s.f.FourCC == FourCCRGBA
+		src := s.line(0, stride, y)
+		for x := 0; x < w; x++ {
+			p := src[x*4 : x*4+4]
+			row[x*4] = uint16(p[r]) * 257
+			row[x*4+1] = uint16(p[1]) * 257
+			row[x*4+2] = uint16(p[b]) * 257
+			row[x*4+3] = 65535
+			if alpha {
+				row[x*4+3] = uint16(p[3]) * 257
+			}
+		}
+
+	case FourCCUYVY, FourCCUYVA:
+		src := s.line(0, stride, y)
+		var alpha []byte
+		if s.f.FourCC == FourCCUYVA {
+			alpha = s.line(stride*h, stride/2, y)
+		}
+		for x := 0; x < w; x++ {
+			pair := src[x/2*4 : x/2*4+4]
+			row[x*4] = uint16(pair[1+x%2*2]) << 8
+			row[x*4+1] = uint16(pair[0]) << 8
+			row[x*4+2] = uint16(pair[2]) << 8
+			row[x*4+3] = 65535
+			if alpha != nil {
+				row[x*4+3] = uint16(alpha[x]) * 257
+			}
+		}
+
+	case FourCCP216, FourCCPA16:
+		luma := s.line(0, stride, y)
+		chroma := s.line(stride*h, stride, y)
+		var alpha []byte
+		if s.f.FourCC == FourCCPA16 {
+			alpha = s.line(stride*h*2, stride, y)
+		}
+		for x := 0; x < w; x++ {
+			row[x*4] = le.Uint16(luma[x*2:])
+			row[x*4+1] = le.Uint16(chroma[x/2*4:])
+			row[x*4+2] = le.Uint16(chroma[x/2*4+2:])
+			row[x*4+3] = 65535
+			if alpha != nil {
+				row[x*4+3] = le.Uint16(alpha[x*2:])
+			}
+		}
+
+	case FourCCNV12:
+		luma := s.line(0, stride, y)
+		chroma := s.line(stride*h, stride, y/2)
+		for x := 0; x < w; x++ {
+			row[x*4] = uint16(luma[x]) << 8
+			row[x*4+1] = uint16(chroma[x/2*2]) << 8
+			row[x*4+2] = uint16(chroma[x/2*2+1]) << 8
+			row[x*4+3] = 65535
+		}
+
+	case FourCCI420, FourCCYV12:
+		luma := s.line(0, stride, y)
+		cb, cr := s.chromaPlanes(y / 2)
+		for x := 0; x < w; x++ {
+			row[x*4] = uint16(luma[x]) << 8
+			row[x*4+1] = uint16(cb[x/2]) << 8
+			row[x*4+2] = uint16(cr[x/2]) << 8
+			row[x*4+3] = 65535
+		}
+	}
+}
+
+// Get the lines of the Cb and Cr planes of I420 and YV12
+func (s *frameSurface) chromaPlanes(y int) ([]byte, []byte) {
+	h, stride := s.f.Height, s.stride
+	first := s.line(stride*h, stride/2, y)
+	second := s.line(stride*h+stride/2*((h+1)/2), stride/2, y)
+	if s.f.FourCC == FourCCYV12 {
+		return second, first
+	}
+	return first, second
+}
+
+func to8(v uint16) byte {
+	return byte((uint32(v)*255 + 32895) >> 16)
+}
+
+func yuvTo8(v uint16) byte {
+	if v >= 65535-128 {
+		return 255
+	}
+	return byte((v + 128) >> 8)
+}
+
+// Average the chroma component c of the pixels x and x+1 of the rows, x+1 being skipped past the width
+func chroma(rows [][]uint16, x, c, w int) uint16 {
+	sum, n := uint32(0), uint32(0)
+	for _, row := range rows {
+		sum += uint32(row[x*4+c])
+		n++
+		if x+1 < w {
+			sum += uint32(row[x*4+4+c])
+			n++
+		}
+	}
+	return uint16((sum + n/2) / n)
+}
+
+// Write the rows starting at the even line y, 2 rows unless it is the last line
+func (s *frameSurface) write(y int, rows [][]uint16) {
+	w, h, stride := s.f.Width, s.f.Height, s.stride
+	le := binary.LittleEndian
+
+	switch s.f.FourCC {
+	case FourCCBGRA, FourCCBGRX, FourCCRGBA, FourCCRGBX:
+		r, b := 0, 2
+		if s.f.FourCC == FourCCBGRA || s.f.FourCC == FourCCBGRX {
+			r, b = 2, 0
+		}
+		alpha := s.f.FourCC == FourCCBGRA || s.f.FourCC == FourCCRGBA
+		for i, row := range rows {
+			dst := s.line(0, stride, y+i)
+			for x := 0; x < w; x++ {
+				p := dst[x*4 : x*4+4]
+				p[r] = to8(row[x*4])
+				p[1] = to8(row[x*4+1])
+				p[b] = to8(row[x*4+2])
+				p[3] = 255
+				if alpha {
+					p[3] = to8(row[x*4+3])
+				}
+			}
+		}
+
+	case FourCCUYVY, FourCCUYVA:
+		for i, row := range rows {
+			dst := s.line(0, stride, y+i)
+			single := rows[i : i+1]
+			for x := 0; x < w; x += 2 {
+				pair := dst[x*2 : x*2+4]
+				pair[0] = yuvTo8(chroma(single, x, 1, w))
+				pair[1] = yuvTo8(row[x*4])
+				pair[2] = yuvTo8(chroma(single, x, 2, w))
+				pair[3] = pair[1]
+				if x+1 < w {
+					pair[3] = yuvTo8(row[x*4+4])
+				}
+			}
+			if s.f.FourCC == FourCCUYVA {
+				alpha := s.line(stride*h, stride/2, y+i)
+				for x := 0; x < w; x++ {
+					alpha[x] = to8(row[x*4+3])
+				}
+			}
+		}
+
+	case FourCCP216, FourCCPA16:
+		for i, row := range rows {
+			luma := s.line(0, stride, y+i)
+			chromaLine := s.line(stride*h, stride, y+i)
+			single := rows[i : i+1]
+			for x := 0; x < w; x++ {
+				le.PutUint16(luma[x*2:], row[x*4])
+			}
+			for x := 0; x < w; x += 2 {
+				le.PutUint16(chromaLine[x*2:], chroma(single, x, 1, w))
+				le.PutUint16(chromaLine[x*2+2:], chroma(single, x, 2, w))
+			}
+			if s.f.FourCC == FourCCPA16 {
+				alpha := s.line(stride*h*2, stride, y+i)
+				for x := 0; x < w; x++ {
+					le.PutUint16(alpha[x*2:], row[x*4+3])
+				}
+			}
+		}
+
+	case FourCCNV12, FourCCI420, FourCCYV12:
+		for i, row := range rows {
+			luma := s.line(0, stride, y+i)
+			for x := 0; x < w; x++ {
+				luma[x] = yuvTo8(row[x*4])
+			}
+		}
+		if s.f.FourCC == FourCCNV12 {
+			chromaLine := s.line(stride*h, stride, y/2)
+			for x := 0; x < w; x += 2 {
+				chromaLine[x] = yuvTo8(chroma(rows, x, 1, w))
+				chromaLine[x+1] = yuvTo8(chroma(rows, x, 2, w))
+			}
+			return
+		}
+		cb, cr := s.chromaPlanes(y / 2)
+		for x := 0; x < w; x += 2 {
+			cb[x/2] = yuvTo8(chroma(rows, x, 1, w))
+			cr[x/2] = yuvTo8(chroma(rows, x, 2, w))
+		}
+	}
+}
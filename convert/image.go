@@ -0,0 +1,310 @@
+package convert
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Convert a frame into an image of the same size, which must be a *image.NRGBA, *image.RGBA, *image.NRGBA64,
+// *image.RGBA64, *image.Gray, or *image.YCbCr with 4:4:4, 4:2:2 or 4:2:0 subsampling. NDI alpha is straight, it is
+// premultiplied for *image.RGBA and *image.RGBA64.
+func ToImage(dst image.Image, src *Frame, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	from, err := newFrameSurface(src, opts)
+	if err != nil {
+		return err
+	}
+	to, err := imageSurface(dst, true)
+	if err != nil {
+		return err
+	}
+
+	return run(to, from, opts)
+}
+
+// Convert a frame to a new image, a *image.NRGBA64 for the 16 bit FourCCs and a *image.NRGBA otherwise
+func NewImage(src *Frame, opts *Options) (image.Image, error) {
+	rect := image.Rect(0, 0, src.Width, src.Height)
+	var dst image.Image = image.NewNRGBA(rect)
+	if src.FourCC == FourCCP216 || src.FourCC == FourCCPA16 {
+		dst = image.NewNRGBA64(rect)
+	}
+	if err := ToImage(dst, src, opts); err != nil {
+		return nil, err
+	}
+
+	return dst, nil
+}
+
+// Convert any image into a frame of the same size. The Go image types are read directly, others through their
+// color model.
+func FromImage(dst *Frame, src image.Image, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	to, err := newFrameSurface(dst, opts)
+	if err != nil {
+		return err
+	}
+	from, err := imageSurface(src, false)
+	if err != nil {
+		return err
+	}
+
+	return run(to, from, opts)
+}
+
+// Get the surface of an image, images that can't be written are only read through At
+func imageSurface(img image.Image, write bool) (surface, error) {
+	switch img := img.(type) {
+	case *image.NRGBA:
+		return nrgbaSurface{img}, nil
+	case *image.RGBA:
+		return rgbaSurface{img}, nil
+	case *image.NRGBA64:
+		return nrgba64Surface{img}, nil
+	case *image.RGBA64:
+		return rgba64Surface{img}, nil
+	case *image.Gray:
+		return graySurface{img}, nil
+	case *image.YCbCr:
+		switch img.SubsampleRatio {
+		case image.YCbCrSubsampleRatio444, image.YCbCrSubsampleRatio422, image.YCbCrSubsampleRatio420:
+		default:
+			if write {
+				return nil, fmt.Errorf("unable to convert to YCbCr %v", img.SubsampleRatio)
+			}
+		}
+		// Go YCbCr is JPEG's, full range BT.601
+		return ycbcrSurface{img, newColorSpace(BT601, FullRange, 0)}, nil
+	}
+	if write {
+		return nil, fmt.Errorf("unable to convert to %T", img)
+	}
+
+	return genericSurface{img}, nil
+}
+
+func size(r image.Rectangle) (int, int) {
+	return r.Dx(), r.Dy()
+}
+
+type nrgbaSurface struct{ img *image.NRGBA }
+
+func (s nrgbaSurface) size() (int, int)   { return size(s.img.Rect) }
+func (s nrgbaSurface) space() *colorSpace { return nil }
+
+func (s nrgbaSurface) read(y int, row []uint16) {
+	i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y)
+	for x := range row {
+		row[x] = uint16(s.img.Pix[i+x]) * 257
+	}
+}
+
+func (s nrgbaSurface) write(y int, rows [][]uint16) {
+	for j, row := range rows {
+		i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y+j)
+		pix := s.img.Pix[i : i+len(row)]
+		for x, v := range row {
+			pix[x] = to8(v)
+		}
+	}
+}
+
+type rgbaSurface struct{ img *image.RGBA }
+
+func (s rgbaSurface) size() (int, int)   { return size(s.img.Rect) }
+func (s rgbaSurface) space() *colorSpace { return nil }
+
+func (s rgbaSurface) read(y int, row []uint16) {
+	i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y)
+	pix := s.img.Pix[i : i+len(row)]
+	for x := 0; x < len(row); x += 4 {
+		a := uint32(pix[x+3]) * 257
+		row[x+3] = uint16(a)
+		for c := 0; c < 3; c++ {
+			row[x+c] = unpremultiply(uint32(pix[x+c])*257, a)
+		}
+	}
+}
+
+func (s rgbaSurface) write(y int, rows [][]uint16) {
+	for j, row := range rows {
+		i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y+j)
+		pix := s.img.Pix[i : i+len(row)]
+		for x := 0; x < len(row); x += 4 {
+			a := uint32(row[x+3])
+			pix[x] = to8(uint16(uint32(row[x]) * a / 65535))
+			pix[x+1] = to8(uint16(uint32(row[x+1]) * a / 65535))
+			pix[x+2] = to8(uint16(uint32(row[x+2]) * a / 65535))
+			pix[x+3] = to8(uint16(a))
+		}
+	}
+}
+
+func unpremultiply(c, a uint32) uint16 {
+	if a == 0 {
+		return 0
+	}
+	if c >= a {
+		return 65535
+	}
+	return uint16(c * 65535 / a)
+}
+
+type nrgba64Surface struct{ img *image.NRGBA64 }
+
+func (s nrgba64Surface) size() (int, int)   { return size(s.img.Rect) }
+func (s nrgba64Surface) space() *colorSpace { return nil }
+
+func (s nrgba64Surface) read(y int, row []uint16) {
+	i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y)
+	pix := s.img.Pix[i : i+len(row)*2]
+	for x := range row {
+		row[x] = uint16(pix[x*2])<<8 | uint16(pix[x*2+1])
+	}
+}
+
+func (s nrgba64Surface) write(y int, rows [][]uint16) {
+	for j, row := range rows {
+		i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y+j)
+		pix := s.img.Pix[i : i+len(row)*2]
+		for x, v := range row {
+			pix[x*2] = byte(v >> 8)
+			pix[x*2+1] = byte(v)
+		}
+	}
+}
+
+type rgba64Surface struct{ img *image.RGBA64 }
+
+func (s rgba64Surface) size() (int, int)   { return size(s.img.Rect) }
+func (s rgba64Surface) space() *colorSpace { return nil }
+
+func (s rgba64Surface) read(y int, row []uint16) {
+	i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y)
+	pix := s.img.Pix[i : i+len(row)*2]
+	for x := 0; x < len(row); x += 4 {
+		a := uint32(pix[x*2+6])<<8 | uint32(pix[x*2+7])
+		row[x+3] = uint16(a)
+		for c := 0; c < 3; c++ {
+			row[x+c] = unpremultiply(uint32(pix[(x+c)*2])<<8|uint32(pix[(x+c)*2+1]), a)
+		}
+	}
+}
+
+func (s rgba64Surface) write(y int, rows [][]uint16) {
+	for j, row := range rows {
+		i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y+j)
+		pix := s.img.Pix[i : i+len(row)*2]
+		for x := 0; x < len(row); x += 4 {
+			a := uint32(row[x+3])
+			for c := 0; c < 4; c++ {
+				v := uint32(row[x+c])
+				if c < 3 {
+					v = v * a / 65535
+				}
+				pix[(x+c)*2] = byte(v >> 8)
+				pix[(x+c)*2+1] = byte(v)
+			}
+		}
+	}
+}
+
+type graySurface struct{ img *image.Gray }
+
+func (s graySurface) size() (int, int)   { return size(s.img.Rect) }
+func (s graySurface) space() *colorSpace { return nil }
+
+func (s graySurface) read(y int, row []uint16) {
+	i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y)
+	for x := 0; x < len(row)/4; x++ {
+		v := uint16(s.img.Pix[i+x]) * 257
+		row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = v, v, v, 65535
+	}
+}
+
+func (s graySurface) write(y int, rows [][]uint16) {
+	for j, row := range rows {
+		i := s.img.PixOffset(s.img.Rect.Min.X, s.img.Rect.Min.Y+y+j)
+		for x := 0; x < len(row)/4; x++ {
+			// The weights of color.GrayModel
+			r, g, b := uint32(row[x*4]), uint32(row[x*4+1]), uint32(row[x*4+2])
+			s.img.Pix[i+x] = to8(uint16((19595*r + 38470*g + 7471*b + 1<<15) >> 16))
+		}
+	}
+}
+
+type ycbcrSurface struct {
+	img *image.YCbCr
+	cs  *colorSpace
+}
+
+func (s ycbcrSurface) size() (int, int)   { return size(s.img.Rect) }
+func (s ycbcrSurface) space() *colorSpace { return s.cs }
+
+func (s ycbcrSurface) read(y int, row []uint16) {
+	origin := s.img.Rect.Min
+	for x := 0; x < len(row)/4; x++ {
+		yi := s.img.YOffset(origin.X+x, origin.Y+y)
+		ci := s.img.COffset(origin.X+x, origin.Y+y)
+		row[x*4] = uint16(s.img.Y[yi]) << 8
+		row[x*4+1] = uint16(s.img.Cb[ci]) << 8
+		row[x*4+2] = uint16(s.img.Cr[ci]) << 8
+		row[x*4+3] = 65535
+	}
+}
+
+func (s ycbcrSurface) write(y int, rows [][]uint16) {
+	origin := s.img.Rect.Min
+	w := len(rows[0]) / 4
+	for j, row := range rows {
+		for x := 0; x < w; x++ {
+			s.img.Y[s.img.YOffset(origin.X+x, origin.Y+y+j)] = yuvTo8(row[x*4])
+		}
+	}
+
+	switch s.img.SubsampleRatio {
+	case image.YCbCrSubsampleRatio444:
+		for j, row := range rows {
+			for x := 0; x < w; x++ {
+				ci := s.img.COffset(origin.X+x, origin.Y+y+j)
+				s.img.Cb[ci] = yuvTo8(row[x*4+1])
+				s.img.Cr[ci] = yuvTo8(row[x*4+2])
+			}
+		}
+	case image.YCbCrSubsampleRatio422:
+		for j := range rows {
+			for x := 0; x < w; x += 2 {
+				ci := s.img.COffset(origin.X+x, origin.Y+y+j)
+				s.img.Cb[ci] = yuvTo8(chroma(rows[j:j+1], x, 1, w))
+				s.img.Cr[ci] = yuvTo8(chroma(rows[j:j+1], x, 2, w))
+			}
+		}
+	case image.YCbCrSubsampleRatio420:
+		for x := 0; x < w; x += 2 {
+			ci := s.img.COffset(origin.X+x, origin.Y+y)
+			s.img.Cb[ci] = yuvTo8(chroma(rows, x, 1, w))
+			s.img.Cr[ci] = yuvTo8(chroma(rows, x, 2, w))
+		}
+	}
+}
+
+// Any image, read through its color model
+type genericSurface struct{ img image.Image }
+
+func (s genericSurface) size() (int, int)   { return size(s.img.Bounds()) }
+func (s genericSurface) space() *colorSpace { return nil }
+
+func (s genericSurface) read(y int, row []uint16) {
+	origin := s.img.Bounds().Min
+	for x := 0; x < len(row)/4; x++ {
+		c := color.NRGBA64Model.Convert(s.img.At(origin.X+x, origin.Y+y)).(color.NRGBA64)
+		row[x*4], row[x*4+1], row[x*4+2], row[x*4+3] = c.R, c.G, c.B, c.A
+	}
+}
+
+func (s genericSurface) write(y int, rows [][]uint16) {}
@@ -0,0 +1,170 @@
+package convert
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Get an image using the data of the frame without copying it, for the packed FourCCs: RGBA and RGBX as a
+// *image.NRGBA, BGRA and BGRX as a *BGRA, and UYVY as a *UYVY. Drawing on the image changes the frame. Convert the
+// other FourCCs with NewImage.
+//
+// The X byte of RGBX and BGRX is read as alpha, NDI sets it to 255.
+func (f *Frame) View(opts *Options) (draw.Image, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if err := f.check(); err != nil {
+		return nil, err
+	}
+	rect := image.Rect(0, 0, f.Width, f.Height)
+	stride := f.stride()
+	size, _ := FrameSize(f.FourCC, f.Width, f.Height, stride)
+	pix := f.Data[:size:size]
+
+	switch f.FourCC {
+	case FourCCRGBA, FourCCRGBX:
+		return &image.NRGBA{Pix: pix, Stride: stride, Rect: rect}, nil
+	case FourCCBGRA, FourCCBGRX:
+		return &BGRA{Pix: pix, Stride: stride, Rect: rect}, nil
+	case FourCCUYVY:
+		return &UYVY{Pix: pix, Stride: stride, Rect: rect, cs: newColorSpace(opts.Matrix, opts.Range, f.Height)}, nil
+	}
+
+	return nil, fmt.Errorf("unable to view %s frames as an image, convert them", f.FourCC)
+}
+
+// BGRA is an image in NDI BGRA memory order, with straight alpha. Like image.NRGBA with blue and red swapped.
+type BGRA struct {
+	Pix    []uint8
+	Stride int
+	Rect   image.Rectangle
+}
+
+func (p *BGRA) ColorModel() color.Model {
+	return color.NRGBAModel
+}
+
+func (p *BGRA) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// Get the index of the first byte of the pixel in Pix
+func (p *BGRA) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+func (p *BGRA) At(x, y int) color.Color {
+	return p.NRGBAAt(x, y)
+}
+
+func (p *BGRA) NRGBAAt(x, y int) color.NRGBA {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.NRGBA{}
+	}
+	s := p.Pix[p.PixOffset(x, y):]
+	return color.NRGBA{s[2], s[1], s[0], s[3]}
+}
+
+func (p *BGRA) RGBA64At(x, y int) color.RGBA64 {
+	r, g, b, a := p.NRGBAAt(x, y).RGBA()
+	return color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}
+}
+
+func (p *BGRA) Set(x, y int, c color.Color) {
+	p.SetNRGBA(x, y, color.NRGBAModel.Convert(c).(color.NRGBA))
+}
+
+func (p *BGRA) SetRGBA64(x, y int, c color.RGBA64) {
+	p.Set(x, y, c)
+}
+
+func (p *BGRA) SetNRGBA(x, y int, c color.NRGBA) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	s := p.Pix[p.PixOffset(x, y):]
+	s[0], s[1], s[2], s[3] = c.B, c.G, c.R, c.A
+}
+
+// UYVY is an image in NDI UYVY memory order: 4:2:2 YCbCr where each pair of pixels is stored as Cb, Y0, Cr, Y1.
+// Colors are converted with the matrix and range of the frame it views, use YCbCrAt to get the codes.
+//
+// Setting a pixel sets the chroma of the pair it belongs to, so drawing changes the color of neighbor pixels
+// when they don't share it.
+type UYVY struct {
+	Pix    []uint8
+	Stride int
+	Rect   image.Rectangle
+	cs     *colorSpace
+}
+
+func (p *UYVY) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (p *UYVY) Bounds() image.Rectangle {
+	return p.Rect
+}
+
+// The color space of the frame, or the default one for images not made by View
+func (p *UYVY) space() *colorSpace {
+	if p.cs == nil {
+		return newColorSpace(MatrixAuto, VideoRange, p.Rect.Dy())
+	}
+	return p.cs
+}
+
+// Get the index of the pair of the pixel in Pix, and the index of its luma
+func (p *UYVY) offsets(x, y int) (int, int) {
+	x -= p.Rect.Min.X
+	pair := (y-p.Rect.Min.Y)*p.Stride + x/2*4
+	return pair, pair + 1 + x%2*2
+}
+
+// Get the codes of a pixel
+func (p *UYVY) YCbCrAt(x, y int) color.YCbCr {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.YCbCr{}
+	}
+	pair, luma := p.offsets(x, y)
+	return color.YCbCr{Y: p.Pix[luma], Cb: p.Pix[pair], Cr: p.Pix[pair+2]}
+}
+
+func (p *UYVY) At(x, y int) color.Color {
+	return p.RGBA64At(x, y)
+}
+
+func (p *UYVY) RGBA64At(x, y int) color.RGBA64 {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return color.RGBA64{}
+	}
+	c := p.YCbCrAt(x, y)
+	pixel := []uint16{uint16(c.Y) << 8, uint16(c.Cb) << 8, uint16(c.Cr) << 8, 65535}
+	p.space().toRGB(pixel)
+	return color.RGBA64{pixel[0], pixel[1], pixel[2], pixel[3]}
+}
+
+// Set a pixel, alpha is ignored
+func (p *UYVY) Set(x, y int, c color.Color) {
+	c64 := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+	p.setRGB(x, y, c64.R, c64.G, c64.B)
+}
+
+func (p *UYVY) SetRGBA64(x, y int, c color.RGBA64) {
+	p.Set(x, y, c)
+}
+
+func (p *UYVY) setRGB(x, y int, r, g, b uint16) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	pixel := []uint16{r, g, b, 65535}
+	p.space().toYUV(pixel)
+	pair, luma := p.offsets(x, y)
+	p.Pix[luma] = yuvTo8(pixel[0])
+	p.Pix[pair] = yuvTo8(pixel[1])
+	p.Pix[pair+2] = yuvTo8(pixel[2])
+}
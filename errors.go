@@ -0,0 +1,71 @@
+package gondi
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// The NDI library could not be found or opened
+	ErrLibraryNotFound = errors.New("NDI library not found")
+
+	// The NDI library does not export a function used by this package
+	ErrSymbolMissing = errors.New("NDI library symbol missing")
+
+	// The NDI library reports that this CPU is not supported, NDI requires at least SSE4.2 on x86
+	ErrCPUNotSupported = errors.New("CPU not supported by NDI")
+
+	// The NDI library failed to initialize
+	ErrInitializeFailed = errors.New("NDI initialization failed")
+
+	// The loaded NDI library, or the backend in use, does not implement this feature. See Capabilities.
+	ErrNotSupported = errors.New("not supported by the NDI library")
+
+	// The options passed to a constructor are not valid, the returned error tells which one
+	ErrInvalidOptions = errors.New("invalid options")
+)
+
+var (
+	errUnsupportedOS   = errors.New("operating system not supported")
+	errLoadFailed      = errors.New("the NDIlib_v3_load function did not return a valid pointer")
+	errInitializeFalse = errors.New("the NDIlib_initialize function returned false")
+)
+
+// InitError is returned by Initialize and InitLibrary. Use errors.Is with ErrLibraryNotFound, ErrSymbolMissing,
+// ErrCPUNotSupported or ErrInitializeFailed to find out what went wrong.
+type InitError struct {
+	// One of ErrLibraryNotFound, ErrSymbolMissing, ErrCPUNotSupported or ErrInitializeFailed
+	Err error
+
+	// The path of the library, empty when several paths were searched
+	Path string
+
+	// The name of the missing function, for ErrSymbolMissing
+	Symbol string
+
+	// The underlying error, for instance from the system loader, may be nil
+	Cause error
+}
+
+func (e *InitError) Error() string {
+	msg := e.Err.Error()
+	if e.Symbol != "" {
+		msg += " " + e.Symbol
+	}
+	if e.Path != "" {
+		msg += fmt.Sprintf(" (%s)", e.Path)
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+
+	return msg
+}
+
+func (e *InitError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Err}
+	}
+
+	return []error{e.Err, e.Cause}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"image"
@@ -13,6 +14,7 @@ import (
 	"unsafe"
 
 	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/convert"
 	"github.com/benitogf/gondi/mjpeg"
 	"github.com/gorilla/mux"
 )
@@ -25,76 +27,84 @@ var (
 	InputStreamName   string
 	InputStreamAdress string
 	FramesSentCount   int64
+	AudioFramesCount  int64
 	FrameRateN        int32 = 30000
 	FrameRateD        int32 = 1001
-	CaptureCount      int64
-	CaptureErrorCount int64
-	CaptureNoneCount  int64
+	MetadataCount     int64
+	StatusChangeCount int64
 	CaptureEmptyCount int64
 	NDISources        []*gondi.Source
 )
 
-func ndiToNDI(receiver *gondi.RecvInstance, sender *gondi.SendInstance) {
+func ndiToNDI(receiver *gondi.Receiver, sender *gondi.SendInstance) {
 	for {
-		CaptureCount++
-		videoInput := gondi.NewVideoFrameV2()
-		frametype := receiver.CaptureV3(videoInput, nil, nil, 1000)
-		if frametype == gondi.FrameTypeNone {
-			CaptureNoneCount++
-		}
-		if frametype == gondi.FrameTypeError {
-			CaptureErrorCount++
-		}
-		if frametype == gondi.FrameTypeVideo {
-			size := videoInput.LineStride * videoInput.Yres
-			videoInputSlice := unsafe.Slice(videoInput.Data, size)
-			frame := make([]byte, len(videoInputSlice))
-			copy(frame, videoInputSlice)
-
-			if len(frame) == 0 {
-				gondi.SendAlphaFrame(sender)
-				CaptureEmptyCount++
-				continue
+		select {
+		case videoInput, ok := <-receiver.Video():
+			if !ok {
+				return
 			}
-
-			// if videoInput.FourCC == gondi.FourCCTypeUYVY {
-			// 	log.Println("preview UYVY", len(frame))
-			// }
-			// if videoInput.FourCC == gondi.FourCCTypeBGRA {
-			// 	log.Println("preview BGRA", len(frame))
-			// }
-			// if videoInput.FourCC == gondi.FourCCTypeUYVA {
-			// 	log.Println("preview UYVA", len(frame))
-			// }
-			// if videoInput.FourCC == gondi.FourCCTypeBGRX {
-			// 	log.Println("preview BGRX", len(frame))
-			// }
-			if videoInput.FourCC == gondi.FourCCTypeRGBA {
-				gondi.SetPreviewFrame(*outputFlag, frame, int(videoInput.Xres), int(videoInput.Yres))
+			sendVideo(videoInput, sender)
+			videoInput.Release()
+		case audioInput, ok := <-receiver.Audio():
+			if !ok {
+				return
 			}
-			if videoInput.FourCC == gondi.FourCCTypeRGBX {
-				gondi.SetPreviewFrame(*outputFlag, frame, int(videoInput.Xres), int(videoInput.Yres))
+			// Only video is copied, audio is given back right away
+			audioInput.Release()
+			AudioFramesCount++
+		case metadata, ok := <-receiver.Metadata():
+			if !ok {
+				return
 			}
-
-			videoOutput := gondi.NewVideoFrameV2()
-			videoOutput.FourCC = videoInput.FourCC
-			videoOutput.FrameFormatType = videoInput.FrameFormatType
-			videoOutput.Xres = videoInput.Xres
-			videoOutput.Yres = videoInput.Yres
-			videoOutput.FrameRateN = videoInput.FrameRateN
-			videoOutput.FrameRateD = videoInput.FrameRateD
-			videoOutput.LineStride = videoInput.LineStride
-			FrameRateN = videoOutput.FrameRateN
-			FrameRateD = videoOutput.FrameRateD
-			videoOutput.Data = &frame[0]
-
-			sender.SendVideoFrame(videoOutput)
-			receiver.FreeVideoV2(videoInput)
-			FramesSentCount++
+			MetadataCount++
+			log.Println("metadata: ", metadata.Data)
+		case _, ok := <-receiver.Status():
+			if !ok {
+				return
+			}
+			StatusChangeCount++
 		}
 	}
 }
 
+func sendVideo(videoInput *gondi.VideoFrame, sender *gondi.SendInstance) {
+	size := videoInput.LineStride * videoInput.Yres
+	if videoInput.Data == nil || size <= 0 {
+		gondi.SendAlphaFrame(sender)
+		CaptureEmptyCount++
+		return
+	}
+	frame := unsafe.Slice(videoInput.Data, size)
+
+	preview := image.NewRGBA(image.Rect(0, 0, int(videoInput.Xres), int(videoInput.Yres)))
+	err := convert.ToImage(preview, &convert.Frame{
+		FourCC: convert.FourCC(videoInput.FourCC),
+		Width:  int(videoInput.Xres),
+		Height: int(videoInput.Yres),
+		Stride: int(videoInput.LineStride),
+		Data:   frame,
+	}, nil)
+	if err == nil {
+		gondi.SetPreviewFrame(*outputFlag, preview.Pix, preview.Rect.Dx(), preview.Rect.Dy())
+	}
+
+	videoOutput := gondi.NewVideoFrameV2()
+	videoOutput.FourCC = videoInput.FourCC
+	videoOutput.FrameFormatType = videoInput.FrameFormatType
+	videoOutput.Xres = videoInput.Xres
+	videoOutput.Yres = videoInput.Yres
+	videoOutput.FrameRateN = videoInput.FrameRateN
+	videoOutput.FrameRateD = videoInput.FrameRateD
+	videoOutput.LineStride = videoInput.LineStride
+	FrameRateN = videoOutput.FrameRateN
+	FrameRateD = videoOutput.FrameRateD
+	videoOutput.Data = &frame[0]
+
+	// Synchronous, so the frame is released only once sent
+	sender.SendVideoFrame(videoOutput)
+	FramesSentCount++
+}
+
 func getNDISources(finder *gondi.FindInstance) ([]*gondi.Source, error) {
 	// Wait for sources to appear
 	for {
@@ -122,7 +132,10 @@ func clear() {
 func main() {
 	flag.Parse()
 	log.Println("Initializing NDI", *inputFlag)
-	gondi.InitLibrary("")
+	if err := gondi.InitLibrary(""); err != nil {
+		log.Println("failed to initialize ndi", err)
+		panic(err)
+	}
 
 	NDIversion = gondi.GetVersion()
 
@@ -143,8 +156,7 @@ func main() {
 	if *inputFlag != "" {
 		found := false
 		for _, source := range NDISources {
-			// log.Println("===============", gondi.ExtractSourceName(source.Name()))
-			if gondi.ExtractSourceName(source.Name()) == *inputFlag {
+			if source.SourceName() == *inputFlag {
 				found = true
 				input = source
 			}
@@ -163,19 +175,19 @@ func main() {
 	InputStreamName = input.Name()
 	InputStreamAdress = input.Address()
 
-	// Set up receiver
-	receiver, err := gondi.NewRecvInstance(&gondi.NewRecvInstanceSettings{
+	// Set up receiver, it frees every frame it captures and stops with the context
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	receiver, err := gondi.NewReceiver(ctx, &gondi.NewRecvInstanceSettings{
 		SourceToConnectTo: input,
 		ColorFormat:       gondi.RecvColorFormatRGBXRGBA,
 		Bandwidth:         gondi.RecvBandwidthHighest,
 		AllowVideoFields:  true,
 	})
-	receiver.Connect(input)
 	if err != nil {
 		log.Println("failed to receive ndi", err)
 		panic(err)
 	}
-	defer receiver.Destroy()
 
 	// Set up sender, block on both audio and video as we are using separate threads for audio and video
 	sender, err := gondi.NewSendInstance(*outputFlag, "", true, true)
@@ -193,11 +205,12 @@ func main() {
 	go func() {
 		for {
 			clear()
-			totals, dropped := receiver.GetPerformance()
+			totals, dropped := receiver.Instance().GetPerformance()
+			connections, _ := sender.GetNumberOfConnections(10)
 			log.Printf("version: %s\n", NDIversion)
 			log.Println("input name: ", InputStreamName)
 			log.Println("output name: ", *outputFlag)
-			log.Println("output connections: ", sender.GetNumberOfConnections(10))
+			log.Println("output connections: ", connections)
 			log.Println("sources", len(NDISources))
 			for _, source := range NDISources {
 				log.Println("-- ", source.Name())
@@ -206,9 +219,9 @@ func main() {
 			log.Println("frames sent: ", FramesSentCount)
 			log.Println("frame rate N: ", FrameRateN)
 			log.Println("frame rate D: ", FrameRateD)
-			log.Println("captures: ", CaptureCount)
-			log.Println("capture error: ", CaptureErrorCount)
-			log.Println("capture none: ", CaptureNoneCount)
+			log.Println("audio frames received: ", AudioFramesCount)
+			log.Println("metadata received: ", MetadataCount)
+			log.Println("status changes: ", StatusChangeCount)
 			log.Println("capture empty: ", CaptureEmptyCount)
 			log.Println("received video frames: ", totals.VideoFrames)
 			log.Println("dropped frames: ", dropped.VideoFrames)
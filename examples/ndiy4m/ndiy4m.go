@@ -0,0 +1,131 @@
+// Pipe NDI video to and from YUV4MPEG2 tools, logging to stderr as stdout carries the stream:
+//
+//	ndiy4m -receive | ffmpeg -f yuv4mpegpipe -i - out.mp4
+//	ffmpeg -i in.mp4 -f yuv4mpegpipe -pix_fmt yuv420p - | ndiy4m -send
+//	ndiy4m -send -file in.y4m
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/y4m"
+)
+
+func receive(source string, chroma y4m.Chroma) error {
+	finder, err := gondi.NewFindInstance(true, "", "")
+	if err != nil {
+		return err
+	}
+	defer finder.Destroy()
+
+	log.Println("Looking for sources...")
+	var selected *gondi.Source
+	for selected == nil {
+		finder.WaitForSources(5000)
+		for _, s := range finder.GetCurrentSources() {
+			if strings.Contains(s.Name(), source) {
+				selected = s
+				break
+			}
+		}
+	}
+	log.Printf("Source selected: %s", selected.Name())
+
+	receiver, err := gondi.NewRecvInstance(&gondi.NewRecvInstanceSettings{
+		SourceToConnectTo: selected,
+		ColorFormat:       gondi.RecvColorFormatUYVYBGRA,
+		Bandwidth:         gondi.RecvBandwidthHighest,
+		Name:              "y4m",
+	})
+	if err != nil {
+		return err
+	}
+	defer receiver.Destroy()
+
+	writer := y4m.NewWriter(os.Stdout, y4m.WithChroma(chroma))
+	for {
+		vf := gondi.NewVideoFrameV2()
+		if receiver.CaptureV2(vf, nil, nil, 1000) != gondi.FrameTypeVideo {
+			continue
+		}
+		err := writer.WriteFrame(vf)
+		receiver.FreeVideoV2(vf)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func send(name string, file string) error {
+	var input io.Reader = os.Stdin
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	reader, err := y4m.NewReader(input)
+	if err != nil {
+		return err
+	}
+	header := reader.Header()
+	log.Printf("Sending %dx%d at %d/%d fps as %s", header.Width, header.Height, header.FrameRateN, header.FrameRateD, name)
+
+	// Clocked on video, so frames go out at the frame rate of the stream however fast they are read
+	sender, err := gondi.NewSendInstance(name, "", true, false)
+	if err != nil {
+		return err
+	}
+	defer sender.Destroy()
+
+	for {
+		vf, err := reader.ReadFrame()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		sender.SendVideoFrame(vf)
+	}
+}
+
+func main() {
+	receiveMode := flag.Bool("receive", false, "write the video of a source to stdout")
+	source := flag.String("source", "", "receive from the first source whose name contains this")
+	chroma422 := flag.Bool("422", false, "write 4:2:2 instead of 4:2:0, needed for interlaced sources")
+	sendMode := flag.Bool("send", false, "send a stream read from stdin or a file")
+	name := flag.String("name", "y4m", "name of the source sent")
+	file := flag.String("file", "", "file to send instead of stdin")
+	flag.Parse()
+
+	if err := gondi.InitLibrary(""); err != nil {
+		log.Fatal(err)
+	}
+
+	var err error
+	switch {
+	case *receiveMode:
+		chroma := y4m.Chroma420
+		if *chroma422 {
+			chroma = y4m.Chroma422
+		}
+		err = receive(*source, chroma)
+	case *sendMode:
+		err = send(*name, *file)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
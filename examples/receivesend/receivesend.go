@@ -33,7 +33,9 @@ func captureAndSendAudio(receiver *gondi.RecvInstance, sender *gondi.SendInstanc
 
 func main() {
 	fmt.Println("Initializing NDI")
-	gondi.InitLibrary("")
+	if err := gondi.InitLibrary(""); err != nil {
+		panic(err)
+	}
 
 	version := gondi.GetVersion()
 	fmt.Printf("NDI version: %s\n", version)
@@ -9,7 +9,9 @@ import (
 
 func main() {
 	fmt.Println("Initializing NDI")
-	gondi.InitLibrary("")
+	if err := gondi.InitLibrary(""); err != nil {
+		panic(err)
+	}
 
 	version := gondi.GetVersion()
 	fmt.Printf("NDI version: %s\n", version)
@@ -128,7 +128,9 @@ func clear() {
 }
 
 func main() {
-	gondi.InitLibrary("")
+	if err := gondi.InitLibrary(""); err != nil {
+		panic(err)
+	}
 	NDIversion := gondi.GetVersion()
 
 	// Set up sender, block on both audio and video as we are using separate threads for audio and video
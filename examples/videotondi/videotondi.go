@@ -10,6 +10,7 @@ import (
 	vidio "github.com/AlexEidt/Vidio"
 	"github.com/AlexEidt/aio"
 	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/audioproc"
 )
 
 var (
@@ -32,6 +33,12 @@ func streamNDI(fileName string, sender *gondi.SendInstance) {
 }
 
 func audioToNDI(fileName string, sender *gondi.SendInstance) {
+	// Mixers downstream expect 48 kHz stereo, whatever the file has
+	processor, err := audioproc.NewProcessor(audioproc.WithSampleRate(48000, audioproc.QualityMedium), audioproc.WithStereo())
+	if err != nil {
+		log.Panic("failed to create the audio processor", err)
+	}
+
 	for {
 		audio, err := aio.NewAudio(fileName, &aio.Options{
 			Format: "f32",
@@ -41,22 +48,33 @@ func audioToNDI(fileName string, sender *gondi.SendInstance) {
 		}
 
 		for audio.Read() {
-			audioInput := gondi.NewAudioFrameV3()
-			audioInput.SampleRate = int32(audio.SampleRate())
-			audioInput.NumChannels = int32(audio.Channels())
-			audioInput.NumSamples = int32(len(audio.Samples().([]float32)) / 2)
-			audioInput.Data = &audio.Buffer()[0]
-			// audioInput.ChannelStride = 0
+			samples := audio.Samples().([]float32)
+			audioInput := &gondi.AudioBuffer{
+				Format:      gondi.AudioFormatFloat32Interleaved,
+				SampleRate:  audio.SampleRate(),
+				NumChannels: audio.Channels(),
+				NumSamples:  len(samples) / audio.Channels(),
+				Timecode:    gondi.SendTimecodeSynthesize,
+				Float32:     samples,
+			}
 
 			audioFormat = audio.Format()
-			audioSampleRate = audioInput.SampleRate
-			audioChannels = audioInput.NumChannels
-			audioNumSamples = audioInput.NumSamples
-
-			sender.SendAudioFrame32f(audioInput)
+			audioSampleRate = int32(audioInput.SampleRate)
+			audioChannels = int32(audioInput.NumChannels)
+			audioNumSamples = int32(audioInput.NumSamples)
+
+			audioOutput, err := processor.Process(audioInput)
+			if err != nil {
+				log.Println("failed to process audio", err)
+				continue
+			}
+			if err := sender.SendAudio(audioOutput); err != nil {
+				log.Println("failed to send audio", err)
+			}
 
 			audioFrames++
 		}
+		processor.Reset()
 	}
 }
 
@@ -125,7 +143,9 @@ func clear() {
 }
 
 func main() {
-	gondi.InitLibrary("")
+	if err := gondi.InitLibrary(""); err != nil {
+		log.Panic("failed to initialize ndi", err)
+	}
 
 	version := gondi.GetVersion()
 	log.Printf("NDI version: %s\n", version)
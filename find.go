@@ -2,7 +2,6 @@ package gondi
 
 import (
 	"errors"
-	"unsafe"
 )
 
 // Setup a finder instance, initialized groups and extraIPs.
@@ -10,22 +9,14 @@ import (
 // The groups property may be empty, and it will use the default from NDI access manager.
 // The extraIPs is only used to manually find sources from known ips on different subnets and is comma separated.
 func NewFindInstance(showLocalSources bool, groups string, extraIPs string) (*FindInstance, error) {
-	assertLibrary()
-	settings := &findCreateSettings{showLocalSources, cString(groups), cString(extraIPs)}
+	b := currentBackend()
 	inst := &FindInstance{}
 
-	if groups == "" {
-		settings.groups = nil
-	}
-	if extraIPs == "" {
-		settings.extraIPs = nil
-	}
-
-	inst.createSettings = settings
-	inst.ndiInstance = ndilib_find_create_v2(uintptr(unsafe.Pointer(settings)))
+	inst.ndiInstance = b.FindCreateV2(showLocalSources, groups, extraIPs)
 	if inst.ndiInstance == 0 {
 		return nil, errors.New("unable to create finder instance")
 	}
+	trackInstance(inst, func() { b.FindDestroy(inst.ndiInstance) })
 
 	return inst, nil
 }
@@ -34,23 +25,7 @@ func NewFindInstance(showLocalSources bool, groups string, extraIPs string) (*Fi
 // If you have a UI element to change the source, you should call this function before showing the user the list of sources,
 // to always have the latest list of sources.
 func (p *FindInstance) GetCurrentSources() []*Source {
-	assertLibrary()
-
-	var numSources uint32
-	ret := ndilib_find_get_current_sources(p.ndiInstance, uintptr(unsafe.Pointer(&numSources)))
-
-	sources := make([]*Source, numSources)
-
-	// We take the address and then dereference it to trick go vet from creating a possible misuse of unsafe.Pointer
-	blockp := *(*unsafe.Pointer)(unsafe.Pointer(&ret))
-
-	for i := range sources {
-		sources[i] = (*Source)(blockp)
-		// Increment pointer
-		blockp = unsafe.Add(blockp, unsafe.Sizeof(Source{}))
-	}
-
-	return sources
+	return currentBackend().FindGetCurrentSources(p.ndiInstance)
 }
 
 // This allows you to wait until the sources on the network have changed.
@@ -58,14 +33,13 @@ func (p *FindInstance) GetCurrentSources() []*Source {
 // You are not required to call this function, but it is helpful for getting an initial list of sources,
 // and to detect when the list of sources has changed.
 func (p *FindInstance) WaitForSources(timeoutMs uint32) bool {
-	assertLibrary()
-
-	return ndilib_find_wait_for_sources(p.ndiInstance, timeoutMs)
+	return currentBackend().FindWaitForSources(p.ndiInstance, timeoutMs)
 }
 
-// Destroy this finder instance.
+// Destroy this finder instance. Calling it again, or after Shutdown destroyed the instance, does nothing.
 func (p *FindInstance) Destroy() {
-	assertLibrary()
-
-	ndilib_find_destroy(p.ndiInstance)
+	if !untrackInstance(p) {
+		return
+	}
+	currentBackend().FindDestroy(p.ndiInstance)
 }
@@ -0,0 +1,92 @@
+package gondi
+
+import (
+	"errors"
+)
+
+// FrameSync pulls video and audio from a receiver at the pace of the caller instead of the pace of the sender. Video
+// capture always returns the latest frame received, repeating it when no new one arrived, and audio capture returns
+// exactly the number of samples asked for, resampled to correct for the drift between both clocks and padded with
+// silence when not enough audio arrived.
+//
+// Once a frame synchronizer is created, video and audio must not be captured on the receiver anymore, metadata and
+// status changes still are. It is destroyed together with its receiver.
+type FrameSync struct {
+	ndiInstance uintptr
+	recv        *RecvInstance
+}
+
+// Create a frame synchronizer on top of a receiver.
+// Returns ErrNotSupported if the NDI library does not support frame synchronization.
+func NewFrameSync(recv *RecvInstance) (*FrameSync, error) {
+	inst, err := currentBackend().FrameSyncCreate(recv.ndiInstance)
+	if err != nil {
+		return nil, err
+	}
+	if inst == 0 {
+		return nil, errors.New("unable to create frame sync instance")
+	}
+
+	fs := &FrameSync{inst, recv}
+	recv.mu.Lock()
+	if recv.frameSyncs == nil {
+		recv.frameSyncs = map[*FrameSync]struct{}{}
+	}
+	recv.frameSyncs[fs] = struct{}{}
+	recv.mu.Unlock()
+
+	return fs, nil
+}
+
+// Get the latest video frame received. Until the first frame arrives the frame is zeroed, with a nil Data and
+// a resolution of 0x0, so check it before use. The fieldType is the field wanted for interlaced sources, use
+// FrameFormatProgressive otherwise. The frame must be freed with FreeVideo.
+func (p *FrameSync) CaptureVideo(vf *VideoFrameV2, fieldType FrameFormat) {
+	currentBackend().FrameSyncCaptureVideo(p.ndiInstance, vf, fieldType)
+}
+
+// Free a frame returned by CaptureVideo
+func (p *FrameSync) FreeVideo(vf *VideoFrameV2) {
+	currentBackend().FrameSyncFreeVideo(p.ndiInstance, vf)
+}
+
+// Get exactly numSamples samples of planar audio, at the given sample rate and number of channels. Passing 0 for the
+// sample rate or the number of channels uses the ones of the incoming audio, in which case the frame is empty until
+// the first audio arrives. Missing audio is replaced by silence. The frame must be freed with FreeAudio.
+func (p *FrameSync) CaptureAudio(af *AudioFrameV2, sampleRate int, numChannels int, numSamples int) {
+	currentBackend().FrameSyncCaptureAudio(p.ndiInstance, af, int32(sampleRate), int32(numChannels), int32(numSamples))
+}
+
+// Free a frame returned by CaptureAudio
+func (p *FrameSync) FreeAudio(af *AudioFrameV2) {
+	currentBackend().FrameSyncFreeAudio(p.ndiInstance, af)
+}
+
+// Get the number of audio samples currently queued, which allows capturing audio in the blocks it was received in.
+func (p *FrameSync) AudioQueueDepth() int {
+	return int(currentBackend().FrameSyncAudioQueueDepth(p.ndiInstance))
+}
+
+// Destroy the frame synchronizer, after which the receiver can be captured from again. Calling it again does nothing.
+func (p *FrameSync) Destroy() {
+	p.recv.mu.Lock()
+	_, ok := p.recv.frameSyncs[p]
+	delete(p.recv.frameSyncs, p)
+	p.recv.mu.Unlock()
+	if !ok {
+		return
+	}
+	currentBackend().FrameSyncDestroy(p.ndiInstance)
+}
+
+// Frame synchronizers need to be destroyed before their receiver
+func (p *RecvInstance) destroyFrameSyncs(b Backend) {
+	p.mu.Lock()
+	syncs := p.frameSyncs
+	p.frameSyncs = nil
+	p.mu.Unlock()
+
+	for fs := range syncs {
+		b.FrameSyncDestroy(fs.ndiInstance)
+	}
+}
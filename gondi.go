@@ -13,37 +13,31 @@ import (
 	"unsafe"
 )
 
+// Logger receives the diagnostic messages of this package, *log.Logger satisfies it.
+type Logger interface {
+	Printf(format string, v ...any)
+}
+
 // Get the version of the NDI library as string
 func GetVersion() string {
-	assertLibrary()
-
-	mystrptr := ndilib_version()
-	if mystrptr == 0 {
-		return "N/A"
-	}
-
-	return goString(mystrptr)
+	return currentBackend().Version()
 }
 
 // If your audio frames are interleaved, you can use this function to convert them to planar format.
 // You can also use the frame.SetFromInterleavedArray(data) function to automatically convert an array of float32s in interleaved format to planar format as NDI likes it.
 func ConvertAudioFromInterleaved(pSrc *AudioFrameV2, pDst *AudioFrameV2) {
-	assertLibrary()
-
-	ndilib_util_audio_from_interleaved_32f_v2(uintptr(unsafe.Pointer(pSrc)), uintptr(unsafe.Pointer(pDst)))
+	currentBackend().AudioFromInterleaved32fV2(pSrc, pDst)
 }
 
 // If your want your audio frames to be interleaved, you can use this function to convert them from planar format.
 // You can also use the frame.GetInterleavedArray() function to get a coverted array of float32s in interleaved format.
 func ConvertAudioToInterleaved(pSrc *AudioFrameV2, pDst *AudioFrameV2) {
-	assertLibrary()
-
-	ndilib_util_audio_to_interleaved_32f_v2(uintptr(unsafe.Pointer(pSrc)), uintptr(unsafe.Pointer(pDst)))
+	currentBackend().AudioToInterleaved32fV2(pSrc, pDst)
 }
 
 // Allocate a new NDIMetadataFrame and initialize it with the specified utf-8 data string.
+// The data is a Go copy kept alive by the frame, and pinned whenever the frame is passed to the NDI library.
 func NewMetadataFrame(data string) *MetadataFrame {
-	// I am afraid that the data parameter might be garbage collected before the C code is done with it, though.
 	ret := &MetadataFrame{
 		Length:   int32(len(data)),
 		Timecode: SendTimecodeSynthesize,
@@ -58,47 +52,41 @@ func (p *MetadataFrame) GetData() string {
 	return goString(uintptr(unsafe.Pointer(p.Data)))
 }
 
-// Name of the source
-func (s *Source) Name() string {
-	if s.name == nil {
-		return ""
+// Get the audio frames as an array of float32
+// This is planar audio, so the first NumSamples values are the first channel, the next NumSamples values are the second channel, etc.
+// The array points to the data of the frame when the channels are tightly packed, so it is only valid until the frame is
+// freed. When the channel stride has padding, the channels are copied together instead. Use CopyArray to always get a copy.
+// If you need to work with interleaved audio, you can use the GetInterleavedArray() function instead.
+func (p *AudioFrameV2) GetArray() []float32 {
+	if p.Data == nil || p.NumChannels <= 0 || p.NumSamples <= 0 {
+		return []float32{}
 	}
-	return goString(uintptr(unsafe.Pointer(s.name)))
-}
-
-// Address of the source
-func (s *Source) Address() string {
-	if s.address == nil {
-		return ""
+	if p.ChannelStride != 0 && p.ChannelStride != p.NumSamples*4 {
+		return p.CopyArray()
 	}
-	return goString(uintptr(unsafe.Pointer(s.address)))
-}
 
-// Set the name and address of the source object
-func (s *Source) Set(name string, address string) {
-	s.name = cString(name)
-	s.address = cString(address)
+	return unsafe.Slice(p.Data, p.NumSamples*p.NumChannels)
 }
 
-// Get the audio frames as an array of float32
-// This is usually stored as planar audio, so the first NumSamples values are the first channel, the next NumSamples values are the second channel, etc.
-// If you need to work with interleaved audio, you can use the GetInterleavedArray() function instead.
-func (p *AudioFrameV2) GetArray() []float32 {
-	return (*[1 << 30]float32)(unsafe.Pointer(p.Data))[0 : p.NumSamples*p.NumChannels]
+// Get a copy of the audio as planar float32, like GetArray, which stays valid after the frame is freed
+func (p *AudioFrameV2) CopyArray() []float32 {
+	return copyPlanarAudio(p.Data, p.NumChannels, p.NumSamples, p.ChannelStride)
 }
 
 // Get the audio frames as an array of float32
 // This function converts the audio to interleaved audio, so each sample is stored as a single value, and the channels are interleaved.
 func (p *AudioFrameV2) GetInterleavedArray() []float32 {
-	dst := make([]float32, p.NumSamples*p.NumChannels)
+	dst := make([]float32, max(p.NumSamples*p.NumChannels, 0))
+	if len(dst) == 0 {
+		return dst
+	}
 	tempFrame := &AudioFrameV2{
 		NumSamples:  p.NumSamples,
 		SampleRate:  p.SampleRate,
 		NumChannels: p.NumChannels,
 		Data:        &dst[0],
 	}
-
-	ndilib_util_audio_to_interleaved_32f_v2(uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(tempFrame)))
+	audioToInterleaved32f(p, tempFrame)
 
 	return dst
 }
@@ -109,20 +97,82 @@ func (p *AudioFrameV2) SetFromInterleavedArray(audio []float32) {
 	if p.Data == nil {
 		panic("AudioFrameV2.Data is nil")
 	}
+	if len(audio) < int(p.NumSamples*p.NumChannels) {
+		panic("audio is shorter than the frame")
+	}
+	if len(audio) == 0 {
+		return
+	}
 	tempFrame := &AudioFrameV2{
 		NumSamples:  p.NumSamples,
 		SampleRate:  p.SampleRate,
 		NumChannels: p.NumChannels,
 		Data:        &audio[0],
 	}
-	ndilib_util_audio_from_interleaved_32f_v2(uintptr(unsafe.Pointer(tempFrame)), uintptr(unsafe.Pointer(p)))
+	audioFromInterleaved32f(tempFrame, p)
 }
 
-// Set the audio frames from an array of float32
+// Set the audio frames from an array of tightly packed planar float32, honoring the channel stride of the frame.
 // The Data field of the frame needs to be preallocated.
 func (p *AudioFrameV2) SetArray(audio []float32) {
 	if p.Data == nil {
 		panic("AudioFrameV2.Data is nil")
 	}
-	copy((*[1 << 30]float32)(unsafe.Pointer(p.Data))[0:p.NumSamples*p.NumChannels], audio)
+	if p.NumChannels <= 0 || p.NumSamples <= 0 {
+		return
+	}
+
+	stride := p.ChannelStride / 4
+	if stride == 0 {
+		stride = p.NumSamples
+	}
+	planar := unsafe.Slice(p.Data, (p.NumChannels-1)*stride+p.NumSamples)
+	for ch := int32(0); ch < p.NumChannels && int(ch*p.NumSamples) < len(audio); ch++ {
+		copy(planar[ch*stride:ch*stride+p.NumSamples], audio[ch*p.NumSamples:])
+	}
+}
+
+// Size of the compressed data in bytes. This shares its storage with ChannelStride, as the SDK uses the same field for
+// both, and is only meaningful for compressed FourCCs.
+func (p *AudioFrameV3) DataSize() int32 {
+	return p.ChannelStride
+}
+
+// Set the size of the compressed data in bytes, see DataSize.
+func (p *AudioFrameV3) SetDataSize(size int32) {
+	p.ChannelStride = size
+}
+
+// Get the samples of one channel of a planar float32 (FourCCAudioTypeFLTP) frame, honoring the channel stride.
+// The returned slice points to the frame data, so it is only valid until the frame is freed.
+// Returns nil for other FourCCs or if the channel does not exist.
+func (p *AudioFrameV3) Channel(channel int) []float32 {
+	if p.FourCC != FourCCAudioTypeFLTP || p.Data == nil || channel < 0 || channel >= int(p.NumChannels) || p.NumSamples <= 0 {
+		return nil
+	}
+
+	stride := p.ChannelStride
+	if stride == 0 {
+		stride = p.NumSamples * 4
+	}
+
+	return unsafe.Slice((*float32)(unsafe.Add(unsafe.Pointer(p.Data), channel*int(stride))), p.NumSamples)
+}
+
+// Get a copy of the audio of a planar float32 (FourCCAudioTypeFLTP) frame as interleaved float32 samples, honoring the
+// channel stride. Returns nil for other FourCCs.
+func (p *AudioFrameV3) GetInterleavedArray() []float32 {
+	if p.FourCC != FourCCAudioTypeFLTP || p.Data == nil || p.NumChannels <= 0 || p.NumSamples <= 0 {
+		return nil
+	}
+
+	numChannels := int(p.NumChannels)
+	dst := make([]float32, int(p.NumSamples)*numChannels)
+	for ch := 0; ch < numChannels; ch++ {
+		for i, sample := range p.Channel(ch) {
+			dst[i*numChannels+ch] = sample
+		}
+	}
+
+	return dst
 }
@@ -3,7 +3,26 @@ This is a test file for gondi.go, it only tests two functions so far, so it shou
 */
 package gondi
 
-import "testing"
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// The tests fall back to the loopback backend when the NDI runtime is not installed
+func TestMain(m *testing.M) {
+	if err := Initialize(); err != nil {
+		UseBackend(NewLoopbackBackend())
+	}
+	os.Exit(m.Run())
+}
+
+// Use the backend for the rest of the test, the previous one is restored when it ends
+func useTestBackend(t *testing.T, b Backend) {
+	previous := loadBackend()
+	t.Cleanup(func() { UseBackend(previous) })
+	UseBackend(b)
+}
 
 func TestGetVersion(t *testing.T) {
 	InitLibrary("")
@@ -17,6 +36,23 @@ func TestGetVersion(t *testing.T) {
 	}
 }
 
+func TestInitializeLibraryNotFound(t *testing.T) {
+	useTestBackend(t, nil)
+
+	err := Initialize(WithLibraryPath("/nonexistent/libndi.so"))
+	if !errors.Is(err, ErrLibraryNotFound) {
+		t.Fatalf("Initialize returned %v, want ErrLibraryNotFound", err)
+	}
+
+	var initErr *InitError
+	if !errors.As(err, &initErr) || initErr.Path != "/nonexistent/libndi.so" {
+		t.Errorf("Initialize returned %#v, want an *InitError with the library path", err)
+	}
+	if IsInitialized() {
+		t.Error("IsInitialized returned true after a failed Initialize")
+	}
+}
+
 func TestNewMetadataFrame(t *testing.T) {
 	InitLibrary("")
 	testString := `<data value="The quick brown fox jumps over the lazy dog" />`
@@ -39,3 +75,15 @@ func TestNewMetadataFrame(t *testing.T) {
 		t.Errorf("Length is %d, want %d", mf.Length, len(testString))
 	}
 }
+
+func TestCapabilities(t *testing.T) {
+	useTestBackend(t, NewLoopbackBackend())
+
+	caps := Capabilities()
+	if !caps.AudioV3 || !caps.ConnectionCount || !caps.Routing {
+		t.Errorf("loopback capabilities are %+v", caps)
+	}
+	if len(caps.Missing) != 0 {
+		t.Errorf("loopback is missing %v", caps.Missing)
+	}
+}
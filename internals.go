@@ -1,24 +1,26 @@
 package gondi
 
 import (
-	"errors"
-	"fmt"
-	"log"
+	"os"
+	"path/filepath"
+	"reflect"
 	"runtime"
 
 	"github.com/ebitengine/purego"
 )
 
 var (
-	ndi_shared_library uintptr
-	ndilib_load        func() uintptr
-	ndilib_initialize  func() bool
-	ndilib_version     func() uintptr
+	ndi_shared_library      uintptr
+	ndilib_load             func() uintptr
+	ndilib_initialize       func() bool
+	ndilib_version          func() uintptr
+	ndilib_is_supported_cpu func() bool
+	ndilib_destroy          func()
 
 	ndilib_util_audio_from_interleaved_32f_v2   func(src uintptr, dst uintptr)
 	ndilib_util_audio_to_interleaved_32f_v2     func(src uintptr, dst uintptr)
-	ndilib_util_send_send_audio_interleaved_16s func(instance uintptr, frame *AudioFrameV3)
-	ndilib_util_send_send_audio_interleaved_32f func(instance uintptr, frame *AudioFrameV3)
+	ndilib_util_send_send_audio_interleaved_16s func(instance uintptr, frame *audioFrameInterleaved16s)
+	ndilib_util_send_send_audio_interleaved_32f func(instance uintptr, frame *audioFrameInterleaved32f)
 
 	ndilib_send_create                    func(settings *sendCreateSettings) uintptr
 	ndilib_send_destroy                   func(instance uintptr)
@@ -32,7 +34,7 @@ var (
 	ndilib_send_free_metadata             func(instance uintptr, metadata *MetadataFrame)
 	ndilib_send_add_connection_metadata   func(instance uintptr, metadata *MetadataFrame)
 	ndilib_send_clear_connection_metadata func(instance uintptr)
-	ndilib_send_set_failover              func(instance uintptr, source *Source)
+	ndilib_send_set_failover              func(instance uintptr, source *cSource)
 	ndilib_send_get_no_connections        func(instance uintptr, timeout uint32) int32
 
 	ndilib_find_create_v2           func(settings uintptr) uintptr
@@ -40,11 +42,12 @@ var (
 	ndilib_find_get_current_sources func(instance uintptr, numSources uintptr) uintptr
 	ndilib_find_wait_for_sources    func(instance uintptr, timeout uint32) bool
 
-	ndilib_recv_connect                   func(instance uintptr, source *Source)
+	ndilib_recv_connect                   func(instance uintptr, source *cSource)
 	ndilib_recv_create_v3                 func(settings *recvCreateSettings) uintptr
 	ndilib_recv_destroy                   func(instance uintptr)
 	ndilib_recv_free_video_v2             func(instance uintptr, frame *VideoFrameV2)
 	ndilib_recv_free_audio_v2             func(instance uintptr, frame *AudioFrameV2)
+	ndilib_recv_free_audio_v3             func(instance uintptr, frame *AudioFrameV3)
 	ndilib_recv_free_metadata             func(instance uintptr, frame *MetadataFrame)
 	ndilib_recv_capture_v2                func(instance uintptr, videoFrame *VideoFrameV2, audioFrame *AudioFrameV2, metadataFrame *MetadataFrame, timeout uint32) int32
 	ndilib_recv_capture_v3                func(instance uintptr, videoFrame *VideoFrameV2, audioFrame *AudioFrameV3, metadataFrame *MetadataFrame, timeout uint32) int32
@@ -54,109 +57,348 @@ var (
 	ndilib_recv_add_connection_metadata   func(instance uintptr, metadata *MetadataFrame) bool
 	ndilib_recv_clear_connection_metadata func(instance uintptr)
 
+	ndilib_recv_ptz_is_supported          func(instance uintptr) bool
+	ndilib_recv_ptz_zoom                  func(instance uintptr, zoom float32) bool
+	ndilib_recv_ptz_zoom_speed            func(instance uintptr, speed float32) bool
+	ndilib_recv_ptz_pan_tilt              func(instance uintptr, pan float32, tilt float32) bool
+	ndilib_recv_ptz_pan_tilt_speed        func(instance uintptr, panSpeed float32, tiltSpeed float32) bool
+	ndilib_recv_ptz_store_preset          func(instance uintptr, preset int32) bool
+	ndilib_recv_ptz_recall_preset         func(instance uintptr, preset int32, speed float32) bool
+	ndilib_recv_ptz_auto_focus            func(instance uintptr) bool
+	ndilib_recv_ptz_focus                 func(instance uintptr, focus float32) bool
+	ndilib_recv_ptz_focus_speed           func(instance uintptr, speed float32) bool
+	ndilib_recv_ptz_white_balance_auto    func(instance uintptr) bool
+	ndilib_recv_ptz_white_balance_indoor  func(instance uintptr) bool
+	ndilib_recv_ptz_white_balance_outdoor func(instance uintptr) bool
+	ndilib_recv_ptz_white_balance_oneshot func(instance uintptr) bool
+	ndilib_recv_ptz_white_balance_manual  func(instance uintptr, red float32, blue float32) bool
+	ndilib_recv_ptz_exposure_auto         func(instance uintptr) bool
+	ndilib_recv_ptz_exposure_manual       func(instance uintptr, level float32) bool
+	ndilib_recv_ptz_exposure_manual_v2    func(instance uintptr, iris float32, gain float32, shutterSpeed float32) bool
+
+	ndilib_framesync_create            func(receiver uintptr) uintptr
+	ndilib_framesync_destroy           func(instance uintptr)
+	ndilib_framesync_capture_video     func(instance uintptr, frame *VideoFrameV2, fieldType FrameFormat)
+	ndilib_framesync_free_video        func(instance uintptr, frame *VideoFrameV2)
+	ndilib_framesync_capture_audio     func(instance uintptr, frame *AudioFrameV2, sampleRate int32, numChannels int32, numSamples int32)
+	ndilib_framesync_free_audio        func(instance uintptr, frame *AudioFrameV2)
+	ndilib_framesync_audio_queue_depth func(instance uintptr) int32
+
 	ndilib_routing_create  func(settings uintptr) uintptr
 	ndilib_routing_destroy func(instance uintptr)
 	ndilib_routing_change  func(instance uintptr, source uintptr) bool
 	ndilib_routing_clear   func(instance uintptr) bool
 )
 
-// Windows is not supported by go-purego
-func getLibraryPath() string {
+// A function exported by the NDI library and the variable it is registered to. Optional functions are left nil when
+// the library does not export them, which is reported by Capabilities and makes the methods using them return
+// ErrNotSupported.
+type librarySymbol struct {
+	fptr     any
+	name     string
+	optional bool
+}
+
+// All used NDI Library functions
+var librarySymbols = []librarySymbol{
+	{&ndilib_load, "NDIlib_v3_load", true},
+	{&ndilib_initialize, "NDIlib_initialize", false},
+	{&ndilib_version, "NDIlib_version", false},
+	{&ndilib_is_supported_cpu, "NDIlib_is_supported_CPU", true},
+	{&ndilib_destroy, "NDIlib_destroy", false},
+
+	{&ndilib_util_audio_from_interleaved_32f_v2, "NDIlib_util_audio_from_interleaved_32f_v2", true},
+	{&ndilib_util_audio_to_interleaved_32f_v2, "NDIlib_util_audio_to_interleaved_32f_v2", true},
+	{&ndilib_util_send_send_audio_interleaved_16s, "NDIlib_util_send_send_audio_interleaved_16s", true},
+	{&ndilib_util_send_send_audio_interleaved_32f, "NDIlib_util_send_send_audio_interleaved_32f", true},
+
+	{&ndilib_send_create, "NDIlib_send_create", false},
+	{&ndilib_send_destroy, "NDIlib_send_destroy", false},
+	{&ndilib_send_send_video_v2, "NDIlib_send_send_video_v2", false},
+	{&ndilib_send_send_video_async_v2, "NDIlib_send_send_video_async_v2", false},
+	{&ndilib_send_send_audio_v2, "NDIlib_send_send_audio_v2", false},
+	{&ndilib_send_send_audio_v3, "NDIlib_send_send_audio_v3", true},
+	{&ndilib_send_get_tally, "NDIlib_send_get_tally", false},
+	{&ndilib_send_capture, "NDIlib_send_capture", false},
+	{&ndilib_send_free_metadata, "NDIlib_send_free_metadata", false},
+	{&ndilib_send_send_metadata, "NDIlib_send_send_metadata", false},
+	{&ndilib_send_add_connection_metadata, "NDIlib_send_add_connection_metadata", false},
+	{&ndilib_send_clear_connection_metadata, "NDIlib_send_clear_connection_metadata", false},
+	{&ndilib_send_set_failover, "NDIlib_send_set_failover", true},
+	{&ndilib_send_get_no_connections, "NDIlib_send_get_no_connections", true},
+
+	{&ndilib_find_create_v2, "NDIlib_find_create_v2", false},
+	{&ndilib_find_get_current_sources, "NDIlib_find_get_current_sources", false},
+	{&ndilib_find_wait_for_sources, "NDIlib_find_wait_for_sources", false},
+	{&ndilib_find_destroy, "NDIlib_find_destroy", false},
+
+	{&ndilib_recv_create_v3, "NDIlib_recv_create_v3", false},
+	{&ndilib_recv_connect, "NDIlib_recv_connect", false},
+	{&ndilib_recv_destroy, "NDIlib_recv_destroy", false},
+	{&ndilib_recv_free_metadata, "NDIlib_recv_free_metadata", false},
+	{&ndilib_recv_free_video_v2, "NDIlib_recv_free_video_v2", false},
+	{&ndilib_recv_free_audio_v2, "NDIlib_recv_free_audio_v2", false},
+	{&ndilib_recv_free_audio_v3, "NDIlib_recv_free_audio_v3", true},
+	{&ndilib_recv_capture_v2, "NDIlib_recv_capture_v2", false},
+	{&ndilib_recv_capture_v3, "NDIlib_recv_capture_v3", true},
+	{&ndilib_recv_get_performance, "NDIlib_recv_get_performance", false},
+	{&ndilib_recv_set_tally, "NDIlib_recv_set_tally", false},
+	{&ndilib_recv_send_metadata, "NDIlib_recv_send_metadata", false},
+	{&ndilib_recv_add_connection_metadata, "NDIlib_recv_add_connection_metadata", false},
+	{&ndilib_recv_clear_connection_metadata, "NDIlib_recv_clear_connection_metadata", false},
+
+	{&ndilib_recv_ptz_is_supported, "NDIlib_recv_ptz_is_supported", true},
+	{&ndilib_recv_ptz_zoom, "NDIlib_recv_ptz_zoom", true},
+	{&ndilib_recv_ptz_zoom_speed, "NDIlib_recv_ptz_zoom_speed", true},
+	{&ndilib_recv_ptz_pan_tilt, "NDIlib_recv_ptz_pan_tilt", true},
+	{&ndilib_recv_ptz_pan_tilt_speed, "NDIlib_recv_ptz_pan_tilt_speed", true},
+	{&ndilib_recv_ptz_store_preset, "NDIlib_recv_ptz_store_preset", true},
+	{&ndilib_recv_ptz_recall_preset, "NDIlib_recv_ptz_recall_preset", true},
+	{&ndilib_recv_ptz_auto_focus, "NDIlib_recv_ptz_auto_focus", true},
+	{&ndilib_recv_ptz_focus, "NDIlib_recv_ptz_focus", true},
+	{&ndilib_recv_ptz_focus_speed, "NDIlib_recv_ptz_focus_speed", true},
+	{&ndilib_recv_ptz_white_balance_auto, "NDIlib_recv_ptz_white_balance_auto", true},
+	{&ndilib_recv_ptz_white_balance_indoor, "NDIlib_recv_ptz_white_balance_indoor", true},
+	{&ndilib_recv_ptz_white_balance_outdoor, "NDIlib_recv_ptz_white_balance_outdoor", true},
+	{&ndilib_recv_ptz_white_balance_oneshot, "NDIlib_recv_ptz_white_balance_oneshot", true},
+	{&ndilib_recv_ptz_white_balance_manual, "NDIlib_recv_ptz_white_balance_manual", true},
+	{&ndilib_recv_ptz_exposure_auto, "NDIlib_recv_ptz_exposure_auto", true},
+	{&ndilib_recv_ptz_exposure_manual, "NDIlib_recv_ptz_exposure_manual", true},
+	{&ndilib_recv_ptz_exposure_manual_v2, "NDIlib_recv_ptz_exposure_manual_v2", true},
+
+	{&ndilib_framesync_create, "NDIlib_framesync_create", true},
+	{&ndilib_framesync_destroy, "NDIlib_framesync_destroy", true},
+	{&ndilib_framesync_capture_video, "NDIlib_framesync_capture_video", true},
+	{&ndilib_framesync_free_video, "NDIlib_framesync_free_video", true},
+	{&ndilib_framesync_capture_audio, "NDIlib_framesync_capture_audio", true},
+	{&ndilib_framesync_free_audio, "NDIlib_framesync_free_audio", true},
+	{&ndilib_framesync_audio_queue_depth, "NDIlib_framesync_audio_queue_depth", true},
+
+	{&ndilib_routing_create, "NDIlib_routing_create", true},
+	{&ndilib_routing_destroy, "NDIlib_routing_destroy", true},
+	{&ndilib_routing_change, "NDIlib_routing_change", true},
+	{&ndilib_routing_clear, "NDIlib_routing_clear", true},
+}
+
+// Options for Initialize
+type InitOption func(*initConfig)
+
+type initConfig struct {
+	libraryPath string
+	searchPaths []string
+	logger      Logger
+}
+
+// Load the library from this exact path, instead of searching for it. An empty path means searching.
+func WithLibraryPath(path string) InitOption {
+	return func(c *initConfig) {
+		c.libraryPath = path
+	}
+}
+
+// Add directories to search for the library in. They are searched after the directories in the NDI_RUNTIME_DIR_V6
+// and NDI_RUNTIME_DIR_V5 environment variables, and before the default install locations of the current platform.
+func WithSearchPaths(dirs ...string) InitOption {
+	return func(c *initConfig) {
+		c.searchPaths = append(c.searchPaths, dirs...)
+	}
+}
+
+// Log what Initialize is doing to the given logger, nothing is logged by default.
+func WithLogger(logger Logger) InitOption {
+	return func(c *initConfig) {
+		c.logger = logger
+	}
+}
+
+func (c *initConfig) logf(format string, v ...any) {
+	if c.logger != nil {
+		c.logger.Printf(format, v...)
+	}
+}
+
+// File names of the NDI library for the current platform, newest runtime first
+func libraryNames() []string {
 	switch runtime.GOOS {
 	case "darwin":
-		return "/usr/local/lib/libndi.dylib"
+		return []string{"libndi.dylib"}
 	case "linux":
-		return "libndi.so"
+		return []string{"libndi.so.6", "libndi.so.5", "libndi.so"}
 	case "windows":
-		return `C:\Program Files\NDI\NDI 6 Tools\Runtime\Processing.NDI.Lib.x64.dll`
+		return []string{"Processing.NDI.Lib.x64.dll"}
 	default:
-		panic(fmt.Errorf("GOOS=%s is not supported", runtime.GOOS))
+		return nil
 	}
 }
 
-func assertLibrary() {
-	if ndi_shared_library == 0 {
-		panic("library not initialized, use gondi.InitLibrary()")
+// Directories the NDI runtime is installed to by default on the current platform
+func defaultSearchPaths() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"/usr/local/lib", "/Library/NDI SDK for Apple/lib/macOS"}
+	case "linux":
+		return []string{"/usr/local/lib", "/usr/lib"}
+	case "windows":
+		return []string{
+			`C:\Program Files\NDI\NDI 6 Runtime\v6`,
+			`C:\Program Files\NDI\NDI 6 Tools\Runtime`,
+			`C:\Program Files\NDI\NDI 5 Runtime\v5`,
+		}
+	default:
+		return nil
 	}
 }
 
-// Initialize the NDI Library, the libraryPath argument is optional and will be
-// automatically set to the default library path for the current platform if
-// empty. This function will panic if it does not find the library, or if it is
-// unable to initialize NDI with the given library. But return error if NDI reports an error initializing.
-func InitLibrary(libraryPath string) error {
-	var err error
+// The list of paths to try opening, in order
+func (c *initConfig) candidates() []string {
+	if c.libraryPath != "" {
+		return []string{c.libraryPath}
+	}
 
-	if ndi_shared_library == 0 {
-		if libraryPath == "" {
-			libraryPath = getLibraryPath()
+	dirs := []string{}
+	for _, env := range []string{"NDI_RUNTIME_DIR_V6", "NDI_RUNTIME_DIR_V5"} {
+		if dir := os.Getenv(env); dir != "" {
+			dirs = append(dirs, dir)
 		}
+	}
+	dirs = append(dirs, c.searchPaths...)
+	dirs = append(dirs, defaultSearchPaths()...)
 
-		log.Println("opening lib:", libraryPath)
-		ndi_shared_library, err = openLibrary(libraryPath)
-		if err != nil {
-			panic(err)
+	names := libraryNames()
+	paths := []string{}
+	for _, dir := range dirs {
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
 		}
+	}
 
-		// Register all used NDI Library functions
-		purego.RegisterLibFunc(&ndilib_load, ndi_shared_library, "NDIlib_v3_load")
-		purego.RegisterLibFunc(&ndilib_initialize, ndi_shared_library, "NDIlib_initialize")
-		purego.RegisterLibFunc(&ndilib_version, ndi_shared_library, "NDIlib_version")
-
-		purego.RegisterLibFunc(&ndilib_util_audio_from_interleaved_32f_v2, ndi_shared_library, "NDIlib_util_audio_from_interleaved_32f_v2")
-		purego.RegisterLibFunc(&ndilib_util_audio_to_interleaved_32f_v2, ndi_shared_library, "NDIlib_util_audio_to_interleaved_32f_v2")
-		purego.RegisterLibFunc(&ndilib_util_send_send_audio_interleaved_16s, ndi_shared_library, "NDIlib_util_send_send_audio_interleaved_16s")
-		purego.RegisterLibFunc(&ndilib_util_send_send_audio_interleaved_32f, ndi_shared_library, "NDIlib_util_send_send_audio_interleaved_32f")
-
-		purego.RegisterLibFunc(&ndilib_send_create, ndi_shared_library, "NDIlib_send_create")
-		purego.RegisterLibFunc(&ndilib_send_destroy, ndi_shared_library, "NDIlib_send_destroy")
-		purego.RegisterLibFunc(&ndilib_send_send_video_v2, ndi_shared_library, "NDIlib_send_send_video_v2")
-		purego.RegisterLibFunc(&ndilib_send_send_video_async_v2, ndi_shared_library, "NDIlib_send_send_video_async_v2")
-		purego.RegisterLibFunc(&ndilib_send_send_audio_v2, ndi_shared_library, "NDIlib_send_send_audio_v2")
-		purego.RegisterLibFunc(&ndilib_send_send_audio_v3, ndi_shared_library, "NDIlib_send_send_audio_v3")
-		purego.RegisterLibFunc(&ndilib_send_get_tally, ndi_shared_library, "NDIlib_send_get_tally")
-		purego.RegisterLibFunc(&ndilib_send_capture, ndi_shared_library, "NDIlib_send_capture")
-		purego.RegisterLibFunc(&ndilib_send_free_metadata, ndi_shared_library, "NDIlib_send_free_metadata")
-		purego.RegisterLibFunc(&ndilib_send_send_metadata, ndi_shared_library, "NDIlib_send_send_metadata")
-		purego.RegisterLibFunc(&ndilib_send_add_connection_metadata, ndi_shared_library, "NDIlib_send_add_connection_metadata")
-		purego.RegisterLibFunc(&ndilib_send_clear_connection_metadata, ndi_shared_library, "NDIlib_send_clear_connection_metadata")
-		purego.RegisterLibFunc(&ndilib_send_set_failover, ndi_shared_library, "NDIlib_send_set_failover")
-		purego.RegisterLibFunc(&ndilib_send_get_no_connections, ndi_shared_library, "NDIlib_send_get_no_connections")
-
-		purego.RegisterLibFunc(&ndilib_find_create_v2, ndi_shared_library, "NDIlib_find_create_v2")
-		purego.RegisterLibFunc(&ndilib_find_get_current_sources, ndi_shared_library, "NDIlib_find_get_current_sources")
-		purego.RegisterLibFunc(&ndilib_find_wait_for_sources, ndi_shared_library, "NDIlib_find_wait_for_sources")
-		purego.RegisterLibFunc(&ndilib_find_destroy, ndi_shared_library, "NDIlib_find_destroy")
-
-		purego.RegisterLibFunc(&ndilib_recv_create_v3, ndi_shared_library, "NDIlib_recv_create_v3")
-		purego.RegisterLibFunc(&ndilib_recv_connect, ndi_shared_library, "NDIlib_recv_connect")
-		purego.RegisterLibFunc(&ndilib_recv_destroy, ndi_shared_library, "NDIlib_recv_destroy")
-		purego.RegisterLibFunc(&ndilib_recv_free_metadata, ndi_shared_library, "NDIlib_recv_free_metadata")
-		purego.RegisterLibFunc(&ndilib_recv_free_video_v2, ndi_shared_library, "NDIlib_recv_free_video_v2")
-		purego.RegisterLibFunc(&ndilib_recv_free_audio_v2, ndi_shared_library, "NDIlib_recv_free_audio_v2")
-		purego.RegisterLibFunc(&ndilib_recv_capture_v2, ndi_shared_library, "NDIlib_recv_capture_v2")
-		purego.RegisterLibFunc(&ndilib_recv_capture_v3, ndi_shared_library, "NDIlib_recv_capture_v3")
-		purego.RegisterLibFunc(&ndilib_recv_get_performance, ndi_shared_library, "NDIlib_recv_get_performance")
-		purego.RegisterLibFunc(&ndilib_recv_set_tally, ndi_shared_library, "NDIlib_recv_set_tally")
-		purego.RegisterLibFunc(&ndilib_recv_send_metadata, ndi_shared_library, "NDIlib_recv_send_metadata")
-		purego.RegisterLibFunc(&ndilib_recv_add_connection_metadata, ndi_shared_library, "NDIlib_recv_add_connection_metadata")
-		purego.RegisterLibFunc(&ndilib_recv_clear_connection_metadata, ndi_shared_library, "NDIlib_recv_clear_connection_metadata")
-
-		purego.RegisterLibFunc(&ndilib_routing_create, ndi_shared_library, "NDIlib_routing_create")
-		purego.RegisterLibFunc(&ndilib_routing_destroy, ndi_shared_library, "NDIlib_routing_destroy")
-		purego.RegisterLibFunc(&ndilib_routing_change, ndi_shared_library, "NDIlib_routing_change")
-		purego.RegisterLibFunc(&ndilib_routing_clear, ndi_shared_library, "NDIlib_routing_clear")
-
-		result := ndilib_load()
-		if result == 0 {
-			return errors.New("the NDIlib_v3_load function did not return a valid pointer")
+	// Finally let the system loader search its own paths
+	return append(paths, names...)
+}
+
+// Initialize the NDI Library. Unless WithLibraryPath is given, the library is searched for in the directories of the
+// NDI_RUNTIME_DIR_V6 and NDI_RUNTIME_DIR_V5 environment variables, the directories given with WithSearchPaths, the
+// default install locations and finally the paths of the system loader.
+//
+// The returned error is an *InitError wrapping ErrLibraryNotFound, ErrSymbolMissing, ErrCPUNotSupported or
+// ErrInitializeFailed, in which case the package stays uninitialized and it is safe to call Initialize again later.
+//
+// Initialization is reference counted: if the library is already initialized, or another backend was set with
+// UseBackend, only the count is incremented. Every successful call must be matched by a call to Shutdown.
+func Initialize(opts ...InitOption) error {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	if backend != nil {
+		refs++
+		return nil
+	}
+
+	config := &initConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	candidates := config.candidates()
+	if len(candidates) == 0 {
+		return &InitError{Err: ErrLibraryNotFound, Cause: errUnsupportedOS}
+	}
+
+	var (
+		library uintptr
+		path    string
+		err     error
+	)
+	for _, path = range candidates {
+		config.logf("gondi: opening library %s", path)
+		library, err = openLibrary(path)
+		if err == nil {
+			break
 		}
+		config.logf("gondi: unable to open %s: %v", path, err)
+	}
+	if library == 0 {
+		if len(candidates) > 1 {
+			path = ""
+		}
+		return &InitError{Err: ErrLibraryNotFound, Path: path, Cause: err}
+	}
+
+	if err := initLibrary(library, path); err != nil {
+		config.logf("gondi: %v", err)
+		closeLibrary(library)
+		return err
+	}
+
+	ndi_shared_library = library
+	backend = libraryBackend{capabilities: libraryCapabilities(library)}
+	refs = 1
+	config.logf("gondi: initialized %s", backend.Version())
 
-		loaded := ndilib_initialize()
-		if !loaded {
-			return errors.New("the NDIlib_initialize function returned false")
+	return nil
+}
+
+// Register all used NDI Library functions and initialize NDI
+func initLibrary(library uintptr, path string) error {
+	for _, symbol := range librarySymbols {
+		fn, err := lookupSymbol(library, symbol.name)
+		if err != nil || fn == 0 {
+			if symbol.optional {
+				continue
+			}
+			unbindLibrary()
+			return &InitError{Err: ErrSymbolMissing, Path: path, Symbol: symbol.name, Cause: err}
 		}
+		purego.RegisterFunc(symbol.fptr, fn)
+	}
+
+	if ndilib_is_supported_cpu != nil && !ndilib_is_supported_cpu() {
+		unbindLibrary()
+		return &InitError{Err: ErrCPUNotSupported, Path: path}
+	}
+
+	if ndilib_load != nil && ndilib_load() == 0 {
+		unbindLibrary()
+		return &InitError{Err: ErrInitializeFailed, Path: path, Cause: errLoadFailed}
+	}
+
+	if !ndilib_initialize() {
+		unbindLibrary()
+		return &InitError{Err: ErrInitializeFailed, Path: path, Cause: errInitializeFalse}
 	}
 
 	return nil
 }
+
+// Reset every registered function to nil, so nothing from a library that is unloaded can be called by mistake.
+func unbindLibrary() {
+	for _, symbol := range librarySymbols {
+		reflect.ValueOf(symbol.fptr).Elem().SetZero()
+	}
+}
+
+// Whether the loaded library exports the given function
+func hasSymbol(library uintptr, name string) bool {
+	if library == 0 {
+		return false
+	}
+	fn, err := lookupSymbol(library, name)
+
+	return err == nil && fn != 0
+}
+
+// Initialize the NDI Library, the libraryPath argument is optional and the library will be searched for as described
+// in Initialize if it is empty. The returned error describes why the library could not be used, see Initialize.
+// Like Initialize, every successful call must be matched by a call to Shutdown.
+func InitLibrary(libraryPath string) error {
+	return Initialize(WithLibraryPath(libraryPath))
+}
+
+// Whether the NDI library, or another backend, is ready to be used. Applications that start without NDI when
+// Initialize fails can use this to check if it is available.
+func IsInitialized() bool {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	return backend != nil
+}
@@ -0,0 +1,530 @@
+package gondi
+
+import (
+	"reflect"
+	"slices"
+	"unsafe"
+)
+
+// libraryBackend forwards every call to the functions registered from the NDI shared library in InitLibrary.
+//
+// Every struct passed to the library is pinned, together with the Go memory it points to, for the duration of the
+// call. The SDK copies what it needs before returning, except for frames sent asynchronously.
+type libraryBackend struct {
+	// Taken when the library is loaded, as the bound functions change when it is unloaded
+	capabilities SDKCapabilities
+}
+
+// Destroy NDI and unload the library, it can be loaded again with Initialize.
+func (libraryBackend) Destroy() error {
+	ndilib_destroy()
+	unbindLibrary()
+	library := ndi_shared_library
+	ndi_shared_library = 0
+
+	return closeLibrary(library)
+}
+
+func (libraryBackend) Version() string {
+	mystrptr := ndilib_version()
+	if mystrptr == 0 {
+		return "N/A"
+	}
+
+	return goString(mystrptr)
+}
+
+func (libraryBackend) SendCreate(name string, groups string, clockVideo bool, clockAudio bool) uintptr {
+	var p pinned
+	defer p.release()
+	settings := &sendCreateSettings{clockVideo: clockVideo, clockAudio: clockAudio}
+	if name != "" {
+		settings.name = p.cString(name)
+	}
+	if groups != "" {
+		settings.groups = p.cString(groups)
+	}
+	p.pin(unsafe.Pointer(settings))
+
+	return ndilib_send_create(settings)
+}
+
+// Destroying the sender is a synchronizing event, so an asynchronous frame is not in use anymore
+func (libraryBackend) SendDestroy(instance uintptr) {
+	ndilib_send_destroy(instance)
+}
+
+func (libraryBackend) SendVideoV2(instance uintptr, frame *VideoFrameV2) {
+	var p pinned
+	defer p.release()
+	p.videoFrame(frame)
+
+	ndilib_send_send_video_v2(instance, frame)
+}
+
+// The SDK uses the frame until the next synchronizing event, so the SendInstance keeps it pinned until then
+func (libraryBackend) SendVideoAsyncV2(instance uintptr, frame *VideoFrameV2) {
+	ndilib_send_send_video_async_v2(instance, frame)
+}
+
+func (libraryBackend) SendAudioV2(instance uintptr, frame *AudioFrameV2) {
+	var p pinned
+	defer p.release()
+	p.pin(unsafe.Pointer(frame), unsafe.Pointer(frame.Data), unsafe.Pointer(frame.Metadata))
+
+	ndilib_send_send_audio_v2(instance, frame)
+}
+
+func (libraryBackend) SendAudioV3(instance uintptr, frame *AudioFrameV3) error {
+	if ndilib_send_send_audio_v3 == nil {
+		return ErrNotSupported
+	}
+	var p pinned
+	defer p.release()
+	p.pin(unsafe.Pointer(frame), unsafe.Pointer(frame.Data), unsafe.Pointer(frame.Metadata))
+
+	ndilib_send_send_audio_v3(instance, frame)
+
+	return nil
+}
+
+// The interleaved functions take their own frame types, so the fields of the v3 frame are copied over
+func (libraryBackend) SendAudioInterleaved16s(instance uintptr, frame *AudioFrameV3) error {
+	if ndilib_util_send_send_audio_interleaved_16s == nil {
+		return ErrNotSupported
+	}
+	var p pinned
+	defer p.release()
+	interleaved := &audioFrameInterleaved16s{
+		sampleRate:  frame.SampleRate,
+		numChannels: frame.NumChannels,
+		numSamples:  frame.NumSamples,
+		timecode:    frame.Timecode,
+		data:        (*int16)(unsafe.Pointer(frame.Data)),
+	}
+	p.pin(unsafe.Pointer(interleaved), unsafe.Pointer(frame.Data))
+
+	ndilib_util_send_send_audio_interleaved_16s(instance, interleaved)
+
+	return nil
+}
+
+func (libraryBackend) SendAudioInterleaved32f(instance uintptr, frame *AudioFrameV3) error {
+	if ndilib_util_send_send_audio_interleaved_32f == nil {
+		return ErrNotSupported
+	}
+	var p pinned
+	defer p.release()
+	interleaved := &audioFrameInterleaved32f{
+		sampleRate:  frame.SampleRate,
+		numChannels: frame.NumChannels,
+		numSamples:  frame.NumSamples,
+		timecode:    frame.Timecode,
+		data:        (*float32)(unsafe.Pointer(frame.Data)),
+	}
+	p.pin(unsafe.Pointer(interleaved), unsafe.Pointer(frame.Data))
+
+	ndilib_util_send_send_audio_interleaved_32f(instance, interleaved)
+
+	return nil
+}
+
+func (libraryBackend) SendMetadata(instance uintptr, frame *MetadataFrame) {
+	var p pinned
+	defer p.release()
+	p.metadataFrame(frame)
+
+	ndilib_send_send_metadata(instance, frame)
+}
+
+func (libraryBackend) SendCapture(instance uintptr, frame *MetadataFrame, timeoutMs uint32) FrameType {
+	return FrameType(ndilib_send_capture(instance, frame, timeoutMs))
+}
+
+func (libraryBackend) SendFreeMetadata(instance uintptr, frame *MetadataFrame) {
+	ndilib_send_free_metadata(instance, frame)
+}
+
+func (libraryBackend) SendGetTally(instance uintptr, tally *Tally, timeoutMs uint32) bool {
+	return ndilib_send_get_tally(instance, tally, timeoutMs)
+}
+
+func (libraryBackend) SendGetNoConnections(instance uintptr, timeoutMs uint32) (int32, error) {
+	if ndilib_send_get_no_connections == nil {
+		return 0, ErrNotSupported
+	}
+
+	return ndilib_send_get_no_connections(instance, timeoutMs), nil
+}
+
+func (libraryBackend) SendAddConnectionMetadata(instance uintptr, frame *MetadataFrame) {
+	var p pinned
+	defer p.release()
+	p.metadataFrame(frame)
+
+	ndilib_send_add_connection_metadata(instance, frame)
+}
+
+func (libraryBackend) SendClearConnectionMetadata(instance uintptr) {
+	ndilib_send_clear_connection_metadata(instance)
+}
+
+func (libraryBackend) SendSetFailover(instance uintptr, source *Source) error {
+	if ndilib_send_set_failover == nil {
+		return ErrNotSupported
+	}
+	var p pinned
+	defer p.release()
+
+	ndilib_send_set_failover(instance, source.c(&p))
+
+	return nil
+}
+
+func (libraryBackend) FindCreateV2(showLocalSources bool, groups string, extraIPs string) uintptr {
+	var p pinned
+	defer p.release()
+	settings := &findCreateSettings{showLocalSources: showLocalSources}
+	if groups != "" {
+		settings.groups = p.cString(groups)
+	}
+	if extraIPs != "" {
+		settings.extraIPs = p.cString(extraIPs)
+	}
+	p.pin(unsafe.Pointer(settings))
+
+	return ndilib_find_create_v2(uintptr(unsafe.Pointer(settings)))
+}
+
+func (libraryBackend) FindDestroy(instance uintptr) {
+	ndilib_find_destroy(instance)
+}
+
+// The sources returned by the SDK are only valid until the next call, so they are copied to Go memory
+func (libraryBackend) FindGetCurrentSources(instance uintptr) []*Source {
+	var numSources uint32
+	ret := ndilib_find_get_current_sources(instance, uintptr(unsafe.Pointer(&numSources)))
+
+	sources := make([]*Source, numSources)
+
+	// We take the address and then dereference it to trick go vet from creating a possible misuse of unsafe.Pointer
+	blockp := *(*unsafe.Pointer)(unsafe.Pointer(&ret))
+
+	for i := range sources {
+		sources[i] = (*cSource)(blockp).goSource()
+		// Increment pointer
+		blockp = unsafe.Add(blockp, unsafe.Sizeof(cSource{}))
+	}
+
+	return sources
+}
+
+func (libraryBackend) FindWaitForSources(instance uintptr, timeoutMs uint32) bool {
+	return ndilib_find_wait_for_sources(instance, timeoutMs)
+}
+
+func (libraryBackend) RecvCreateV3(settings *NewRecvInstanceSettings) uintptr {
+	var p pinned
+	defer p.release()
+	intSettings := &recvCreateSettings{
+		colorFormat:      settings.ColorFormat,
+		bandwidth:        settings.Bandwidth,
+		allowVideoFields: settings.AllowVideoFields,
+	}
+	if settings.SourceToConnectTo != nil {
+		intSettings.sourceToConnectTo = *settings.SourceToConnectTo.c(&p)
+	}
+	if settings.Name != "" {
+		intSettings.name = p.cString(settings.Name)
+	}
+	p.pin(unsafe.Pointer(intSettings))
+
+	return ndilib_recv_create_v3(intSettings)
+}
+
+func (libraryBackend) RecvDestroy(instance uintptr) {
+	ndilib_recv_destroy(instance)
+}
+
+func (libraryBackend) RecvConnect(instance uintptr, source *Source) {
+	var p pinned
+	defer p.release()
+
+	ndilib_recv_connect(instance, source.c(&p))
+}
+
+func (libraryBackend) RecvCaptureV2(instance uintptr, vf *VideoFrameV2, af *AudioFrameV2, mf *MetadataFrame, timeoutMs uint32) FrameType {
+	return FrameType(ndilib_recv_capture_v2(instance, vf, af, mf, timeoutMs))
+}
+
+func (libraryBackend) RecvCaptureV3(instance uintptr, vf *VideoFrameV2, af *AudioFrameV3, mf *MetadataFrame, timeoutMs uint32) (FrameType, error) {
+	if ndilib_recv_capture_v3 == nil {
+		return FrameTypeError, ErrNotSupported
+	}
+
+	return FrameType(ndilib_recv_capture_v3(instance, vf, af, mf, timeoutMs)), nil
+}
+
+func (libraryBackend) RecvFreeVideoV2(instance uintptr, frame *VideoFrameV2) {
+	ndilib_recv_free_video_v2(instance, frame)
+}
+
+func (libraryBackend) RecvFreeAudioV2(instance uintptr, frame *AudioFrameV2) {
+	ndilib_recv_free_audio_v2(instance, frame)
+}
+
+func (libraryBackend) RecvFreeAudioV3(instance uintptr, frame *AudioFrameV3) error {
+	if ndilib_recv_free_audio_v3 == nil {
+		return ErrNotSupported
+	}
+	ndilib_recv_free_audio_v3(instance, frame)
+
+	return nil
+}
+
+func (libraryBackend) RecvFreeMetadata(instance uintptr, frame *MetadataFrame) {
+	ndilib_recv_free_metadata(instance, frame)
+}
+
+func (libraryBackend) RecvGetPerformance(instance uintptr, total *RecvPerformance, dropped *RecvPerformance) {
+	ndilib_recv_get_performance(instance, total, dropped)
+}
+
+func (libraryBackend) RecvSetTally(instance uintptr, tally *Tally) bool {
+	return ndilib_recv_set_tally(instance, tally)
+}
+
+func (libraryBackend) RecvSendMetadata(instance uintptr, frame *MetadataFrame) bool {
+	var p pinned
+	defer p.release()
+	p.metadataFrame(frame)
+
+	return ndilib_recv_send_metadata(instance, frame)
+}
+
+func (libraryBackend) RecvAddConnectionMetadata(instance uintptr, frame *MetadataFrame) bool {
+	var p pinned
+	defer p.release()
+	p.metadataFrame(frame)
+
+	return ndilib_recv_add_connection_metadata(instance, frame)
+}
+
+func (libraryBackend) RecvClearConnectionMetadata(instance uintptr) {
+	ndilib_recv_clear_connection_metadata(instance)
+}
+
+func (libraryBackend) RecvPTZIsSupported(instance uintptr) (bool, error) {
+	if ndilib_recv_ptz_is_supported == nil {
+		return false, ErrNotSupported
+	}
+
+	return ndilib_recv_ptz_is_supported(instance), nil
+}
+
+// Every PTZ function is optional, as the SDK added them over time
+func (libraryBackend) RecvPTZ(instance uintptr, command PTZCommand) (bool, error) {
+	v := command.Values
+	switch command.Op {
+	case PTZOpZoom:
+		if ndilib_recv_ptz_zoom == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_zoom(instance, v[0]), nil
+	case PTZOpZoomSpeed:
+		if ndilib_recv_ptz_zoom_speed == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_zoom_speed(instance, v[0]), nil
+	case PTZOpPanTilt:
+		if ndilib_recv_ptz_pan_tilt == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_pan_tilt(instance, v[0], v[1]), nil
+	case PTZOpPanTiltSpeed:
+		if ndilib_recv_ptz_pan_tilt_speed == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_pan_tilt_speed(instance, v[0], v[1]), nil
+	case PTZOpStorePreset:
+		if ndilib_recv_ptz_store_preset == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_store_preset(instance, command.Preset), nil
+	case PTZOpRecallPreset:
+		if ndilib_recv_ptz_recall_preset == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_recall_preset(instance, command.Preset, v[0]), nil
+	case PTZOpAutoFocus:
+		if ndilib_recv_ptz_auto_focus == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_auto_focus(instance), nil
+	case PTZOpFocus:
+		if ndilib_recv_ptz_focus == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_focus(instance, v[0]), nil
+	case PTZOpFocusSpeed:
+		if ndilib_recv_ptz_focus_speed == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_focus_speed(instance, v[0]), nil
+	case PTZOpWhiteBalanceAuto:
+		if ndilib_recv_ptz_white_balance_auto == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_white_balance_auto(instance), nil
+	case PTZOpWhiteBalanceIndoor:
+		if ndilib_recv_ptz_white_balance_indoor == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_white_balance_indoor(instance), nil
+	case PTZOpWhiteBalanceOutdoor:
+		if ndilib_recv_ptz_white_balance_outdoor == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_white_balance_outdoor(instance), nil
+	case PTZOpWhiteBalanceOneShot:
+		if ndilib_recv_ptz_white_balance_oneshot == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_white_balance_oneshot(instance), nil
+	case PTZOpWhiteBalanceManual:
+		if ndilib_recv_ptz_white_balance_manual == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_white_balance_manual(instance, v[0], v[1]), nil
+	case PTZOpExposureAuto:
+		if ndilib_recv_ptz_exposure_auto == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_exposure_auto(instance), nil
+	case PTZOpExposureManual:
+		if ndilib_recv_ptz_exposure_manual == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_exposure_manual(instance, v[0]), nil
+	case PTZOpExposureManualV2:
+		if ndilib_recv_ptz_exposure_manual_v2 == nil {
+			return false, ErrNotSupported
+		}
+		return ndilib_recv_ptz_exposure_manual_v2(instance, v[0], v[1], v[2]), nil
+	}
+
+	return false, ErrNotSupported
+}
+
+// The frame synchronizer functions were added together, so they are all there or none is
+func (libraryBackend) FrameSyncCreate(receiver uintptr) (uintptr, error) {
+	if ndilib_framesync_create == nil || ndilib_framesync_capture_audio == nil || ndilib_framesync_audio_queue_depth == nil {
+		return 0, ErrNotSupported
+	}
+
+	return ndilib_framesync_create(receiver), nil
+}
+
+func (libraryBackend) FrameSyncDestroy(instance uintptr) {
+	ndilib_framesync_destroy(instance)
+}
+
+func (libraryBackend) FrameSyncCaptureVideo(instance uintptr, frame *VideoFrameV2, fieldType FrameFormat) {
+	ndilib_framesync_capture_video(instance, frame, fieldType)
+}
+
+func (libraryBackend) FrameSyncFreeVideo(instance uintptr, frame *VideoFrameV2) {
+	ndilib_framesync_free_video(instance, frame)
+}
+
+func (libraryBackend) FrameSyncCaptureAudio(instance uintptr, frame *AudioFrameV2, sampleRate int32, numChannels int32, numSamples int32) {
+	ndilib_framesync_capture_audio(instance, frame, sampleRate, numChannels, numSamples)
+}
+
+func (libraryBackend) FrameSyncFreeAudio(instance uintptr, frame *AudioFrameV2) {
+	ndilib_framesync_free_audio(instance, frame)
+}
+
+func (libraryBackend) FrameSyncAudioQueueDepth(instance uintptr) int32 {
+	return ndilib_framesync_audio_queue_depth(instance)
+}
+
+func (libraryBackend) RoutingCreate(name string, groups string) (uintptr, error) {
+	if ndilib_routing_create == nil {
+		return 0, ErrNotSupported
+	}
+
+	var p pinned
+	defer p.release()
+	settings := &routingCreateSettings{name: p.cString(name)}
+	if groups != "" {
+		settings.groups = p.cString(groups)
+	}
+	p.pin(unsafe.Pointer(settings))
+
+	return ndilib_routing_create(uintptr(unsafe.Pointer(settings))), nil
+}
+
+func (libraryBackend) RoutingDestroy(instance uintptr) {
+	ndilib_routing_destroy(instance)
+}
+
+func (libraryBackend) RoutingChange(instance uintptr, source *Source) bool {
+	var p pinned
+	defer p.release()
+
+	return ndilib_routing_change(instance, uintptr(unsafe.Pointer(source.c(&p))))
+}
+
+func (libraryBackend) RoutingClear(instance uintptr) bool {
+	return ndilib_routing_clear(instance)
+}
+
+// Falls back to a Go implementation when the library does not export the conversion
+func (libraryBackend) AudioFromInterleaved32fV2(src *AudioFrameV2, dst *AudioFrameV2) {
+	if ndilib_util_audio_from_interleaved_32f_v2 == nil {
+		audioFromInterleaved32f(src, dst)
+		return
+	}
+	ndilib_util_audio_from_interleaved_32f_v2(uintptr(unsafe.Pointer(src)), uintptr(unsafe.Pointer(dst)))
+}
+
+// Falls back to a Go implementation when the library does not export the conversion
+func (libraryBackend) AudioToInterleaved32fV2(src *AudioFrameV2, dst *AudioFrameV2) {
+	if ndilib_util_audio_to_interleaved_32f_v2 == nil {
+		audioToInterleaved32f(src, dst)
+		return
+	}
+	ndilib_util_audio_to_interleaved_32f_v2(uintptr(unsafe.Pointer(src)), uintptr(unsafe.Pointer(dst)))
+}
+
+func (b libraryBackend) Capabilities() SDKCapabilities {
+	caps := b.capabilities
+	caps.Missing = slices.Clone(caps.Missing)
+
+	return caps
+}
+
+// Report the features of the library just bound, called with lifecycleMu held
+func libraryCapabilities(library uintptr) SDKCapabilities {
+	caps := SDKCapabilities{
+		AudioV3:          ndilib_send_send_audio_v3 != nil && ndilib_recv_capture_v3 != nil && ndilib_recv_free_audio_v3 != nil,
+		InterleavedAudio: ndilib_util_send_send_audio_interleaved_16s != nil && ndilib_util_send_send_audio_interleaved_32f != nil,
+		ConnectionCount:  ndilib_send_get_no_connections != nil,
+		Failover:         ndilib_send_set_failover != nil,
+		Routing:          ndilib_routing_create != nil,
+		PTZ:              ndilib_recv_ptz_is_supported != nil,
+		FrameSync:        ndilib_framesync_create != nil,
+		KVM:              hasSymbol(library, "NDIlib_recv_kvm_is_supported"),
+		Listener:         hasSymbol(library, "NDIlib_recv_listener_create"),
+		Advertiser:       hasSymbol(library, "NDIlib_recv_advertiser_create"),
+		Genlock:          hasSymbol(library, "NDIlib_genlock_create"),
+	}
+	for _, symbol := range librarySymbols {
+		if reflect.ValueOf(symbol.fptr).Elem().IsNil() {
+			caps.Missing = append(caps.Missing, symbol.name)
+		}
+	}
+
+	return caps
+}
@@ -0,0 +1,97 @@
+package gondi
+
+import "sync"
+
+var (
+	// Guards backend, refs and instances
+	lifecycleMu sync.Mutex
+
+	// Number of Initialize calls not matched by Shutdown yet
+	refs int
+
+	// Functions destroying every instance that has not been destroyed yet, keyed by the instance
+	instances = map[any]func(){}
+)
+
+// Register a newly created instance, so Shutdown can destroy it.
+func trackInstance(instance any, destroy func()) {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	instances[instance] = destroy
+}
+
+// Unregister an instance which is about to be destroyed, returns false if it was already destroyed.
+func untrackInstance(instance any) bool {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	if _, ok := instances[instance]; !ok {
+		return false
+	}
+	delete(instances, instance)
+
+	return true
+}
+
+// Report whether an instance is still alive, it is not once destroyed directly or by Shutdown.
+func isTracked(instance any) bool {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	_, ok := instances[instance]
+
+	return ok
+}
+
+// Call fn with the backend if the instance is still alive, holding the lock so it can't be destroyed meanwhile. fn
+// must call the backend it is given, not currentBackend. Returns false if the instance was already destroyed.
+func whileTracked(instance any, fn func(b Backend)) bool {
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	if _, ok := instances[instance]; !ok {
+		return false
+	}
+	fn(backend)
+
+	return true
+}
+
+// Release one reference taken by Initialize, InitLibrary or UseBackend. When the last one is released, every
+// Send, Recv, Find and Routing instance still alive is destroyed, NDI is destroyed and the library unloaded, after
+// which Initialize can be called again. Calling Shutdown more often than Initialize does nothing.
+//
+// Instances must not be in use by other goroutines while the last reference is released.
+func Shutdown() error {
+	lifecycleMu.Lock()
+	if refs == 0 {
+		lifecycleMu.Unlock()
+		return nil
+	}
+	refs--
+	if refs > 0 {
+		lifecycleMu.Unlock()
+		return nil
+	}
+
+	live := instances
+	instances = map[any]func(){}
+	b := backend
+	lifecycleMu.Unlock()
+
+	for _, destroy := range live {
+		destroy()
+	}
+
+	lifecycleMu.Lock()
+	defer lifecycleMu.Unlock()
+
+	// Someone may have initialized again while the instances were being destroyed
+	if refs > 0 || backend != b {
+		return nil
+	}
+	backend = nil
+
+	return b.Destroy()
+}
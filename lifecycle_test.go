@@ -0,0 +1,64 @@
+package gondi
+
+import "testing"
+
+func TestShutdown(t *testing.T) {
+	useTestBackend(t, NewLoopbackBackend())
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	sender, err := NewSendInstance("shutdown", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver, err := NewRecvInstance(&NewRecvInstanceSettings{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiver.Destroy()
+
+	// The second reference keeps everything alive
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if !IsInitialized() {
+		t.Fatal("the first Shutdown released the last reference")
+	}
+	if _, ok := instances[sender]; !ok {
+		t.Fatal("the sender was destroyed by the first Shutdown")
+	}
+
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if IsInitialized() {
+		t.Fatal("the library is still initialized after the last Shutdown")
+	}
+	if len(instances) != 0 {
+		t.Fatalf("%d instances are still alive after Shutdown", len(instances))
+	}
+
+	// Destroying an instance already destroyed by Shutdown does nothing
+	sender.Destroy()
+	receiver.Destroy()
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackendSwitch(t *testing.T) {
+	// Calls racing with UseBackend go to either backend, never to a half set one
+	useTestBackend(t, NewLoopbackBackend())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			GetVersion()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		UseBackend(NewLoopbackBackend())
+	}
+	<-done
+}
@@ -0,0 +1,1217 @@
+package gondi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// Number of frames of each type a loopback receiver queues before it starts dropping the oldest ones.
+const loopbackQueueDepth = 16
+
+// Machine name used for every source announced by the loopback backend.
+const loopbackMachineName = "LOOPBACK"
+
+// LoopbackBackend is a pure Go Backend that never touches the NDI runtime or the network. Video, audio and metadata
+// frames sent on a SendInstance are copied to every RecvInstance connected to it, FindInstance lists the senders and
+// routing instances created in the same process, and tally and connection counts behave like the real SDK.
+//
+// Frames are delivered in the FourCC they were sent with, the color format requested by a receiver is ignored.
+//
+// PTZ works like in the SDK: a sender announces support with a <ndi_capabilities ntk_ptz="true"/> connection metadata
+// frame, and receives the commands of PTZ as metadata frames, see PTZCommand.XML.
+//
+//	gondi.UseBackend(gondi.NewLoopbackBackend())
+type LoopbackBackend struct {
+	mu      sync.Mutex
+	changed chan struct{}
+	nextID  uintptr
+	seq     uint64
+
+	// Incremented every time the list of sources changes
+	sourcesGen uint64
+
+	senders   map[uintptr]*loopSender
+	receivers map[uintptr]*loopReceiver
+	finders   map[uintptr]*loopFinder
+	routes    map[uintptr]*loopRoute
+	syncs     map[uintptr]*loopFrameSync
+}
+
+type loopSender struct {
+	name      string
+	groups    []string
+	fullName  string
+	address   string
+	destroyed bool
+
+	clockVideo, clockAudio bool
+	videoClock, audioClock loopClock
+
+	failover           string
+	connectionMetadata []string
+	metadata           []loopMetadata
+
+	tally         Tally
+	reportedTally Tally
+}
+
+type loopReceiver struct {
+	bandwidth RecvBandwidth
+	source    string
+	connected *loopSender
+	destroyed bool
+
+	statusChanged bool
+	video         []loopVideo
+	audio         []loopAudio
+	metadata      []loopMetadata
+
+	tally              Tally
+	connectionMetadata []string
+	total, dropped     RecvPerformance
+}
+
+type loopFinder struct {
+	showLocalSources bool
+	groups           []string
+	seen             uint64
+	destroyed        bool
+}
+
+type loopRoute struct {
+	groups   []string
+	fullName string
+	address  string
+	target   string
+}
+
+// A frame synchronizer takes the frames of its receiver. Audio is kept as one queue per channel, and is not resampled
+// as the senders and receivers of the loopback backend all share the same clock.
+type loopFrameSync struct {
+	receiver    uintptr
+	video       *loopVideo
+	sampleRate  int32
+	numChannels int32
+	timecode    int64
+	audio       [][]float32
+}
+
+type loopVideo struct {
+	seq      uint64
+	frame    VideoFrameV2
+	data     []byte
+	metadata string
+}
+
+// Audio is kept as tightly packed planar float32, which is what both capture functions return.
+type loopAudio struct {
+	seq         uint64
+	sampleRate  int32
+	numChannels int32
+	numSamples  int32
+	timecode    int64
+	timestamp   int64
+	data        []float32
+	metadata    string
+}
+
+type loopMetadata struct {
+	seq      uint64
+	timecode int64
+	data     string
+}
+
+type loopClock struct {
+	next time.Time
+}
+
+// Create a new loopback backend, activate it with UseBackend.
+func NewLoopbackBackend() *LoopbackBackend {
+	return &LoopbackBackend{
+		changed:   make(chan struct{}),
+		senders:   map[uintptr]*loopSender{},
+		receivers: map[uintptr]*loopReceiver{},
+		finders:   map[uintptr]*loopFinder{},
+		routes:    map[uintptr]*loopRoute{},
+		syncs:     map[uintptr]*loopFrameSync{},
+	}
+}
+
+func (b *LoopbackBackend) Version() string {
+	return "gondi loopback backend"
+}
+
+func (b *LoopbackBackend) Capabilities() SDKCapabilities {
+	return SDKCapabilities{
+		AudioV3:          true,
+		InterleavedAudio: true,
+		ConnectionCount:  true,
+		Failover:         true,
+		Routing:          true,
+		PTZ:              true,
+		FrameSync:        true,
+	}
+}
+
+// Destroy every instance still known to the backend, waking up anyone blocked on them.
+func (b *LoopbackBackend) Destroy() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, s := range b.senders {
+		s.destroyed = true
+		delete(b.senders, id)
+	}
+	for id, r := range b.receivers {
+		r.destroyed = true
+		delete(b.receivers, id)
+	}
+	for id, f := range b.finders {
+		f.destroyed = true
+		delete(b.finders, id)
+	}
+	for id := range b.routes {
+		delete(b.routes, id)
+	}
+	for id := range b.syncs {
+		delete(b.syncs, id)
+	}
+	b.sourcesGen++
+	b.notify()
+
+	return nil
+}
+
+// Wake up everyone waiting in waitLocked. Must be called with b.mu held.
+func (b *LoopbackBackend) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// Wait until ready returns true or the timeout expires, returning whether ready returned true.
+// Must be called with b.mu held, which is released while waiting and held again when returning.
+func (b *LoopbackBackend) waitLocked(timeoutMs uint32, ready func() bool) bool {
+	deadline := time.Now().Add(time.Duration(timeoutMs) * time.Millisecond)
+	for !ready() {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return false
+		}
+
+		changed := b.changed
+		b.mu.Unlock()
+		timer := time.NewTimer(remaining)
+		select {
+		case <-changed:
+		case <-timer.C:
+		}
+		timer.Stop()
+		b.mu.Lock()
+	}
+
+	return true
+}
+
+func (b *LoopbackBackend) newID() uintptr {
+	b.nextID++
+	return b.nextID
+}
+
+func (b *LoopbackBackend) nextSeq() uint64 {
+	b.seq++
+	return b.seq
+}
+
+// Find the sender a source name or address ends up at, following routing instances.
+func (b *LoopbackBackend) resolve(source string) *loopSender {
+	for hops := 0; hops < 8 && source != ""; hops++ {
+		for _, s := range b.senders {
+			if s.fullName == source || s.address == source {
+				return s
+			}
+		}
+
+		next, found := "", false
+		for _, r := range b.routes {
+			if r.fullName == source || r.address == source {
+				next, found = r.target, true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+		source = next
+	}
+
+	return nil
+}
+
+// Update the connections of every receiver after the topology changed. Newly connected receivers get the connection
+// metadata of the sender, and the sender gets the connection metadata of the receiver.
+func (b *LoopbackBackend) relink() {
+	for _, r := range b.receivers {
+		s := b.resolve(r.source)
+		if s == r.connected {
+			continue
+		}
+
+		r.connected = s
+		r.statusChanged = true
+		if s == nil {
+			continue
+		}
+		for _, data := range s.connectionMetadata {
+			r.pushMetadata(loopMetadata{b.nextSeq(), SendTimecodeEmpty, data}, false)
+		}
+		for _, data := range r.connectionMetadata {
+			s.pushMetadata(loopMetadata{b.nextSeq(), SendTimecodeEmpty, data})
+		}
+	}
+
+	for _, s := range b.senders {
+		s.tally = b.senderTally(s)
+	}
+
+	b.notify()
+}
+
+func (b *LoopbackBackend) senderTally(s *loopSender) Tally {
+	tally := Tally{}
+	for _, r := range b.receivers {
+		if r.connected == s {
+			tally.Program = tally.Program || r.tally.Program
+			tally.Preview = tally.Preview || r.tally.Preview
+		}
+	}
+
+	return tally
+}
+
+func (b *LoopbackBackend) connections(s *loopSender) int32 {
+	var count int32
+	for _, r := range b.receivers {
+		if r.connected == s {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (b *LoopbackBackend) SendCreate(name string, groups string, clockVideo bool, clockAudio bool) uintptr {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.newID()
+	b.senders[id] = &loopSender{
+		name:       name,
+		groups:     parseGroups(groups),
+		fullName:   fmt.Sprintf("%s (%s)", loopbackMachineName, name),
+		address:    loopbackAddress(id),
+		clockVideo: clockVideo,
+		clockAudio: clockAudio,
+	}
+	b.sourcesGen++
+	b.relink()
+
+	return id
+}
+
+func (b *LoopbackBackend) SendDestroy(instance uintptr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.senders[instance]
+	if s == nil {
+		return
+	}
+
+	s.destroyed = true
+	delete(b.senders, instance)
+	if s.failover != "" {
+		for _, r := range b.receivers {
+			if r.connected == s {
+				r.source = s.failover
+			}
+		}
+	}
+	b.sourcesGen++
+	b.relink()
+}
+
+func (b *LoopbackBackend) SendVideoV2(instance uintptr, frame *VideoFrameV2) {
+	b.sendVideo(instance, frame)
+}
+
+// The loopback backend copies the frame before returning, so the asynchronous version is as safe as the synchronous one.
+func (b *LoopbackBackend) SendVideoAsyncV2(instance uintptr, frame *VideoFrameV2) {
+	b.sendVideo(instance, frame)
+}
+
+func (b *LoopbackBackend) sendVideo(instance uintptr, frame *VideoFrameV2) {
+	// A nil frame only flushes asynchronous sends in the SDK, there is nothing to flush here
+	if frame == nil {
+		return
+	}
+
+	b.mu.Lock()
+	s := b.senders[instance]
+	if s == nil {
+		b.mu.Unlock()
+		return
+	}
+	var delay time.Duration
+	if s.clockVideo && frame.FrameRateN > 0 && frame.FrameRateD > 0 {
+		delay = s.videoClock.advance(time.Duration(int64(time.Second) * int64(frame.FrameRateD) / int64(frame.FrameRateN)))
+	}
+	b.mu.Unlock()
+
+	time.Sleep(delay)
+	video := loopVideo{frame: *frame, metadata: goString(uintptr(unsafe.Pointer(frame.Metadata)))}
+	video.data, video.frame.LineStride = copyVideoData(frame)
+	video.frame.Timecode = synthesizeTimecode(frame.Timecode)
+	video.frame.Timestamp = loopbackTimestamp()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	video.seq = b.nextSeq()
+	for _, r := range b.receivers {
+		if r.connected == s && r.bandwidth != RecvBandwidthMetadataOnly && r.bandwidth != RecvBandwidthAudioOnly {
+			r.total.VideoFrames++
+			if len(r.video) >= loopbackQueueDepth {
+				r.video = r.video[1:]
+				r.dropped.VideoFrames++
+			}
+			r.video = append(r.video, video)
+		}
+	}
+	b.notify()
+}
+
+func (b *LoopbackBackend) SendAudioV2(instance uintptr, frame *AudioFrameV2) {
+	if frame == nil {
+		return
+	}
+	data := copyPlanarAudio(frame.Data, frame.NumChannels, frame.NumSamples, frame.ChannelStride)
+	b.sendAudio(instance, frame.SampleRate, frame.NumChannels, frame.NumSamples, frame.Timecode, frame.Metadata, data)
+}
+
+// Only planar float audio is supported, a frame without FourCC is taken as such.
+func (b *LoopbackBackend) SendAudioV3(instance uintptr, frame *AudioFrameV3) error {
+	if frame == nil {
+		return nil
+	}
+	if frame.FourCC != FourCCAudioTypeFLTP && frame.FourCC != (FourCCAudioType{}) {
+		return ErrNotSupported
+	}
+	data := copyPlanarAudio((*float32)(unsafe.Pointer(frame.Data)), frame.NumChannels, frame.NumSamples, frame.ChannelStride)
+	b.sendAudio(instance, frame.SampleRate, frame.NumChannels, frame.NumSamples, frame.Timecode, frame.Metadata, data)
+
+	return nil
+}
+
+func (b *LoopbackBackend) SendAudioInterleaved16s(instance uintptr, frame *AudioFrameV3) error {
+	if frame == nil {
+		return nil
+	}
+	data := make([]float32, frame.NumChannels*frame.NumSamples)
+	if frame.Data != nil && len(data) > 0 {
+		src := unsafe.Slice((*int16)(unsafe.Pointer(frame.Data)), len(data))
+		for ch := int32(0); ch < frame.NumChannels; ch++ {
+			for i := int32(0); i < frame.NumSamples; i++ {
+				data[ch*frame.NumSamples+i] = float32(src[i*frame.NumChannels+ch]) / 32768
+			}
+		}
+	}
+	b.sendAudio(instance, frame.SampleRate, frame.NumChannels, frame.NumSamples, frame.Timecode, frame.Metadata, data)
+
+	return nil
+}
+
+func (b *LoopbackBackend) SendAudioInterleaved32f(instance uintptr, frame *AudioFrameV3) error {
+	if frame == nil {
+		return nil
+	}
+	data := make([]float32, frame.NumChannels*frame.NumSamples)
+	if frame.Data != nil && len(data) > 0 {
+		deinterleave32f(unsafe.Slice((*float32)(unsafe.Pointer(frame.Data)), len(data)), data, frame.NumChannels, frame.NumSamples, frame.NumSamples)
+	}
+	b.sendAudio(instance, frame.SampleRate, frame.NumChannels, frame.NumSamples, frame.Timecode, frame.Metadata, data)
+
+	return nil
+}
+
+func (b *LoopbackBackend) sendAudio(instance uintptr, sampleRate, numChannels, numSamples int32, timecode int64, metadata *byte, data []float32) {
+	b.mu.Lock()
+	s := b.senders[instance]
+	if s == nil {
+		b.mu.Unlock()
+		return
+	}
+	var delay time.Duration
+	if s.clockAudio && sampleRate > 0 {
+		delay = s.audioClock.advance(time.Duration(int64(time.Second) * int64(numSamples) / int64(sampleRate)))
+	}
+	b.mu.Unlock()
+
+	time.Sleep(delay)
+	audio := loopAudio{
+		sampleRate:  sampleRate,
+		numChannels: numChannels,
+		numSamples:  numSamples,
+		timecode:    synthesizeTimecode(timecode),
+		timestamp:   loopbackTimestamp(),
+		data:        data,
+		metadata:    goString(uintptr(unsafe.Pointer(metadata))),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	audio.seq = b.nextSeq()
+	for _, r := range b.receivers {
+		if r.connected == s && r.bandwidth != RecvBandwidthMetadataOnly {
+			r.total.AudioFrames++
+			if len(r.audio) >= loopbackQueueDepth {
+				r.audio = r.audio[1:]
+				r.dropped.AudioFrames++
+			}
+			r.audio = append(r.audio, audio)
+		}
+	}
+	b.notify()
+}
+
+func (b *LoopbackBackend) SendMetadata(instance uintptr, frame *MetadataFrame) {
+	if frame == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.senders[instance]
+	if s == nil {
+		return
+	}
+	metadata := loopMetadata{b.nextSeq(), synthesizeTimecode(frame.Timecode), frame.GetData()}
+	for _, r := range b.receivers {
+		if r.connected == s {
+			r.pushMetadata(metadata, true)
+		}
+	}
+	b.notify()
+}
+
+func (b *LoopbackBackend) SendCapture(instance uintptr, frame *MetadataFrame, timeoutMs uint32) FrameType {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.senders[instance]
+	if s == nil {
+		return FrameTypeError
+	}
+	if !b.waitLocked(timeoutMs, func() bool { return s.destroyed || len(s.metadata) > 0 }) {
+		return FrameTypeNone
+	}
+	if s.destroyed {
+		return FrameTypeError
+	}
+
+	s.metadata[0].fill(frame)
+	s.metadata = s.metadata[1:]
+
+	return FrameTypeMetadata
+}
+
+func (b *LoopbackBackend) SendFreeMetadata(instance uintptr, frame *MetadataFrame) {}
+
+func (b *LoopbackBackend) SendGetTally(instance uintptr, tally *Tally, timeoutMs uint32) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.senders[instance]
+	if s == nil {
+		return false
+	}
+	changed := b.waitLocked(timeoutMs, func() bool { return s.destroyed || s.tally != s.reportedTally })
+	*tally = s.tally
+	s.reportedTally = s.tally
+
+	return changed && !s.destroyed
+}
+
+func (b *LoopbackBackend) SendGetNoConnections(instance uintptr, timeoutMs uint32) (int32, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.senders[instance]
+	if s == nil {
+		return 0, nil
+	}
+	b.waitLocked(timeoutMs, func() bool { return s.destroyed || b.connections(s) > 0 })
+
+	return b.connections(s), nil
+}
+
+func (b *LoopbackBackend) SendAddConnectionMetadata(instance uintptr, frame *MetadataFrame) {
+	if frame == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.senders[instance]
+	if s == nil {
+		return
+	}
+	data := frame.GetData()
+	s.connectionMetadata = append(s.connectionMetadata, data)
+	for _, r := range b.receivers {
+		if r.connected == s {
+			r.pushMetadata(loopMetadata{b.nextSeq(), SendTimecodeEmpty, data}, false)
+			r.statusChanged = true
+		}
+	}
+	b.notify()
+}
+
+func (b *LoopbackBackend) SendClearConnectionMetadata(instance uintptr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if s := b.senders[instance]; s != nil {
+		s.connectionMetadata = nil
+	}
+}
+
+func (b *LoopbackBackend) SendSetFailover(instance uintptr, source *Source) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if s := b.senders[instance]; s != nil {
+		s.failover = sourceKey(source)
+	}
+
+	return nil
+}
+
+func (b *LoopbackBackend) FindCreateV2(showLocalSources bool, groups string, extraIPs string) uintptr {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.newID()
+	b.finders[id] = &loopFinder{showLocalSources: showLocalSources, groups: parseGroups(groups)}
+
+	return id
+}
+
+func (b *LoopbackBackend) FindDestroy(instance uintptr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if f := b.finders[instance]; f != nil {
+		f.destroyed = true
+		delete(b.finders, instance)
+		b.notify()
+	}
+}
+
+// Every loopback source lives on the local machine, so a finder which does not show local sources sees nothing.
+func (b *LoopbackBackend) FindGetCurrentSources(instance uintptr) []*Source {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f := b.finders[instance]
+	if f == nil || !f.showLocalSources {
+		return []*Source{}
+	}
+
+	sources := []*Source{}
+	add := func(name string, address string) {
+		sources = append(sources, NewSource(name, address))
+	}
+	for _, s := range b.senders {
+		if groupsIntersect(f.groups, s.groups) {
+			add(s.fullName, s.address)
+		}
+	}
+	for _, r := range b.routes {
+		if groupsIntersect(f.groups, r.groups) {
+			add(r.fullName, r.address)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name() < sources[j].Name() })
+
+	return sources
+}
+
+func (b *LoopbackBackend) FindWaitForSources(instance uintptr, timeoutMs uint32) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	f := b.finders[instance]
+	if f == nil {
+		return false
+	}
+	if !b.waitLocked(timeoutMs, func() bool { return f.destroyed || f.seen != b.sourcesGen }) || f.destroyed {
+		return false
+	}
+	f.seen = b.sourcesGen
+
+	return true
+}
+
+func (b *LoopbackBackend) RecvCreateV3(settings *NewRecvInstanceSettings) uintptr {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.newID()
+	b.receivers[id] = &loopReceiver{
+		bandwidth: settings.Bandwidth,
+		source:    sourceKey(settings.SourceToConnectTo),
+	}
+	b.relink()
+
+	return id
+}
+
+func (b *LoopbackBackend) RecvDestroy(instance uintptr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r := b.receivers[instance]; r != nil {
+		r.destroyed = true
+		delete(b.receivers, instance)
+		b.relink()
+	}
+}
+
+func (b *LoopbackBackend) RecvConnect(instance uintptr, source *Source) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r := b.receivers[instance]; r != nil {
+		r.source = sourceKey(source)
+		b.relink()
+	}
+}
+
+func (b *LoopbackBackend) RecvCaptureV2(instance uintptr, vf *VideoFrameV2, af *AudioFrameV2, mf *MetadataFrame, timeoutMs uint32) FrameType {
+	return b.recvCapture(instance, vf, af, nil, mf, timeoutMs)
+}
+
+func (b *LoopbackBackend) RecvCaptureV3(instance uintptr, vf *VideoFrameV2, af *AudioFrameV3, mf *MetadataFrame, timeoutMs uint32) (FrameType, error) {
+	return b.recvCapture(instance, vf, nil, af, mf, timeoutMs), nil
+}
+
+func (b *LoopbackBackend) recvCapture(instance uintptr, vf *VideoFrameV2, af2 *AudioFrameV2, af3 *AudioFrameV3, mf *MetadataFrame, timeoutMs uint32) FrameType {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.receivers[instance]
+	if r == nil {
+		return FrameTypeError
+	}
+	wantAudio := af2 != nil || af3 != nil
+	ready := func() bool {
+		return r.destroyed || r.statusChanged ||
+			(vf != nil && len(r.video) > 0) ||
+			(wantAudio && len(r.audio) > 0) ||
+			(mf != nil && len(r.metadata) > 0)
+	}
+	if !b.waitLocked(timeoutMs, ready) {
+		return FrameTypeNone
+	}
+	if r.destroyed {
+		return FrameTypeError
+	}
+	if r.statusChanged {
+		r.statusChanged = false
+		return FrameTypeStatusChange
+	}
+
+	// Return the oldest queued frame among the requested types
+	next, seq := FrameTypeNone, uint64(math.MaxUint64)
+	if vf != nil && len(r.video) > 0 && r.video[0].seq < seq {
+		next, seq = FrameTypeVideo, r.video[0].seq
+	}
+	if wantAudio && len(r.audio) > 0 && r.audio[0].seq < seq {
+		next, seq = FrameTypeAudio, r.audio[0].seq
+	}
+	if mf != nil && len(r.metadata) > 0 && r.metadata[0].seq < seq {
+		next = FrameTypeMetadata
+	}
+
+	switch next {
+	case FrameTypeVideo:
+		r.video[0].fill(vf)
+		r.video = r.video[1:]
+	case FrameTypeAudio:
+		if af2 != nil {
+			r.audio[0].fillV2(af2)
+		} else {
+			r.audio[0].fillV3(af3)
+		}
+		r.audio = r.audio[1:]
+	case FrameTypeMetadata:
+		r.metadata[0].fill(mf)
+		r.metadata = r.metadata[1:]
+	}
+
+	return next
+}
+
+// Captured frames reference memory owned by the Go garbage collector, so there is nothing to free.
+func (b *LoopbackBackend) RecvFreeVideoV2(instance uintptr, frame *VideoFrameV2) {}
+
+func (b *LoopbackBackend) RecvFreeAudioV2(instance uintptr, frame *AudioFrameV2) {}
+
+func (b *LoopbackBackend) RecvFreeAudioV3(instance uintptr, frame *AudioFrameV3) error {
+	return nil
+}
+
+func (b *LoopbackBackend) RecvFreeMetadata(instance uintptr, frame *MetadataFrame) {}
+
+func (b *LoopbackBackend) RecvGetPerformance(instance uintptr, total *RecvPerformance, dropped *RecvPerformance) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r := b.receivers[instance]; r != nil {
+		*total = r.total
+		*dropped = r.dropped
+	}
+}
+
+func (b *LoopbackBackend) RecvSetTally(instance uintptr, tally *Tally) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.receivers[instance]
+	if r == nil {
+		return false
+	}
+	r.tally = *tally
+	if r.connected == nil {
+		return false
+	}
+	r.connected.tally = b.senderTally(r.connected)
+	b.notify()
+
+	return true
+}
+
+func (b *LoopbackBackend) RecvSendMetadata(instance uintptr, frame *MetadataFrame) bool {
+	if frame == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.receivers[instance]
+	if r == nil || r.connected == nil {
+		return false
+	}
+	r.connected.pushMetadata(loopMetadata{b.nextSeq(), synthesizeTimecode(frame.Timecode), frame.GetData()})
+	b.notify()
+
+	return true
+}
+
+func (b *LoopbackBackend) RecvAddConnectionMetadata(instance uintptr, frame *MetadataFrame) bool {
+	if frame == nil {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.receivers[instance]
+	if r == nil {
+		return false
+	}
+	data := frame.GetData()
+	r.connectionMetadata = append(r.connectionMetadata, data)
+	if r.connected != nil {
+		r.connected.pushMetadata(loopMetadata{b.nextSeq(), SendTimecodeEmpty, data})
+		b.notify()
+	}
+
+	return true
+}
+
+func (b *LoopbackBackend) RecvClearConnectionMetadata(instance uintptr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if r := b.receivers[instance]; r != nil {
+		r.connectionMetadata = nil
+	}
+}
+
+func (b *LoopbackBackend) RecvPTZIsSupported(instance uintptr) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.receivers[instance]
+
+	return r != nil && r.connected != nil && r.connected.ptzSupported(), nil
+}
+
+func (b *LoopbackBackend) RecvPTZ(instance uintptr, command PTZCommand) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.receivers[instance]
+	if r == nil || r.connected == nil || !r.connected.ptzSupported() {
+		return false, nil
+	}
+	r.connected.pushMetadata(loopMetadata{b.nextSeq(), SendTimecodeEmpty, command.XML()})
+	b.notify()
+
+	return true, nil
+}
+
+func (b *LoopbackBackend) FrameSyncCreate(receiver uintptr) (uintptr, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.receivers[receiver] == nil {
+		return 0, nil
+	}
+	id := b.newID()
+	b.syncs[id] = &loopFrameSync{receiver: receiver}
+
+	return id, nil
+}
+
+func (b *LoopbackBackend) FrameSyncDestroy(instance uintptr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.syncs, instance)
+}
+
+// Only progressive frames are produced by the loopback backend, so the field type is ignored
+func (b *LoopbackBackend) FrameSyncCaptureVideo(instance uintptr, frame *VideoFrameV2, fieldType FrameFormat) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fs := b.syncs[instance]
+	if fs == nil {
+		*frame = VideoFrameV2{}
+		return
+	}
+	fs.drain(b.receivers[fs.receiver])
+	if fs.video == nil {
+		*frame = VideoFrameV2{}
+		return
+	}
+	fs.video.fill(frame)
+}
+
+func (b *LoopbackBackend) FrameSyncFreeVideo(instance uintptr, frame *VideoFrameV2) {}
+
+func (b *LoopbackBackend) FrameSyncCaptureAudio(instance uintptr, frame *AudioFrameV2, sampleRate int32, numChannels int32, numSamples int32) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	*frame = AudioFrameV2{}
+	fs := b.syncs[instance]
+	if fs == nil {
+		return
+	}
+	fs.drain(b.receivers[fs.receiver])
+	if sampleRate == 0 {
+		sampleRate = fs.sampleRate
+	}
+	if numChannels == 0 {
+		numChannels = fs.numChannels
+	}
+	if sampleRate == 0 || numChannels == 0 || numSamples <= 0 {
+		return
+	}
+
+	data := make([]float32, numChannels*numSamples)
+	for ch := 0; ch < int(numChannels) && ch < len(fs.audio); ch++ {
+		n := copy(data[ch*int(numSamples):(ch+1)*int(numSamples)], fs.audio[ch])
+		fs.audio[ch] = fs.audio[ch][n:]
+	}
+	for ch := int(numChannels); ch < len(fs.audio); ch++ {
+		fs.audio[ch] = fs.audio[ch][min(int(numSamples), len(fs.audio[ch])):]
+	}
+
+	*frame = AudioFrameV2{
+		SampleRate:    sampleRate,
+		NumChannels:   numChannels,
+		NumSamples:    numSamples,
+		Timecode:      fs.timecode,
+		Data:          &data[0],
+		ChannelStride: numSamples * 4,
+		Timestamp:     loopbackTimestamp(),
+	}
+}
+
+func (b *LoopbackBackend) FrameSyncFreeAudio(instance uintptr, frame *AudioFrameV2) {}
+
+func (b *LoopbackBackend) FrameSyncAudioQueueDepth(instance uintptr) int32 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	fs := b.syncs[instance]
+	if fs == nil {
+		return 0
+	}
+	fs.drain(b.receivers[fs.receiver])
+	if len(fs.audio) == 0 {
+		return 0
+	}
+
+	return int32(len(fs.audio[0]))
+}
+
+func (b *LoopbackBackend) RoutingCreate(name string, groups string) (uintptr, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	id := b.newID()
+	b.routes[id] = &loopRoute{
+		groups:   parseGroups(groups),
+		fullName: fmt.Sprintf("%s (%s)", loopbackMachineName, name),
+		address:  loopbackAddress(id),
+	}
+	b.sourcesGen++
+	b.relink()
+
+	return id, nil
+}
+
+func (b *LoopbackBackend) RoutingDestroy(instance uintptr) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.routes[instance]; ok {
+		delete(b.routes, instance)
+		b.sourcesGen++
+		b.relink()
+	}
+}
+
+func (b *LoopbackBackend) RoutingChange(instance uintptr, source *Source) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := b.routes[instance]
+	if r == nil {
+		return false
+	}
+	r.target = sourceKey(source)
+	b.relink()
+
+	return true
+}
+
+func (b *LoopbackBackend) RoutingClear(instance uintptr) bool {
+	return b.RoutingChange(instance, nil)
+}
+
+func (b *LoopbackBackend) AudioFromInterleaved32fV2(src *AudioFrameV2, dst *AudioFrameV2) {
+	audioFromInterleaved32f(src, dst)
+}
+
+func (b *LoopbackBackend) AudioToInterleaved32fV2(src *AudioFrameV2, dst *AudioFrameV2) {
+	audioToInterleaved32f(src, dst)
+}
+
+func (r *loopReceiver) pushMetadata(metadata loopMetadata, count bool) {
+	if count {
+		r.total.MetadataFrames++
+	}
+	if len(r.metadata) >= loopbackQueueDepth {
+		r.metadata = r.metadata[1:]
+		r.dropped.MetadataFrames++
+	}
+	r.metadata = append(r.metadata, metadata)
+}
+
+func (s *loopSender) pushMetadata(metadata loopMetadata) {
+	if len(s.metadata) >= loopbackQueueDepth {
+		s.metadata = s.metadata[1:]
+	}
+	s.metadata = append(s.metadata, metadata)
+}
+
+// Take the queued video and audio of the receiver, keeping the latest video frame and queueing the audio. The queued
+// audio is dropped when the number of channels changes.
+func (fs *loopFrameSync) drain(r *loopReceiver) {
+	if r == nil {
+		return
+	}
+	if len(r.video) > 0 {
+		latest := r.video[len(r.video)-1]
+		fs.video = &latest
+		r.video = nil
+	}
+	for _, a := range r.audio {
+		if a.numChannels != fs.numChannels {
+			fs.audio = make([][]float32, a.numChannels)
+		}
+		fs.sampleRate, fs.numChannels, fs.timecode = a.sampleRate, a.numChannels, a.timecode
+		for ch := range fs.audio {
+			fs.audio[ch] = append(fs.audio[ch], a.data[ch*int(a.numSamples):(ch+1)*int(a.numSamples)]...)
+		}
+	}
+	r.audio = nil
+}
+
+// Look for <ndi_capabilities ntk_ptz="true"/> in the connection metadata
+func (s *loopSender) ptzSupported() bool {
+	for _, data := range s.connectionMetadata {
+		decoder := xml.NewDecoder(strings.NewReader(data))
+		for {
+			token, err := decoder.Token()
+			if err != nil {
+				break
+			}
+			if start, ok := token.(xml.StartElement); ok && start.Name.Local == "ndi_capabilities" {
+				for _, attr := range start.Attr {
+					if attr.Name.Local == "ntk_ptz" && attr.Value == "true" {
+						return true
+					}
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (v *loopVideo) fill(frame *VideoFrameV2) {
+	*frame = v.frame
+	frame.Data = nil
+	if len(v.data) > 0 {
+		frame.Data = &v.data[0]
+	}
+	frame.Metadata = nil
+	if v.metadata != "" {
+		frame.Metadata = cString(v.metadata)
+	}
+}
+
+func (a *loopAudio) fillV2(frame *AudioFrameV2) {
+	*frame = AudioFrameV2{
+		SampleRate:    a.sampleRate,
+		NumChannels:   a.numChannels,
+		NumSamples:    a.numSamples,
+		Timecode:      a.timecode,
+		ChannelStride: a.numSamples * 4,
+		Timestamp:     a.timestamp,
+	}
+	if len(a.data) > 0 {
+		frame.Data = &a.data[0]
+	}
+	if a.metadata != "" {
+		frame.Metadata = cString(a.metadata)
+	}
+}
+
+func (a *loopAudio) fillV3(frame *AudioFrameV3) {
+	*frame = AudioFrameV3{
+		SampleRate:    a.sampleRate,
+		NumChannels:   a.numChannels,
+		NumSamples:    a.numSamples,
+		Timecode:      a.timecode,
+		FourCC:        FourCCAudioTypeFLTP,
+		ChannelStride: a.numSamples * 4,
+		Timestamp:     a.timestamp,
+	}
+	if len(a.data) > 0 {
+		frame.Data = (*byte)(unsafe.Pointer(&a.data[0]))
+	}
+	if a.metadata != "" {
+		frame.Metadata = cString(a.metadata)
+	}
+}
+
+func (m *loopMetadata) fill(frame *MetadataFrame) {
+	frame.Length = int32(len(m.data) + 1)
+	frame.Timecode = m.timecode
+	frame.Data = cString(m.data)
+}
+
+// Returns how long to wait before a frame of the given duration is due, and schedules the next one.
+func (c *loopClock) advance(duration time.Duration) time.Duration {
+	now := time.Now()
+	if c.next.Before(now) {
+		c.next = now
+	}
+	wait := c.next.Sub(now)
+	c.next = c.next.Add(duration)
+
+	return wait
+}
+
+func sourceKey(source *Source) string {
+	if source == nil {
+		return ""
+	}
+	if name := source.Name(); name != "" {
+		return name
+	}
+
+	return source.Address()
+}
+
+func loopbackAddress(id uintptr) string {
+	return fmt.Sprintf("127.0.0.1:%d", 5960+id)
+}
+
+// Current time in 100ns intervals, as used by the SDK for timecodes and timestamps
+func loopbackTimestamp() int64 {
+	return time.Now().UnixNano() / 100
+}
+
+func synthesizeTimecode(timecode int64) int64 {
+	if timecode == SendTimecodeSynthesize {
+		return loopbackTimestamp()
+	}
+
+	return timecode
+}
+
+// Groups are a comma separated list, where an empty list means the "public" group.
+func parseGroups(groups string) []string {
+	parsed := []string{}
+	for _, group := range strings.Split(groups, ",") {
+		if group = strings.ToLower(strings.TrimSpace(group)); group != "" {
+			parsed = append(parsed, group)
+		}
+	}
+	if len(parsed) == 0 {
+		parsed = append(parsed, "public")
+	}
+
+	return parsed
+}
+
+func groupsIntersect(a []string, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+
+	return false
+}
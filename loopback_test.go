@@ -0,0 +1,336 @@
+package gondi
+
+import (
+	"errors"
+	"testing"
+	"unsafe"
+)
+
+// Create a sender and a receiver connected to it through a finder, like an application would
+func newLoopbackPair(t *testing.T, name string) (*SendInstance, *RecvInstance) {
+	t.Helper()
+	useTestBackend(t, NewLoopbackBackend())
+
+	sender, err := NewSendInstance(name, "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sender.Destroy() })
+
+	finder, err := NewFindInstance(true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer finder.Destroy()
+
+	if !finder.WaitForSources(1000) {
+		t.Fatal("WaitForSources did not report any change")
+	}
+	sources := finder.GetCurrentSources()
+	if len(sources) != 1 {
+		t.Fatalf("found %d sources, want 1", len(sources))
+	}
+	if ExtractSourceName(sources[0].Name()) != name {
+		t.Fatalf("found source %q, want %q", sources[0].Name(), name)
+	}
+
+	receiver, err := NewRecvInstance(&NewRecvInstanceSettings{
+		SourceToConnectTo: sources[0],
+		Bandwidth:         RecvBandwidthHighest,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(receiver.Destroy)
+
+	// The first capture reports the connection
+	if ft := receiver.CaptureV2(nil, nil, nil, 1000); ft != FrameTypeStatusChange {
+		t.Fatalf("first capture returned %d, want a status change", ft)
+	}
+
+	return sender, receiver
+}
+
+func TestLoopbackVideo(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "video")
+
+	pixels := make([]byte, 4*2*4)
+	for i := range pixels {
+		pixels[i] = byte(i)
+	}
+	frame := NewVideoFrameV2()
+	frame.FourCC = FourCCTypeBGRA
+	frame.Xres = 4
+	frame.Yres = 2
+	frame.LineStride = 4 * 4
+	frame.Data = &pixels[0]
+	sender.SendVideoFrame(frame)
+
+	// The sender must not share memory with the receiver
+	pixels[0] = 255
+
+	received := NewVideoFrameV2()
+	if ft := receiver.CaptureV2(received, nil, nil, 1000); ft != FrameTypeVideo {
+		t.Fatalf("capture returned %d, want video", ft)
+	}
+	defer receiver.FreeVideoV2(received)
+
+	if received.Xres != 4 || received.Yres != 2 || received.FourCC != FourCCTypeBGRA {
+		t.Errorf("received a %dx%d %s frame", received.Xres, received.Yres, received.FourCC[:])
+	}
+	data := unsafe.Slice(received.Data, received.LineStride*received.Yres)
+	if data[0] != 0 || data[31] != 31 {
+		t.Errorf("received data %v", data)
+	}
+
+	total, dropped := receiver.GetPerformance()
+	if total.VideoFrames != 1 || dropped.VideoFrames != 0 {
+		t.Errorf("performance reports %d total and %d dropped frames", total.VideoFrames, dropped.VideoFrames)
+	}
+}
+
+func TestLoopbackAudio(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "audio")
+
+	frame := NewAudioFrameV2Preallocated(2, 4)
+	frame.SampleRate = 48000
+	frame.NumChannels = 2
+	frame.NumSamples = 4
+	frame.SetFromInterleavedArray([]float32{0, 1, 0.1, 1.1, 0.2, 1.2, 0.3, 1.3})
+	sender.SendAudioFrame(frame)
+
+	received := NewAudioFrameV2()
+	if ft := receiver.CaptureV2(nil, received, nil, 1000); ft != FrameTypeAudio {
+		t.Fatalf("capture returned %d, want audio", ft)
+	}
+	defer receiver.FreeAudioV2(received)
+
+	want := []float32{0, 0.1, 0.2, 0.3, 1, 1.1, 1.2, 1.3}
+	got := received.GetArray()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("received %v, want %v", got, want)
+		}
+	}
+	if interleaved := received.GetInterleavedArray(); interleaved[1] != 1 || interleaved[2] != 0.1 {
+		t.Errorf("interleaved array is %v", interleaved)
+	}
+}
+
+func TestLoopbackMetadataAndTally(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "metadata")
+
+	if n, _ := sender.GetNumberOfConnections(0); n != 1 {
+		t.Errorf("sender has %d connections, want 1", n)
+	}
+
+	sender.SendMetadataFrame(NewMetadataFrame(`<downstream/>`))
+	mf := &MetadataFrame{}
+	if ft := receiver.CaptureV2(nil, nil, mf, 1000); ft != FrameTypeMetadata || mf.GetData() != `<downstream/>` {
+		t.Errorf("receiver captured %d %q", ft, mf.GetData())
+	}
+	receiver.FreeMetadata(mf)
+
+	if !receiver.SendMetadata(NewMetadataFrame(`<upstream/>`)) {
+		t.Error("SendMetadata reported no connection")
+	}
+	mf = &MetadataFrame{}
+	if ft := sender.Capture(mf, 1000); ft != FrameTypeMetadata || mf.GetData() != `<upstream/>` {
+		t.Errorf("sender captured %d %q", ft, mf.GetData())
+	}
+
+	receiver.SetTally(true, false)
+	tally, changed := sender.GetTally(1000)
+	if !changed || !tally.Program || tally.Preview {
+		t.Errorf("sender tally is %+v, changed %v", tally, changed)
+	}
+
+	receiver.Destroy()
+	if n, _ := sender.GetNumberOfConnections(0); n != 0 {
+		t.Errorf("sender has %d connections after the receiver was destroyed, want 0", n)
+	}
+}
+
+func TestLoopbackRouting(t *testing.T) {
+	useTestBackend(t, NewLoopbackBackend())
+
+	sender, _ := NewSendInstance("camera", "", false, false)
+	defer sender.Destroy()
+	route, _ := NewRoutingInstance("program", "")
+	defer route.Destroy()
+
+	program := &Source{}
+	program.Set("LOOPBACK (program)", "")
+	receiver, _ := NewRecvInstance(&NewRecvInstanceSettings{SourceToConnectTo: program})
+	defer receiver.Destroy()
+
+	if n, _ := sender.GetNumberOfConnections(0); n != 0 {
+		t.Errorf("sender has %d connections before routing, want 0", n)
+	}
+
+	camera := &Source{}
+	camera.Set("LOOPBACK (camera)", "")
+	route.Change(camera)
+	if n, _ := sender.GetNumberOfConnections(0); n != 1 {
+		t.Errorf("sender has %d connections after routing, want 1", n)
+	}
+
+	route.Clear()
+	if n, _ := sender.GetNumberOfConnections(0); n != 0 {
+		t.Errorf("sender has %d connections after clearing the route, want 0", n)
+	}
+}
+
+func TestLoopbackAudioV3(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "audio v3")
+
+	// Two channels of three samples, padded to a stride of four samples
+	samples := []float32{0, 0.1, 0.2, -1, 1, 1.1, 1.2, -1}
+	frame := NewAudioFrameV3()
+	frame.SampleRate = 48000
+	frame.NumChannels = 2
+	frame.NumSamples = 3
+	frame.ChannelStride = 4 * 4
+	frame.Data = (*byte)(unsafe.Pointer(&samples[0]))
+	if err := sender.SendAudioFrameV3(frame); err != nil {
+		t.Fatal(err)
+	}
+
+	received := NewAudioFrameV3()
+	ft, err := receiver.CaptureV3(nil, received, nil, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ft != FrameTypeAudio {
+		t.Fatalf("capture returned %d, want audio", ft)
+	}
+	defer receiver.FreeAudioV3(received)
+
+	if received.FourCC != FourCCAudioTypeFLTP {
+		t.Errorf("received FourCC %q, want FLTp", received.FourCC[:])
+	}
+	if got := received.Channel(1); len(got) != 3 || got[0] != 1 || got[2] != 1.2 {
+		t.Errorf("channel 1 is %v", got)
+	}
+	want := []float32{0, 1, 0.1, 1.1, 0.2, 1.2}
+	got := received.GetInterleavedArray()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("interleaved array is %v, want %v", got, want)
+		}
+	}
+}
+
+func TestLoopbackPTZ(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "camera")
+
+	ptz := receiver.PTZ()
+	if ptz.Supported() {
+		t.Fatal("PTZ supported before the source announced it")
+	}
+	if err := ptz.Zoom(0.5); !errors.Is(err, ErrPTZRejected) {
+		t.Fatalf("Zoom returned %v, want ErrPTZRejected", err)
+	}
+
+	sender.AddConnectionMetadata(NewMetadataFrame(`<ndi_capabilities ntk_ptz="true"/>`))
+	for {
+		ft := receiver.CaptureV2(nil, nil, nil, 1000)
+		if ft == FrameTypeStatusChange {
+			break
+		}
+		if ft == FrameTypeNone || ft == FrameTypeError {
+			t.Fatalf("capture returned %d, want a status change", ft)
+		}
+	}
+	if !ptz.Supported() {
+		t.Fatal("PTZ not supported after the source announced it")
+	}
+
+	if err := ptz.PanTilt(-0.25, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := ptz.RecallPreset(3, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`<ntk_ptz_pan_tilt pan="-0.25" tilt="1"/>`,
+		`<ntk_ptz_recall_preset index="3" speed="0.5"/>`,
+	} {
+		metadata := &MetadataFrame{}
+		if ft := sender.Capture(metadata, 1000); ft != FrameTypeMetadata {
+			t.Fatalf("sender capture returned %d, want metadata", ft)
+		}
+		if got := metadata.GetData(); got != want {
+			t.Errorf("sender received %q, want %q", got, want)
+		}
+	}
+}
+
+func TestLoopbackFrameSync(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "framesync")
+
+	fs, err := NewFrameSync(receiver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fs.Destroy()
+
+	video := NewVideoFrameV2()
+	fs.CaptureVideo(video, FrameFormatProgressive)
+	if video.Data != nil || video.Xres != 0 {
+		t.Errorf("captured a %dx%d frame before anything was sent", video.Xres, video.Yres)
+	}
+
+	pixels := make([]byte, 4*2*2)
+	for _, width := range []int32{2, 1} {
+		frame := NewVideoFrameV2()
+		frame.FourCC = FourCCTypeBGRA
+		frame.Xres = width
+		frame.Yres = 2
+		frame.LineStride = width * 4
+		frame.Data = &pixels[0]
+		sender.SendVideoFrame(frame)
+	}
+
+	// The latest frame is returned, as often as asked for
+	for i := 0; i < 2; i++ {
+		fs.CaptureVideo(video, FrameFormatProgressive)
+		if video.Xres != 1 || video.Data == nil {
+			t.Errorf("captured a %dx%d frame, want the latest 1x2 frame", video.Xres, video.Yres)
+		}
+		fs.FreeVideo(video)
+	}
+
+	audio := NewAudioFrameV2Preallocated(2, 4)
+	audio.SampleRate = 48000
+	audio.NumChannels = 2
+	audio.NumSamples = 4
+	audio.SetArray([]float32{1, 2, 3, 4, -1, -2, -3, -4})
+	sender.SendAudioFrame(audio)
+	sender.SendAudioFrame(audio)
+
+	if depth := fs.AudioQueueDepth(); depth != 8 {
+		t.Errorf("audio queue depth is %d, want 8", depth)
+	}
+
+	captured := NewAudioFrameV2()
+	fs.CaptureAudio(captured, 0, 0, 6)
+	if captured.SampleRate != 48000 || captured.NumChannels != 2 || captured.NumSamples != 6 {
+		t.Fatalf("captured %d samples of %d channels at %d Hz", captured.NumSamples, captured.NumChannels, captured.SampleRate)
+	}
+	if got := captured.GetArray(); got[4] != 1 || got[11] != -2 {
+		t.Errorf("captured %v", got)
+	}
+	fs.FreeAudio(captured)
+
+	// Only two samples are left, the rest is silence
+	fs.CaptureAudio(captured, 48000, 2, 4)
+	want := []float32{3, 4, 0, 0, -3, -4, 0, 0}
+	got := captured.GetArray()
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("captured %v, want %v", got, want)
+		}
+	}
+	fs.FreeAudio(captured)
+}
@@ -0,0 +1,113 @@
+package metadata
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Kind of KVM event, the first byte of the payload
+type KVMEventType byte
+
+const (
+	// The mouse moved, to X and Y
+	KVMMouseMove       KVMEventType = 0x03
+	KVMMouseLeftDown   KVMEventType = 0x04
+	KVMMouseMiddleDown KVMEventType = 0x05
+	KVMMouseRightDown  KVMEventType = 0x06
+	KVMMouseLeftUp     KVMEventType = 0x07
+	KVMMouseMiddleUp   KVMEventType = 0x08
+	KVMMouseRightUp    KVMEventType = 0x09
+	// A key was pressed, Key is the X11 keysym
+	KVMKeyDown KVMEventType = 0x0b
+	// A key was released, Key is the X11 keysym
+	KVMKeyUp KVMEventType = 0x0c
+)
+
+// KVM is a keyboard or mouse event sent upstream by a receiver controlling a source that announced ntk_kvm in its
+// Capabilities. The event is a binary payload, base64 encoded in the u attribute of the ndi_kvm element.
+//
+// The SDK documents the ntk_kvm capability but not this payload, so the event types and their layout could not be
+// confirmed against it. Events of other types keep their payload in Data, so they go through unchanged.
+type KVM struct {
+	Type KVMEventType
+
+	// Mouse position for KVMMouseMove, from 0.0 to 1.0 of the width and height of the video
+	X, Y float32
+
+	// X11 keysym for KVMKeyDown and KVMKeyUp
+	Key uint32
+
+	// Payload after the type byte for the other events, kept as is
+	Data []byte
+}
+
+func (*KVM) Element() string {
+	return "ndi_kvm"
+}
+
+func (k *KVM) payload() []byte {
+	payload := []byte{byte(k.Type)}
+	switch k.Type {
+	case KVMMouseMove:
+		payload = binary.LittleEndian.AppendUint32(payload, math.Float32bits(k.X))
+		payload = binary.LittleEndian.AppendUint32(payload, math.Float32bits(k.Y))
+	case KVMKeyDown, KVMKeyUp:
+		payload = binary.LittleEndian.AppendUint32(payload, k.Key)
+	default:
+		payload = append(payload, k.Data...)
+	}
+
+	return payload
+}
+
+func (k *KVM) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{
+		Name: xml.Name{Local: "ndi_kvm"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "u"}, Value: base64.StdEncoding.EncodeToString(k.payload())}},
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func (k *KVM) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	encoded := ""
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "u" {
+			encoded = attr.Value
+		}
+	}
+	payload, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid KVM payload: %w", err)
+	}
+	if len(payload) == 0 {
+		return errors.New("empty KVM payload")
+	}
+
+	*k = KVM{Type: KVMEventType(payload[0])}
+	data := payload[1:]
+	switch k.Type {
+	case KVMMouseMove:
+		if len(data) < 8 {
+			return errors.New("short KVM mouse move")
+		}
+		k.X = math.Float32frombits(binary.LittleEndian.Uint32(data))
+		k.Y = math.Float32frombits(binary.LittleEndian.Uint32(data[4:]))
+	case KVMKeyDown, KVMKeyUp:
+		if len(data) < 4 {
+			return errors.New("short KVM key event")
+		}
+		k.Key = binary.LittleEndian.Uint32(data)
+	default:
+		k.Data = data
+	}
+
+	return d.Skip()
+}
@@ -0,0 +1,86 @@
+package metadata
+
+import (
+	"encoding/xml"
+
+	"github.com/benitogf/gondi"
+)
+
+// Product describes the device or application behind a source, usually sent as connection metadata
+type Product struct {
+	XMLName      xml.Name `xml:"ndi_product"`
+	LongName     string   `xml:"long_name,attr,omitempty"`
+	ShortName    string   `xml:"short_name,attr,omitempty"`
+	Manufacturer string   `xml:"manufacturer,attr,omitempty"`
+	Version      string   `xml:"version,attr,omitempty"`
+	Session      string   `xml:"session,attr,omitempty"`
+	ModelName    string   `xml:"model_name,attr,omitempty"`
+	Serial       string   `xml:"serial,attr,omitempty"`
+}
+
+func (*Product) Element() string {
+	return "ndi_product"
+}
+
+// Capabilities tells receivers what a source can be controlled with, sent as connection metadata. Receivers learn
+// that a source is a PTZ camera from NTKPTZ.
+type Capabilities struct {
+	XMLName         xml.Name `xml:"ndi_capabilities"`
+	WebControl      string   `xml:"web_control,attr,omitempty"`
+	NTKPTZ          bool     `xml:"ntk_ptz,attr,omitempty"`
+	NTKPanTilt      bool     `xml:"ntk_pan_tilt,attr,omitempty"`
+	NTKZoom         bool     `xml:"ntk_zoom,attr,omitempty"`
+	NTKIris         bool     `xml:"ntk_iris,attr,omitempty"`
+	NTKWhiteBalance bool     `xml:"ntk_white_balance,attr,omitempty"`
+	NTKExposure     bool     `xml:"ntk_exposure,attr,omitempty"`
+	NTKRecord       bool     `xml:"ntk_record,attr,omitempty"`
+	NTKKVM          bool     `xml:"ntk_kvm,attr,omitempty"`
+}
+
+func (*Capabilities) Element() string {
+	return "ndi_capabilities"
+}
+
+// TallyEcho is sent by a source to its receivers with the tally it gets from all of them combined
+type TallyEcho struct {
+	XMLName   xml.Name `xml:"ndi_tally_echo"`
+	OnProgram bool     `xml:"on_program,attr"`
+	OnPreview bool     `xml:"on_preview,attr"`
+}
+
+func (*TallyEcho) Element() string {
+	return "ndi_tally_echo"
+}
+
+// Format announces the video format of a source, so receivers can prepare before the first frame arrives
+type Format struct {
+	XMLName     xml.Name `xml:"ndi_format"`
+	Xres        int32    `xml:"xres,attr"`
+	Yres        int32    `xml:"yres,attr"`
+	FrameRateN  int32    `xml:"frame_rate_n,attr"`
+	FrameRateD  int32    `xml:"frame_rate_d,attr"`
+	AspectRatio float32  `xml:"aspect_ratio,attr,omitempty"`
+	Progressive bool     `xml:"progressive,attr"`
+	FourCC      string   `xml:"fourcc,attr,omitempty"`
+}
+
+func (*Format) Element() string {
+	return "ndi_format"
+}
+
+// Get the format of a video frame
+func FormatOf(vf *gondi.VideoFrameV2) *Format {
+	format := &Format{
+		Xres:        vf.Xres,
+		Yres:        vf.Yres,
+		FrameRateN:  vf.FrameRateN,
+		FrameRateD:  vf.FrameRateD,
+		AspectRatio: vf.PictureAspectRatio,
+		Progressive: vf.FrameFormatType == gondi.FrameFormatProgressive,
+	}
+	if vf.FourCC != (gondi.FourCCType{}) {
+		format.FourCC = string(vf.FourCC[:])
+	}
+
+	return format
+}
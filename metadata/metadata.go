@@ -0,0 +1,147 @@
+// Package metadata encodes and decodes the XML messages NDI senders and receivers exchange as metadata frames.
+//
+// The standard messages have their own types: Product, Capabilities, TallyEcho, PTZ, Format and KVM. Applications add
+// their own elements to a Registry, every element without a registered type is decoded as Raw.
+package metadata
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Message is one metadata element
+type Message interface {
+	// Name of the root XML element of the message
+	Element() string
+}
+
+// Raw is an element no type is registered for, kept as is
+type Raw struct {
+	Name string
+	XML  string
+}
+
+func (r *Raw) Element() string {
+	return r.Name
+}
+
+// Registry maps element names to the type their messages are decoded into
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]func() Message
+}
+
+// Create a registry knowing only the standard NDI messages
+func NewRegistry() *Registry {
+	r := &Registry{factories: map[string]func() Message{}}
+	r.Register("ndi_product", func() Message { return &Product{} })
+	r.Register("ndi_capabilities", func() Message { return &Capabilities{} })
+	r.Register("ndi_tally_echo", func() Message { return &TallyEcho{} })
+	r.Register("ndi_format", func() Message { return &Format{} })
+	r.Register("ndi_kvm", func() Message { return &KVM{} })
+	for _, element := range ptzElements {
+		r.Register(element, func() Message { return &PTZ{} })
+	}
+
+	return r
+}
+
+// The registry used by the package level functions
+var DefaultRegistry = NewRegistry()
+
+// Decode the element with the given name into the message returned by factory, which must be a pointer to a type
+// encoding/xml can unmarshal into. Registering a name again replaces the previous type.
+func (r *Registry) Register(element string, factory func() Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.factories[element] = factory
+}
+
+// Register an element in the default registry
+func Register(element string, factory func() Message) {
+	DefaultRegistry.Register(element, factory)
+}
+
+func (r *Registry) factory(element string) func() Message {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.factories[element]
+}
+
+// Encode a message as XML
+func Encode(m Message) (string, error) {
+	if raw, ok := m.(*Raw); ok {
+		return raw.XML, nil
+	}
+
+	data, err := xml.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode %s: %w", m.Element(), err)
+	}
+
+	return string(data), nil
+}
+
+// Decode the first element of a metadata frame
+func (r *Registry) Decode(data string) (Message, error) {
+	messages, err := r.DecodeAll(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, errors.New("unable to decode metadata: no element")
+	}
+
+	return messages[0], nil
+}
+
+// Decode every top level element of a metadata frame, as connection metadata often holds more than one
+func (r *Registry) DecodeAll(data string) ([]Message, error) {
+	decoder := xml.NewDecoder(bytes.NewReader([]byte(data)))
+	messages := []Message{}
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, fmt.Errorf("unable to decode metadata: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		factory := r.factory(start.Name.Local)
+		if factory == nil {
+			if err := decoder.Skip(); err != nil {
+				return messages, fmt.Errorf("unable to decode metadata: %w", err)
+			}
+			messages = append(messages, &Raw{start.Name.Local, data[offset:decoder.InputOffset()]})
+			continue
+		}
+
+		message := factory()
+		if err := decoder.DecodeElement(message, &start); err != nil {
+			return messages, fmt.Errorf("unable to decode %s: %w", start.Name.Local, err)
+		}
+		messages = append(messages, message)
+	}
+}
+
+// Decode the first element of a metadata frame with the default registry
+func Decode(data string) (Message, error) {
+	return DefaultRegistry.Decode(data)
+}
+
+// Decode every element of a metadata frame with the default registry
+func DecodeAll(data string) ([]Message, error) {
+	return DefaultRegistry.DecodeAll(data)
+}
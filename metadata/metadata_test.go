@@ -0,0 +1,282 @@
+package metadata
+
+import (
+	"context"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/benitogf/gondi"
+)
+
+type cue struct {
+	XMLName xml.Name `xml:"my_cue"`
+	ID      int      `xml:"id,attr"`
+	Text    string   `xml:",chardata"`
+}
+
+func (*cue) Element() string {
+	return "my_cue"
+}
+
+func TestRoundTrip(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("my_cue", func() Message { return &cue{} })
+
+	messages := []Message{
+		&Product{LongName: "Go camera", ShortName: "cam", Manufacturer: "gondi", Version: "1.0"},
+		&Capabilities{WebControl: "http://%IP%/", NTKPTZ: true, NTKKVM: true},
+		&TallyEcho{OnProgram: true},
+		&Format{Xres: 1920, Yres: 1080, FrameRateN: 30000, FrameRateD: 1001, Progressive: true, FourCC: "UYVY"},
+		&KVM{Type: KVMMouseMove, X: 0.25, Y: 0.75},
+		&KVM{Type: KVMKeyDown, Key: 0xff0d},
+		&KVM{Type: KVMMouseLeftDown, Data: []byte{}},
+		&PTZ{gondi.PTZCommand{Op: gondi.PTZOpPanTilt, Values: [3]float32{0.5, -0.25}}},
+		&PTZ{gondi.PTZCommand{Op: gondi.PTZOpRecallPreset, Values: [3]float32{1}, Preset: 3}},
+		&PTZ{gondi.PTZCommand{Op: gondi.PTZOpFocus, Values: [3]float32{0.5}}},
+		&PTZ{gondi.PTZCommand{Op: gondi.PTZOpWhiteBalanceOneShot}},
+		&PTZ{gondi.PTZCommand{Op: gondi.PTZOpExposureManualV2, Values: [3]float32{0.1, 0.2, 0.3}}},
+		&cue{ID: 7, Text: "go"},
+	}
+
+	frame := ""
+	for _, m := range messages {
+		data, err := Encode(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := registry.Decode(data)
+		if err != nil {
+			t.Fatalf("%s: %v", data, err)
+		}
+		if decoded.Element() != m.Element() {
+			t.Errorf("%s decoded as %s", data, decoded.Element())
+		}
+		// Normalize the XMLName set by the decoder
+		if encoded, _ := Encode(decoded); encoded != data {
+			t.Errorf("%s encoded again as %s", data, encoded)
+		}
+		frame += data
+	}
+
+	decoded, err := registry.DecodeAll(frame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != len(messages) {
+		t.Fatalf("decoded %d messages, want %d", len(decoded), len(messages))
+	}
+}
+
+func TestKVM(t *testing.T) {
+	// A key up of 'a' is the type byte then the keysym in little endian
+	data, err := Encode(&KVM{Type: KVMKeyUp, Key: 'a'})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<ndi_kvm u="DGEAAAA="></ndi_kvm>`; data != want {
+		t.Errorf("encoded %s, want %s", data, want)
+	}
+
+	decoded, err := Decode(`<ndi_kvm u="AwAAgD4AAEA/"/>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kvm, ok := decoded.(*KVM); !ok || kvm.Type != KVMMouseMove || kvm.X != 0.25 || kvm.Y != 0.75 {
+		t.Errorf("decoded %#v, want a mouse move to 0.25, 0.75", decoded)
+	}
+	for _, invalid := range []string{`<ndi_kvm u=""/>`, `<ndi_kvm u="Aw=="/>`, `<ndi_kvm u="!"/>`} {
+		if _, err := Decode(invalid); err == nil {
+			t.Errorf("%s decoded", invalid)
+		}
+	}
+}
+
+func TestDecodeSDK(t *testing.T) {
+	// What the SDK sends for the PTZ functions decodes to the same command
+	for op := gondi.PTZOpZoom; op <= gondi.PTZOpExposureManualV2; op++ {
+		command := gondi.PTZCommand{Op: op, Values: [3]float32{0.5, 0.25, 0.125}, Preset: 2}
+		message, err := Decode(command.XML())
+		if err != nil {
+			t.Fatalf("%s: %v", command.XML(), err)
+		}
+		ptz, ok := message.(*PTZ)
+		if !ok {
+			t.Fatalf("%s decoded as %T", command.XML(), message)
+		}
+		if ptz.XML() != command.XML() {
+			t.Errorf("%s decoded as %s", command.XML(), ptz.XML())
+		}
+	}
+
+	messages, err := DecodeAll(` <ndi_product long_name="x"/> <unknown a="1"><b/></unknown>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Message{&Product{XMLName: xml.Name{Local: "ndi_product"}, LongName: "x"}, &Raw{"unknown", `<unknown a="1"><b/></unknown>`}}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("decoded %#v, want %#v", messages, want)
+	}
+
+	if _, err := Decode(`<ntk_ptz_zoom zoom="near"/>`); err == nil {
+		t.Error("invalid zoom decoded")
+	}
+	if _, err := Decode(""); err == nil {
+		t.Error("empty metadata decoded")
+	}
+}
+
+// Use the loopback backend for the rest of the test, subpackage tests start without a backend so it is reset after
+func useLoopback(t *testing.T) {
+	gondi.UseBackend(gondi.NewLoopbackBackend())
+	t.Cleanup(func() { gondi.UseBackend(nil) })
+}
+
+func TestTransport(t *testing.T) {
+	useLoopback(t)
+
+	sender, err := gondi.NewSendInstance("camera", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Destroy()
+
+	if err := AddConnectionMetadata(sender, &Capabilities{NTKPTZ: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	finder, err := gondi.NewFindInstance(true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer finder.Destroy()
+	finder.WaitForSources(1000)
+	sources := finder.GetCurrentSources()
+	if len(sources) != 1 {
+		t.Fatalf("found %d sources, want 1", len(sources))
+	}
+	recv, err := gondi.NewRecvInstance(&gondi.NewRecvInstanceSettings{SourceToConnectTo: sources[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recv.Destroy()
+
+	// The connection metadata comes first, possibly after a status change
+	var messages []Message
+	for i := 0; i < 5 && len(messages) == 0; i++ {
+		if messages, err = Capture(recv, 1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(messages) != 1 || !messages[0].(*Capabilities).NTKPTZ {
+		t.Fatalf("received %#v, want the capabilities", messages)
+	}
+	if !recv.PTZ().Supported() {
+		t.Fatal("PTZ not supported after the capabilities")
+	}
+
+	if err := Send(sender, &TallyEcho{OnPreview: true}); err != nil {
+		t.Fatal(err)
+	}
+	messages, err = Capture(recv, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || !messages[0].(*TallyEcho).OnPreview {
+		t.Fatalf("received %#v, want the tally echo", messages)
+	}
+
+	// A PTZ call of the receiver reaches the sender as a PTZ message
+	if err := recv.PTZ().Zoom(0.5); err != nil {
+		t.Fatal(err)
+	}
+	if err := SendUpstream(recv, &KVM{Type: KVMKeyUp, Key: 'a'}); err != nil {
+		t.Fatal(err)
+	}
+	want := []Message{
+		&PTZ{gondi.PTZCommand{Op: gondi.PTZOpZoom, Values: [3]float32{0.5}}},
+		&KVM{Type: KVMKeyUp, Key: 'a'},
+	}
+	for _, m := range want {
+		messages, err := CaptureUpstream(sender, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 1 || !reflect.DeepEqual(messages[0], m) {
+			t.Fatalf("captured %#v, want %#v", messages, m)
+		}
+	}
+}
+
+func TestHandlePTZ(t *testing.T) {
+	useLoopback(t)
+
+	sender, err := gondi.NewSendInstance("ptz", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Destroy()
+	if err := AddConnectionMetadata(sender, &Capabilities{NTKPTZ: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := make(chan gondi.PTZCommand, 1)
+	server := gondi.NewCommandServer(sender)
+	HandlePTZ(server, func(command gondi.PTZCommand) error {
+		commands <- command
+		return nil
+	})
+	// Commands are decoded with the registry they are handled on
+	registry := NewRegistry()
+	registry.Register("my_cue", func() Message { return &cue{} })
+	registry.Handle(server, "my_cue", func(m Message) (Message, error) {
+		return &Product{ShortName: m.(*cue).Text}, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- server.Serve(ctx) }()
+	defer func() {
+		cancel()
+		<-served
+	}()
+
+	finder, err := gondi.NewFindInstance(true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer finder.Destroy()
+	finder.WaitForSources(1000)
+	recv, err := gondi.NewRecvInstance(&gondi.NewRecvInstanceSettings{SourceToConnectTo: finder.GetCurrentSources()[0]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer recv.Destroy()
+
+	if err := recv.PTZ().RecallPreset(4, 0.5); err != nil {
+		t.Fatal(err)
+	}
+	want := gondi.PTZCommand{Op: gondi.PTZOpRecallPreset, Values: [3]float32{0.5}, Preset: 4}
+	if command := <-commands; command != want {
+		t.Errorf("handled %+v, want %+v", command, want)
+	}
+
+	if err := SendUpstream(recv, &cue{ID: 1, Text: "echoed"}); err != nil {
+		t.Fatal(err)
+	}
+	// The capabilities and status changes come before the reply
+	for i := 0; i < 5; i++ {
+		messages, err := Capture(recv, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) == 1 {
+			if product, ok := messages[0].(*Product); ok {
+				if product.ShortName != "echoed" {
+					t.Errorf("got reply %+v", product)
+				}
+				return
+			}
+		}
+	}
+	t.Fatal("no reply")
+}
@@ -0,0 +1,130 @@
+package metadata
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/benitogf/gondi"
+)
+
+// The elements of the PTZ commands, as sent by the NDIlib_recv_ptz_* functions
+var ptzElements = []string{
+	"ntk_ptz_zoom",
+	"ntk_ptz_zoom_speed",
+	"ntk_ptz_pan_tilt",
+	"ntk_ptz_pan_tilt_speed",
+	"ntk_ptz_store_preset",
+	"ntk_ptz_recall_preset",
+	"ntk_ptz_focus",
+	"ntk_ptz_focus_speed",
+	"ntk_ptz_white_balance",
+	"ntk_ptz_exposure",
+	"ntk_ptz_exposure_v2",
+}
+
+// PTZ is a command sent by a receiver to a PTZ camera, see gondi.PTZ. Cameras implemented with a SendInstance get
+// them from SendInstance.Capture, and apply the Op with its Values and Preset.
+type PTZ struct {
+	gondi.PTZCommand
+}
+
+func (p *PTZ) Element() string {
+	start, err := p.start()
+	if err != nil {
+		return ""
+	}
+
+	return start.Name.Local
+}
+
+// The element of the command as the SDK writes it, from gondi.PTZCommand.XML
+func (p *PTZ) start() (xml.StartElement, error) {
+	token, err := xml.NewDecoder(strings.NewReader(p.XML())).Token()
+	if err != nil {
+		return xml.StartElement{}, fmt.Errorf("unable to encode PTZ operation %d", p.Op)
+	}
+
+	return token.(xml.StartElement), nil
+}
+
+func (p *PTZ) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start, err := p.start()
+	if err != nil {
+		return err
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func (p *PTZ) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	attrs := map[string]string{}
+	for _, attr := range start.Attr {
+		attrs[attr.Name.Local] = attr.Value
+	}
+	var err error
+	value := func(name string) float32 {
+		v, parseErr := strconv.ParseFloat(attrs[name], 32)
+		if parseErr != nil && err == nil {
+			err = fmt.Errorf("invalid %s %q", name, attrs[name])
+		}
+		return float32(v)
+	}
+	preset := func() int32 {
+		v, parseErr := strconv.ParseInt(attrs["index"], 10, 32)
+		if parseErr != nil && err == nil {
+			err = fmt.Errorf("invalid index %q", attrs["index"])
+		}
+		return int32(v)
+	}
+
+	c := gondi.PTZCommand{}
+	switch element, mode := start.Name.Local, attrs["mode"]; {
+	case element == "ntk_ptz_zoom":
+		c = gondi.PTZCommand{Op: gondi.PTZOpZoom, Values: [3]float32{value("zoom")}}
+	case element == "ntk_ptz_zoom_speed":
+		c = gondi.PTZCommand{Op: gondi.PTZOpZoomSpeed, Values: [3]float32{value("zoom_speed")}}
+	case element == "ntk_ptz_pan_tilt":
+		c = gondi.PTZCommand{Op: gondi.PTZOpPanTilt, Values: [3]float32{value("pan"), value("tilt")}}
+	case element == "ntk_ptz_pan_tilt_speed":
+		c = gondi.PTZCommand{Op: gondi.PTZOpPanTiltSpeed, Values: [3]float32{value("pan_speed"), value("tilt_speed")}}
+	case element == "ntk_ptz_store_preset":
+		c = gondi.PTZCommand{Op: gondi.PTZOpStorePreset, Preset: preset()}
+	case element == "ntk_ptz_recall_preset":
+		c = gondi.PTZCommand{Op: gondi.PTZOpRecallPreset, Values: [3]float32{value("speed")}, Preset: preset()}
+	case element == "ntk_ptz_focus" && mode == "auto":
+		c = gondi.PTZCommand{Op: gondi.PTZOpAutoFocus}
+	case element == "ntk_ptz_focus":
+		c = gondi.PTZCommand{Op: gondi.PTZOpFocus, Values: [3]float32{value("distance")}}
+	case element == "ntk_ptz_focus_speed":
+		c = gondi.PTZCommand{Op: gondi.PTZOpFocusSpeed, Values: [3]float32{value("distance")}}
+	case element == "ntk_ptz_white_balance" && mode == "auto":
+		c = gondi.PTZCommand{Op: gondi.PTZOpWhiteBalanceAuto}
+	case element == "ntk_ptz_white_balance" && mode == "indoor":
+		c = gondi.PTZCommand{Op: gondi.PTZOpWhiteBalanceIndoor}
+	case element == "ntk_ptz_white_balance" && mode == "outdoor":
+		c = gondi.PTZCommand{Op: gondi.PTZOpWhiteBalanceOutdoor}
+	case element == "ntk_ptz_white_balance" && mode == "one_shot":
+		c = gondi.PTZCommand{Op: gondi.PTZOpWhiteBalanceOneShot}
+	case element == "ntk_ptz_white_balance" && mode == "manual":
+		c = gondi.PTZCommand{Op: gondi.PTZOpWhiteBalanceManual, Values: [3]float32{value("red"), value("blue")}}
+	case element == "ntk_ptz_exposure" && mode == "auto":
+		c = gondi.PTZCommand{Op: gondi.PTZOpExposureAuto}
+	case element == "ntk_ptz_exposure" && mode == "manual":
+		c = gondi.PTZCommand{Op: gondi.PTZOpExposureManual, Values: [3]float32{value("value")}}
+	case element == "ntk_ptz_exposure_v2":
+		c = gondi.PTZCommand{Op: gondi.PTZOpExposureManualV2, Values: [3]float32{value("iris"), value("gain"), value("shutter_speed")}}
+	default:
+		return fmt.Errorf("unknown PTZ command %s mode %q", element, mode)
+	}
+	if err != nil {
+		return err
+	}
+	p.PTZCommand = c
+
+	return d.Skip()
+}
@@ -0,0 +1,43 @@
+package metadata
+
+import (
+	"encoding/xml"
+
+	"github.com/benitogf/gondi"
+)
+
+// Handle an element on a command server with decoded messages, with the default registry. A reply that is not nil
+// is encoded and sent to the receivers.
+func Handle(server *gondi.CommandServer, element string, handler func(Message) (Message, error)) {
+	DefaultRegistry.Handle(server, element, handler)
+}
+
+// Handle an element on a command server with messages decoded by the registry, see Handle
+func (r *Registry) Handle(server *gondi.CommandServer, element string, handler func(Message) (Message, error)) {
+	server.Handle(element, func(command gondi.Command) (string, error) {
+		message, err := r.Decode(command.Data)
+		if err != nil {
+			return "", err
+		}
+		reply, err := handler(message)
+		if reply == nil || err != nil {
+			return "", err
+		}
+
+		return Encode(reply)
+	})
+}
+
+// Handle every PTZ command on a command server, to emulate a PTZ camera. Receivers only send PTZ commands to sources
+// announcing them, so add Capabilities with NTKPTZ to the connection metadata of the sender as well.
+func HandlePTZ(server *gondi.CommandServer, handler func(gondi.PTZCommand) error) {
+	for _, element := range ptzElements {
+		server.Handle(element, func(command gondi.Command) (string, error) {
+			ptz := &PTZ{}
+			if err := xml.Unmarshal([]byte(command.Data), ptz); err != nil {
+				return "", err
+			}
+			return "", handler(ptz.PTZCommand)
+		})
+	}
+}
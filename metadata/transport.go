@@ -0,0 +1,98 @@
+package metadata
+
+import (
+	"errors"
+
+	"github.com/benitogf/gondi"
+)
+
+// The receiver is not connected to a source, so metadata can't be sent upstream
+var ErrNotConnected = errors.New("unable to send metadata, the receiver is not connected")
+
+func newFrame(m Message) (*gondi.MetadataFrame, error) {
+	data, err := Encode(m)
+	if err != nil {
+		return nil, err
+	}
+
+	return gondi.NewMetadataFrame(data), nil
+}
+
+// Send a message to every receiver connected to the sender
+func Send(sender *gondi.SendInstance, m Message) error {
+	frame, err := newFrame(m)
+	if err != nil {
+		return err
+	}
+	sender.SendMetadataFrame(frame)
+
+	return nil
+}
+
+// Send a message to every receiver connecting to the sender from now on, and to those already connected
+func AddConnectionMetadata(sender *gondi.SendInstance, m Message) error {
+	frame, err := newFrame(m)
+	if err != nil {
+		return err
+	}
+	sender.AddConnectionMetadata(frame)
+
+	return nil
+}
+
+// Send a message upstream, to the source the receiver is connected to
+func SendUpstream(recv *gondi.RecvInstance, m Message) error {
+	frame, err := newFrame(m)
+	if err != nil {
+		return err
+	}
+	if !recv.SendMetadata(frame) {
+		return ErrNotConnected
+	}
+
+	return nil
+}
+
+// Wait up to timeoutMs for a metadata frame sent upstream to the sender, and decode it. No messages and no error are
+// returned when nothing arrived in time.
+func (r *Registry) CaptureUpstream(sender *gondi.SendInstance, timeoutMs uint32) ([]Message, error) {
+	frame := &gondi.MetadataFrame{}
+	switch sender.Capture(frame, timeoutMs) {
+	case gondi.FrameTypeMetadata:
+	case gondi.FrameTypeError:
+		return nil, errors.New("unable to capture metadata from the sender")
+	default:
+		return nil, nil
+	}
+	data := frame.GetData()
+	sender.FreeMetadata(frame)
+
+	return r.DecodeAll(data)
+}
+
+// Wait up to timeoutMs for a metadata frame from the source the receiver is connected to, and decode it. No messages
+// and no error are returned when nothing, or a status change, arrived in time.
+func (r *Registry) Capture(recv *gondi.RecvInstance, timeoutMs uint32) ([]Message, error) {
+	frame := &gondi.MetadataFrame{}
+	switch recv.CaptureV2(nil, nil, frame, timeoutMs) {
+	case gondi.FrameTypeMetadata:
+	case gondi.FrameTypeError:
+		return nil, errors.New("unable to capture metadata, the receiver lost its connection")
+	default:
+		return nil, nil
+	}
+	data := frame.GetData()
+	recv.FreeMetadata(frame)
+
+	return r.DecodeAll(data)
+}
+
+// Capture metadata sent upstream to the sender, decoded with the default registry
+func CaptureUpstream(sender *gondi.SendInstance, timeoutMs uint32) ([]Message, error) {
+	return DefaultRegistry.CaptureUpstream(sender, timeoutMs)
+}
+
+// Capture metadata from the source of the receiver, decoded with the default registry
+func Capture(recv *gondi.RecvInstance, timeoutMs uint32) ([]Message, error) {
+	return DefaultRegistry.Capture(recv, timeoutMs)
+}
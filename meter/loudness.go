@@ -0,0 +1,234 @@
+package meter
+
+import "math"
+
+// Second order IIR filter in transposed direct form II
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// The K-weighting filters of ITU-R BS.1770 at any sample rate: a high shelf modelling the head, then a high pass.
+// The analog prototypes are the ones libebur128 derives from the 48kHz coefficients of the recommendation.
+func kWeighting(sampleRate int) [2]biquad {
+	k := math.Tan(math.Pi * 1681.974450955533 / float64(sampleRate))
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * 38.13547087602444 / float64(sampleRate))
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return [2]biquad{shelf, highPass}
+}
+
+// Taps of the interpolation filter of each of the 3 samples added between two samples when oversampling 4 times
+const truePeakTaps = 12
+
+// Coefficients of the interpolation filter, a Blackman windowed sinc, for the samples 1/4, 2/4 and 3/4 of the way
+// between the two samples in the middle of the taps
+var truePeakPhases = func() [3][truePeakTaps]float64 {
+	var phases [3][truePeakTaps]float64
+	for p := range phases {
+		var sum float64
+		for k := range phases[p] {
+			d := float64(k) - truePeakTaps/2 + float64(p+1)/4
+			h := 1.0
+			if d != 0 {
+				h = math.Sin(math.Pi*d) / (math.Pi * d)
+			}
+			u := d / (truePeakTaps / 2)
+			h *= 0.42 + 0.5*math.Cos(math.Pi*u) + 0.08*math.Cos(2*math.Pi*u)
+			phases[p][k] = h
+			sum += h
+		}
+		for k := range phases[p] {
+			phases[p][k] /= sum
+		}
+	}
+	return phases
+}()
+
+// Oversampler finds the peaks between samples, as ITU-R BS.1770 measures true-peak
+type oversampler struct {
+	history [truePeakTaps]float64
+	next    int
+}
+
+// Add a sample, returning the highest absolute value of the samples it lets interpolate, delayed by half the taps
+func (o *oversampler) peak(sample float64) float64 {
+	o.history[o.next] = sample
+	peak := math.Abs(sample)
+	for p := range truePeakPhases {
+		var y float64
+		for k, c := range truePeakPhases[p] {
+			y += c * o.history[(o.next-k+truePeakTaps)%truePeakTaps]
+		}
+		peak = max(peak, math.Abs(y))
+	}
+	o.next = (o.next + 1) % truePeakTaps
+
+	return peak
+}
+
+// Loudness is measured in blocks of 100ms, momentary over the last 4 and short-term over the last 30
+const (
+	momentaryBlocks = 4
+	shortTermBlocks = 30
+)
+
+// EBU R128 loudness state of a stream
+type loudness struct {
+	blockSize int
+	power     float64
+	samples   int
+
+	// Mean powers of the last blocks, a ring
+	recent [shortTermBlocks]float64
+	next   int
+	filled int
+
+	// Every momentary loudness for the integrated loudness, and every short-term one for the loudness range
+	blocks     histogram
+	shortTerms histogram
+}
+
+func newLoudness(sampleRate int) *loudness {
+	return &loudness{blockSize: max(sampleRate/10, 1)}
+}
+
+// Add the weighted power of a sample summed over the channels
+func (l *loudness) add(power float64) {
+	l.power += power
+	l.samples++
+	if l.samples < l.blockSize {
+		return
+	}
+
+	l.recent[l.next] = l.power / float64(l.samples)
+	l.next = (l.next + 1) % shortTermBlocks
+	l.filled = min(l.filled+1, shortTermBlocks)
+	l.power, l.samples = 0, 0
+
+	if l.filled >= momentaryBlocks {
+		l.blocks.add(l.momentary())
+	}
+	if l.filled >= shortTermBlocks {
+		l.shortTerms.add(l.shortTerm())
+	}
+}
+
+// Mean power of the last blocks, 0 when there are not that many yet
+func (l *loudness) mean(blocks int) float64 {
+	if l.filled < blocks {
+		return 0
+	}
+	var sum float64
+	for i := 1; i <= blocks; i++ {
+		sum += l.recent[(l.next-i+shortTermBlocks)%shortTermBlocks]
+	}
+	return sum / float64(blocks)
+}
+
+func (l *loudness) momentary() float64 {
+	return l.mean(momentaryBlocks)
+}
+
+func (l *loudness) shortTerm() float64 {
+	return l.mean(shortTermBlocks)
+}
+
+// Loudness range per EBU Tech 3342: the spread between the 10th and the 95th percentile of the short-term loudness,
+// gated 20 LU below its mean
+func (l *loudness) loudnessRange() float64 {
+	threshold := lufs(l.shortTerms.gated(0)) - 20
+	var bins []int
+	total := 0
+	for i, count := range l.shortTerms {
+		if count > 0 && binLoudness(i) >= threshold {
+			bins = append(bins, i)
+			total += count
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	percentile := func(p float64) float64 {
+		target := int(math.Ceil(p * float64(total)))
+		seen := 0
+		for _, i := range bins {
+			seen += l.shortTerms[i]
+			if seen >= target {
+				return binLoudness(i)
+			}
+		}
+		return binLoudness(bins[len(bins)-1])
+	}
+
+	return percentile(0.95) - percentile(0.10)
+}
+
+// Count of loudness values between -70 and +30 LUFS in bins of 0.1 LU, so measuring for days takes no more memory.
+// Values under -70 LUFS are left out, as the absolute gate of EBU R128 does.
+type histogram [1000]int
+
+func binLoudness(i int) float64 {
+	return -70 + (float64(i)+0.5)/10
+}
+
+func (h *histogram) add(power float64) {
+	loudness := lufs(power)
+	if loudness < -70 {
+		return
+	}
+	h[min(int((loudness+70)*10), len(h)-1)]++
+}
+
+// Mean power of the values, gated relative LU below the mean of all of them. 0 without values.
+func (h *histogram) gated(relative float64) float64 {
+	mean := func(threshold float64) float64 {
+		var sum float64
+		count := 0
+		for i, n := range h {
+			if n > 0 && binLoudness(i) >= threshold {
+				sum += float64(n) * math.Pow(10, (binLoudness(i)+0.691)/10)
+				count += n
+			}
+		}
+		if count == 0 {
+			return 0
+		}
+		return sum / float64(count)
+	}
+
+	all := mean(-70)
+	if all == 0 || relative == 0 {
+		return all
+	}
+
+	return mean(lufs(all) + relative)
+}
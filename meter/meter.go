@@ -0,0 +1,210 @@
+// Package meter measures the audio levels of NDI streams: per channel peak, RMS and true-peak, and the EBU R128
+// momentary, short-term and integrated loudness with its loudness range.
+//
+// Levels can be encoded as JSON, or sent as metadata: they implement metadata.Message as the ndi_audio_levels element.
+package meter
+
+import (
+	"encoding/xml"
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/benitogf/gondi"
+)
+
+// Levels below this, and silence, are reported as Floor so they can be encoded as JSON
+const Floor = -144.0
+
+// Levels of one channel over the audio added since the previous call to Levels, in dBFS. A sine wave at full scale
+// has a peak of 0 and a RMS of -3.01.
+type Channel struct {
+	Peak     float64 `json:"peak" xml:"peak,attr"`
+	RMS      float64 `json:"rms" xml:"rms,attr"`
+	TruePeak float64 `json:"truePeak" xml:"true_peak,attr"`
+}
+
+// Levels of a stream, see Meter.Levels
+type Levels struct {
+	XMLName  xml.Name  `json:"-" xml:"ndi_audio_levels"`
+	Channels []Channel `json:"channels" xml:"channel"`
+
+	// EBU R128 loudness in LUFS over the last 400ms, the last 3s, and since the meter started. Floor until there is
+	// enough audio.
+	Momentary  float64 `json:"momentary" xml:"momentary,attr"`
+	ShortTerm  float64 `json:"shortTerm" xml:"short_term,attr"`
+	Integrated float64 `json:"integrated" xml:"integrated,attr"`
+
+	// EBU R128 loudness range in LU since the meter started
+	Range float64 `json:"range" xml:"range,attr"`
+
+	// Highest true-peak of every channel since the meter started, in dBTP
+	MaxTruePeak float64 `json:"maxTruePeak" xml:"max_true_peak,attr"`
+}
+
+func (*Levels) Element() string {
+	return "ndi_audio_levels"
+}
+
+// Meter measures the levels of the audio added to it. Frames are added from the capture goroutine and the levels read
+// from any other.
+type Meter struct {
+	mu sync.Mutex
+
+	sampleRate int
+	channels   []*channelState
+	loudness   *loudness
+	maxTrue    float64
+}
+
+// State of one channel
+type channelState struct {
+	peak, truePeak, squares float64
+	samples                 int
+	oversampler             oversampler
+	kWeighting              [2]biquad
+	weight                  float64
+}
+
+// Create a meter, it starts measuring with the first frame
+func New() *Meter {
+	return &Meter{}
+}
+
+// Forget everything measured, like when the program changes
+func (m *Meter) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sampleRate, m.channels, m.loudness, m.maxTrue = 0, nil, nil, 0
+}
+
+// Measure a buffer. The meter resets when the sample rate or the number of channels changes.
+func (m *Meter) Add(buf *gondi.AudioBuffer) error {
+	if err := buf.Validate(); err != nil {
+		return err
+	}
+	if buf.SampleRate <= 0 {
+		return errors.New("unable to measure audio without a sample rate")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if buf.SampleRate != m.sampleRate || buf.NumChannels != len(m.channels) {
+		m.start(buf.SampleRate, buf.NumChannels)
+	}
+
+	for ch, state := range m.channels {
+		for i := 0; i < buf.NumSamples; i++ {
+			state.add(float64(buf.Sample(ch, i)))
+		}
+		m.maxTrue = max(m.maxTrue, state.truePeak)
+	}
+
+	// Loudness is measured over every channel at once, in blocks of 100ms
+	for i := 0; i < buf.NumSamples; i++ {
+		var power float64
+		for ch, state := range m.channels {
+			filtered := state.kWeighting[1].process(state.kWeighting[0].process(float64(buf.Sample(ch, i))))
+			power += state.weight * filtered * filtered
+		}
+		m.loudness.add(power)
+	}
+
+	return nil
+}
+
+// Measure a received frame, honoring its channel stride
+func (m *Meter) AddFrame(af *gondi.AudioFrameV2) error {
+	return m.Add(gondi.AudioBufferFromV2(af))
+}
+
+// Measure a received planar float32 frame, honoring its channel stride
+func (m *Meter) AddFrameV3(af *gondi.AudioFrameV3) error {
+	buf, err := gondi.AudioBufferFromV3(af)
+	if err != nil {
+		return err
+	}
+
+	return m.Add(buf)
+}
+
+func (m *Meter) start(sampleRate int, channels int) {
+	m.sampleRate = sampleRate
+	m.channels = make([]*channelState, channels)
+	for ch := range m.channels {
+		m.channels[ch] = &channelState{
+			kWeighting: kWeighting(sampleRate),
+			weight:     channelWeight(ch, channels),
+		}
+	}
+	m.loudness = newLoudness(sampleRate)
+	m.maxTrue = 0
+}
+
+// Get the levels. Peak, RMS and true-peak are measured over the audio added since the previous call, the loudness
+// since the meter started.
+func (m *Meter) Levels() *Levels {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	levels := &Levels{
+		Channels:    make([]Channel, len(m.channels)),
+		Momentary:   Floor,
+		ShortTerm:   Floor,
+		Integrated:  Floor,
+		MaxTruePeak: decibels(m.maxTrue),
+	}
+	for ch, state := range m.channels {
+		levels.Channels[ch] = Channel{Peak: decibels(state.peak), RMS: Floor, TruePeak: decibels(state.truePeak)}
+		if state.samples > 0 {
+			levels.Channels[ch].RMS = decibels(math.Sqrt(state.squares / float64(state.samples)))
+		}
+		state.peak, state.truePeak, state.squares, state.samples = 0, 0, 0, 0
+	}
+	if m.loudness != nil {
+		levels.Momentary = lufs(m.loudness.momentary())
+		levels.ShortTerm = lufs(m.loudness.shortTerm())
+		levels.Integrated = lufs(m.loudness.blocks.gated(-10))
+		levels.Range = m.loudness.loudnessRange()
+	}
+
+	return levels
+}
+
+func (s *channelState) add(sample float64) {
+	s.peak = max(s.peak, math.Abs(sample))
+	s.squares += sample * sample
+	s.samples++
+	s.truePeak = max(s.truePeak, s.oversampler.peak(sample))
+}
+
+// Weight of a channel in the loudness, the surround channels of 5.1 and 7.1 count more and the LFE not at all
+func channelWeight(channel int, channels int) float64 {
+	if channels != 6 && channels != 8 {
+		return 1
+	}
+	switch {
+	case channel == 3:
+		return 0
+	case channel >= 4:
+		return 1.41
+	}
+	return 1
+}
+
+func decibels(amplitude float64) float64 {
+	if amplitude <= 0 {
+		return Floor
+	}
+	return max(20*math.Log10(amplitude), Floor)
+}
+
+// Loudness in LUFS of a mean weighted power
+func lufs(power float64) float64 {
+	if power <= 0 {
+		return Floor
+	}
+	return max(-0.691+10*math.Log10(power), Floor)
+}
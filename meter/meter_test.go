@@ -0,0 +1,119 @@
+package meter
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/metadata"
+)
+
+// Add seconds of a stereo sine at the given level in dBFS, in frames of 10ms
+func addTone(t *testing.T, m *Meter, frequency float64, level float64, phase float64, seconds float64) {
+	t.Helper()
+	amplitude := math.Pow(10, level/20)
+	frames := int(seconds * 100)
+	for f := 0; f < frames; f++ {
+		buf := gondi.NewAudioBuffer(gondi.AudioFormatFloat32Planar, 48000, 2, 480)
+		for i := 0; i < 480; i++ {
+			v := float32(amplitude * math.Sin(2*math.Pi*frequency*float64(f*480+i)/48000+phase))
+			buf.SetSample(0, i, v)
+			buf.SetSample(1, i, v)
+		}
+		if err := m.Add(buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func near(got float64, want float64, tolerance float64) bool {
+	return math.Abs(got-want) <= tolerance
+}
+
+func TestLevels(t *testing.T) {
+	m := New()
+	addTone(t, m, 1000, -20, 0, 5)
+	levels := m.Levels()
+
+	// A 1kHz sine on both channels is as loud in LUFS as its level in dBFS
+	for name, value := range map[string]float64{"momentary": levels.Momentary, "short-term": levels.ShortTerm, "integrated": levels.Integrated} {
+		if !near(value, -20, 0.1) {
+			t.Errorf("%s loudness is %f, want -20", name, value)
+		}
+	}
+	if !near(levels.Range, 0, 0.2) {
+		t.Errorf("loudness range is %f, want 0", levels.Range)
+	}
+	if len(levels.Channels) != 2 {
+		t.Fatalf("%d channels measured", len(levels.Channels))
+	}
+	c := levels.Channels[1]
+	if !near(c.Peak, -20, 0.01) || !near(c.RMS, -23.01, 0.01) || c.TruePeak < c.Peak || !near(c.TruePeak, -20, 0.1) {
+		t.Errorf("channel levels are %+v", c)
+	}
+
+	// Peak and RMS start over on each call
+	if levels := m.Levels(); levels.Channels[0].Peak != Floor || levels.Channels[0].RMS != Floor {
+		t.Errorf("levels without new audio are %+v", levels.Channels[0])
+	}
+}
+
+func TestTruePeak(t *testing.T) {
+	// A sine at a quarter of the sample rate sampled 45 degrees off its peaks
+	m := New()
+	addTone(t, m, 12000, 0, math.Pi/4, 0.1)
+	c := m.Levels().Channels[0]
+	if !near(c.Peak, -3.01, 0.01) || !near(c.TruePeak, 0, 0.3) {
+		t.Errorf("peak is %f and true-peak %f, want -3.01 and 0", c.Peak, c.TruePeak)
+	}
+}
+
+func TestLoudnessRange(t *testing.T) {
+	// EBU Tech 3342 case 1: 20s at -20 dBFS then 20s at -30 dBFS has a range of 10 LU
+	m := New()
+	addTone(t, m, 1000, -20, 0, 20)
+	addTone(t, m, 1000, -30, 0, 20)
+	levels := m.Levels()
+	if !near(levels.Range, 10, 1) {
+		t.Errorf("loudness range is %f, want 10", levels.Range)
+	}
+
+	// The quieter half is above the relative gate, so it counts in the integrated loudness
+	if !near(levels.Integrated, -22.6, 0.2) {
+		t.Errorf("integrated loudness is %f, want -22.6", levels.Integrated)
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	m := New()
+	if err := m.AddFrame(&gondi.AudioFrameV2{SampleRate: 48000, NumChannels: 1, NumSamples: 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Silence is at the floor, which JSON can encode
+	data, err := json.Marshal(m.Levels())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"momentary":-144`) {
+		t.Errorf("JSON is %s", data)
+	}
+
+	// Levels sent as metadata decode back
+	addTone(t, m, 1000, -20, 0, 0.5)
+	encoded, err := metadata.Encode(m.Levels())
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := metadata.NewRegistry()
+	registry.Register("ndi_audio_levels", func() metadata.Message { return &Levels{} })
+	decoded, err := registry.Decode(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if levels, ok := decoded.(*Levels); !ok || !near(levels.Momentary, -20, 0.1) || len(levels.Channels) != 2 || !near(levels.Channels[0].Peak, -20, 0.01) {
+		t.Errorf("metadata %s decoded to %+v", encoded, decoded)
+	}
+}
@@ -8,3 +8,11 @@ import "github.com/ebitengine/purego"
 func openLibrary(name string) (uintptr, error) {
 	return purego.Dlopen(name, purego.RTLD_NOW|purego.RTLD_GLOBAL)
 }
+
+func lookupSymbol(library uintptr, name string) (uintptr, error) {
+	return purego.Dlsym(library, name)
+}
+
+func closeLibrary(library uintptr) error {
+	return purego.Dlclose(library)
+}
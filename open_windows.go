@@ -11,3 +11,11 @@ func openLibrary(name string) (uintptr, error) {
 	handle, err := syscall.LoadLibrary(name)
 	return uintptr(handle), err
 }
+
+func lookupSymbol(library uintptr, name string) (uintptr, error) {
+	return syscall.GetProcAddress(syscall.Handle(library), name)
+}
+
+func closeLibrary(library uintptr) error {
+	return syscall.FreeLibrary(syscall.Handle(library))
+}
@@ -1,79 +1,252 @@
 package gondi
 
 import (
+	"context"
 	"errors"
 	"image"
 	"image/color"
+	"sort"
+	"sync"
+	"time"
 )
 
 const EMPTY_X = 1920
 const EMPTY_Y = 1080
 
-type Preview struct {
+// The stream has no preview
+var ErrPreviewNotFound = errors.New("preview not found")
+
+// A preview image of a stream, as stored in a PreviewStore. It is never modified once stored, so it can be read
+// while the stream is updated.
+type PreviewFrame struct {
 	StreamName string `json:"streamName"`
 	IMG        *image.RGBA
 	Width      int
 	Height     int
+
+	// Increases with every update of the stream, starting at 1
+	Seq uint64
+
+	// When the frame was stored
+	Updated time.Time
+}
+
+type previewStream struct {
+	frame       *PreviewFrame
+	subscribers map[chan *PreviewFrame]struct{}
+
+	// Closed when the stream is removed, to stop the goroutines of its subscribers
+	done chan struct{}
+}
+
+func newPreviewStream() *previewStream {
+	return &previewStream{
+		frame:       &PreviewFrame{},
+		subscribers: map[chan *PreviewFrame]struct{}{},
+		done:        make(chan struct{}),
+	}
+}
+
+// PreviewStore keeps the last preview frame of each stream. It is safe to update streams from capture goroutines
+// while HTTP handlers read them: frames are copied when stored and replaced, never modified.
+type PreviewStore struct {
+	mu      sync.RWMutex
+	streams map[string]*previewStream
+}
+
+// Create an empty preview store
+func NewPreviewStore() *PreviewStore {
+	return &PreviewStore{streams: map[string]*previewStream{}}
+}
+
+// The store used by GetPreview, SetPreviewFrame and ClearPreview
+var DefaultPreviewStore = NewPreviewStore()
+
+// Store a RGBA or RGBX frame as the preview of the stream. The frame is copied, so the caller can reuse it.
+func (s *PreviewStore) Set(streamName string, frame []byte, width, height int) {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	copy(img.Pix, frame)
+	s.publish(streamName, img)
+}
+
+// Replace the preview of the stream with a transparent frame, creating the stream if needed
+func (s *PreviewStore) Clear(streamName string) {
+	s.publish(streamName, emptyPreview())
+}
+
+func (s *PreviewStore) publish(streamName string, img *image.RGBA) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, ok := s.streams[streamName]
+	if !ok {
+		stream = newPreviewStream()
+		s.streams[streamName] = stream
+	}
+	stream.frame = &PreviewFrame{
+		StreamName: streamName,
+		IMG:        img,
+		Width:      img.Rect.Dx(),
+		Height:     img.Rect.Dy(),
+		Seq:        stream.frame.Seq + 1,
+		Updated:    time.Now(),
+	}
+
+	for subscriber := range stream.subscribers {
+		notify(subscriber, stream.frame)
+	}
+}
+
+// Give the latest frame to a subscriber, replacing the frame it didn't read yet
+func notify(subscriber chan *PreviewFrame, frame *PreviewFrame) {
+	select {
+	case <-subscriber:
+	default:
+	}
+	subscriber <- frame
+}
+
+// Get the current frame of the stream
+func (s *PreviewStore) Get(streamName string) (*PreviewFrame, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stream, ok := s.streams[streamName]
+	if !ok || stream.frame.IMG == nil {
+		return nil, false
+	}
+
+	return stream.frame, true
+}
+
+// Remove the stream, closing the channels of its subscribers
+func (s *PreviewStore) Remove(streamName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream, ok := s.streams[streamName]
+	if !ok {
+		return
+	}
+	for subscriber := range stream.subscribers {
+		delete(stream.subscribers, subscriber)
+		close(subscriber)
+	}
+	close(stream.done)
+	delete(s.streams, streamName)
+}
+
+// Get the names of the streams having a frame, sorted
+func (s *PreviewStore) Streams() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.streams))
+	for name, stream := range s.streams {
+		if stream.frame.IMG != nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return names
 }
 
-var Previews []Preview
+// Get the frames of the stream as they are updated, starting with the current one if any. A slow reader skips
+// frames, it always gets the latest one. The channel is closed when the context is done or the stream is removed.
+//
+// Subscribing to a stream that does not exist yet creates it without a frame, Get then reports it as missing until
+// the first update.
+func (s *PreviewStore) Subscribe(ctx context.Context, streamName string) <-chan *PreviewFrame {
+	subscriber := make(chan *PreviewFrame, 1)
+
+	s.mu.Lock()
+	stream, ok := s.streams[streamName]
+	if !ok {
+		stream = newPreviewStream()
+		s.streams[streamName] = stream
+	}
+	if stream.frame.IMG != nil {
+		subscriber <- stream.frame
+	}
+	stream.subscribers[subscriber] = struct{}{}
+	s.mu.Unlock()
 
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stream.done:
+			// Remove closed the channel already
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if _, ok := stream.subscribers[subscriber]; ok {
+			delete(stream.subscribers, subscriber)
+			close(subscriber)
+		}
+	}()
+
+	return subscriber
+}
+
+// Get the preview image of the stream from the default store, or a transparent image and ErrPreviewNotFound
 func GetPreview(streamName string) (*image.RGBA, error) {
-	for _, preview := range Previews {
-		if preview.StreamName == streamName {
-			return preview.IMG, nil
+	frame, ok := DefaultPreviewStore.Get(streamName)
+	if !ok {
+		return GenerateAlpha(), ErrPreviewNotFound
+	}
+
+	return frame.IMG, nil
+}
+
+// A preview of the default store, as returned by Previews
+//
+// Deprecated: use PreviewFrame and DefaultPreviewStore.
+type Preview = PreviewFrame
+
+// Get a snapshot of the previews of the default store, sorted by stream name
+//
+// Deprecated: use DefaultPreviewStore.Streams and DefaultPreviewStore.Get.
+func Previews() []Preview {
+	previews := []Preview{}
+	for _, name := range DefaultPreviewStore.Streams() {
+		if frame, ok := DefaultPreviewStore.Get(name); ok {
+			previews = append(previews, *frame)
 		}
 	}
 
-	return GenerateAlpha(), errors.New("preview not found")
+	return previews
 }
 
+// Get the index of the stream in Previews, or -1 and ErrPreviewNotFound
+//
+// Deprecated: use DefaultPreviewStore.Get.
 func GetPreviewIndex(streamName string) (int, error) {
-	for index, preview := range Previews {
+	for index, preview := range Previews() {
 		if preview.StreamName == streamName {
 			return index, nil
 		}
 	}
 
-	return -1, errors.New("preview not found")
+	return -1, ErrPreviewNotFound
 }
 
+// Clear the preview of the stream in the default store
 func ClearPreview(streamName string) {
-	index, err := GetPreviewIndex(streamName)
-	if err != nil {
-		Previews = append(Previews, Preview{
-			StreamName: streamName,
-			IMG:        GenerateAlpha(),
-			Width:      EMPTY_X,
-			Height:     EMPTY_Y,
-		})
-		return
-	}
-
-	Previews[index].IMG = GenerateAlpha()
+	DefaultPreviewStore.Clear(streamName)
 }
 
+// Store a RGBA or RGBX frame as the preview of the stream in the default store, the frame is copied
 func SetPreviewFrame(streamName string, frame []byte, width, height int) {
-	index, err := GetPreviewIndex(streamName)
-	if err != nil {
-		img := image.NewRGBA(image.Rect(0, 0, width, height))
-		img.Pix = frame
-		Previews = append(Previews, Preview{
-			StreamName: streamName,
-			IMG:        img,
-			Width:      width,
-			Height:     height,
-		})
-		return
-	}
-
-	if Previews[index].Width != width || Previews[index].Height != height {
-		Previews[index].IMG = image.NewRGBA(image.Rect(0, 0, width, height))
-		Previews[index].Width = width
-		Previews[index].Height = height
-	}
+	DefaultPreviewStore.Set(streamName, frame, width, height)
+}
 
-	Previews[index].IMG.Pix = frame
+// A new transparent frame for a cleared stream, not shared as callers of GetPreview get the image itself
+func emptyPreview() *image.RGBA {
+	return image.NewRGBA(image.Rect(0, 0, EMPTY_X, EMPTY_Y))
 }
 
 func generateStatic(width, height int) []byte {
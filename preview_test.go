@@ -0,0 +1,100 @@
+package gondi
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+func TestPreviewStore(t *testing.T) {
+	store := NewPreviewStore()
+	if _, ok := store.Get("cam"); ok {
+		t.Fatal("found a preview before any update")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := store.Subscribe(ctx, "cam")
+
+	frame := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	store.Set("cam", frame, 2, 1)
+	frame[0] = 9
+
+	preview, ok := store.Get("cam")
+	if !ok || preview.Seq != 1 || preview.Width != 2 || preview.Height != 1 || preview.IMG.Pix[0] != 1 {
+		t.Fatalf("got %+v, want the first frame copied", preview)
+	}
+	if update := <-updates; update != preview {
+		t.Errorf("subscriber got %+v", update)
+	}
+
+	// A slow subscriber only gets the latest frame
+	store.Set("cam", frame, 2, 1)
+	store.Clear("cam")
+	if update := <-updates; update.Seq != 3 || update.Width != EMPTY_X {
+		t.Errorf("subscriber got seq %d, want the cleared frame", update.Seq)
+	}
+	if preview.IMG.Pix[0] != 1 {
+		t.Error("stored frame modified by an update")
+	}
+	if streams := store.Streams(); len(streams) != 1 || streams[0] != "cam" {
+		t.Errorf("got streams %q", streams)
+	}
+
+	cancel()
+	if _, ok := <-updates; ok {
+		t.Error("subscription not closed with its context")
+	}
+
+	removed := store.Subscribe(context.Background(), "cam")
+	<-removed
+	store.Remove("cam")
+	if _, ok := <-removed; ok {
+		t.Error("subscription not closed when the stream was removed")
+	}
+
+	// Cleared streams don't share their image, callers may draw on it
+	store.Clear("a")
+	store.Clear("b")
+	a, _ := store.Get("a")
+	b, _ := store.Get("b")
+	if a.IMG == b.IMG {
+		t.Error("cleared streams share the same image")
+	}
+
+	// Updates and reads from several goroutines, for the race detector
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				store.Set("race", frame, 2, 1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if preview, ok := store.Get("race"); ok {
+					_ = preview.IMG.Pix[0]
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if preview, _ := store.Get("race"); preview.Seq != 400 {
+		t.Errorf("got seq %d after 400 updates", preview.Seq)
+	}
+}
+
+func TestPreviews(t *testing.T) {
+	SetPreviewFrame("previews", []byte{1, 2, 3, 4}, 1, 1)
+	defer DefaultPreviewStore.Remove("previews")
+
+	index, err := GetPreviewIndex("previews")
+	if err != nil || Previews()[index].IMG.Pix[0] != 1 {
+		t.Fatalf("got index %d and %v", index, err)
+	}
+	if index, err := GetPreviewIndex("missing"); index != -1 || err != ErrPreviewNotFound {
+		t.Errorf("got index %d and %v for a missing stream", index, err)
+	}
+}
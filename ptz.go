@@ -0,0 +1,223 @@
+package gondi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// The PTZ command was not accepted, because the receiver is not connected or the source does not support PTZ
+var ErrPTZRejected = errors.New("PTZ command rejected by the source")
+
+// One of the NDIlib_recv_ptz_* functions
+type PTZOp int32
+
+const (
+	PTZOpZoom PTZOp = iota
+	PTZOpZoomSpeed
+	PTZOpPanTilt
+	PTZOpPanTiltSpeed
+	PTZOpStorePreset
+	PTZOpRecallPreset
+	PTZOpAutoFocus
+	PTZOpFocus
+	PTZOpFocusSpeed
+	PTZOpWhiteBalanceAuto
+	PTZOpWhiteBalanceIndoor
+	PTZOpWhiteBalanceOutdoor
+	PTZOpWhiteBalanceOneShot
+	PTZOpWhiteBalanceManual
+	PTZOpExposureAuto
+	PTZOpExposureManual
+	PTZOpExposureManualV2
+)
+
+// PTZCommand is a single call of a NDIlib_recv_ptz_* function, as passed to Backend.RecvPTZ. Values holds the float
+// parameters of the function in the order of the SDK, Preset the preset number for the preset functions.
+type PTZCommand struct {
+	Op     PTZOp
+	Values [3]float32
+	Preset int32
+}
+
+// PTZ controls the pan, tilt and zoom camera a receiver is connected to, get it with RecvInstance.PTZ.
+//
+// Whether the source supports PTZ is only known once it told the receiver, which is signaled by a capture returning
+// FrameTypeStatusChange. Supported is checked again after every status change, so keep capturing on the receiver.
+type PTZ struct {
+	recv *RecvInstance
+
+	mu        sync.Mutex
+	checked   bool
+	supported bool
+}
+
+// Get the PTZ controller of the receiver
+func (p *RecvInstance) PTZ() *PTZ {
+	return p.ptz
+}
+
+// Forget the cached PTZ support, after a status change
+func (p *PTZ) invalidate() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.checked = false
+}
+
+// Is the source the receiver is connected to a PTZ camera
+func (p *PTZ) Supported() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.checked {
+		supported, err := currentBackend().RecvPTZIsSupported(p.recv.ndiInstance)
+		p.supported = supported && err == nil
+		p.checked = true
+	}
+
+	return p.supported
+}
+
+func (p *PTZ) send(command PTZCommand) error {
+	ok, err := currentBackend().RecvPTZ(p.recv.ndiInstance, command)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPTZRejected
+	}
+
+	return nil
+}
+
+// Set the zoom level, from 0.0 (zoomed in) to 1.0 (zoomed out)
+func (p *PTZ) Zoom(zoom float32) error {
+	return p.send(PTZCommand{Op: PTZOpZoom, Values: [3]float32{zoom}})
+}
+
+// Zoom at a speed, from -1.0 (zoom outwards) to 1.0 (zoom inwards), 0.0 stops zooming
+func (p *PTZ) ZoomSpeed(speed float32) error {
+	return p.send(PTZCommand{Op: PTZOpZoomSpeed, Values: [3]float32{speed}})
+}
+
+// Set the pan and tilt, both from -1.0 to 1.0 with 0.0 being centered
+func (p *PTZ) PanTilt(pan float32, tilt float32) error {
+	return p.send(PTZCommand{Op: PTZOpPanTilt, Values: [3]float32{pan, tilt}})
+}
+
+// Pan and tilt at a speed, from -1.0 (left, down) to 1.0 (right, up), 0.0 stops moving
+func (p *PTZ) PanTiltSpeed(panSpeed float32, tiltSpeed float32) error {
+	return p.send(PTZCommand{Op: PTZOpPanTiltSpeed, Values: [3]float32{panSpeed, tiltSpeed}})
+}
+
+// Store the current position as preset, from 0 to 99
+func (p *PTZ) StorePreset(preset int) error {
+	return p.send(PTZCommand{Op: PTZOpStorePreset, Preset: int32(preset)})
+}
+
+// Move to a stored preset, from 0 to 99, at a speed from 0.0 (slowest) to 1.0 (fastest)
+func (p *PTZ) RecallPreset(preset int, speed float32) error {
+	return p.send(PTZCommand{Op: PTZOpRecallPreset, Values: [3]float32{speed}, Preset: int32(preset)})
+}
+
+// Let the camera focus automatically
+func (p *PTZ) AutoFocus() error {
+	return p.send(PTZCommand{Op: PTZOpAutoFocus})
+}
+
+// Set the focus manually, from 0.0 (infinity) to 1.0 (as close as possible)
+func (p *PTZ) Focus(focus float32) error {
+	return p.send(PTZCommand{Op: PTZOpFocus, Values: [3]float32{focus}})
+}
+
+// Change the focus at a speed, from -1.0 (focus outwards) to 1.0 (focus inwards), 0.0 stops changing
+func (p *PTZ) FocusSpeed(speed float32) error {
+	return p.send(PTZCommand{Op: PTZOpFocusSpeed, Values: [3]float32{speed}})
+}
+
+// Let the camera set the white balance automatically
+func (p *PTZ) WhiteBalanceAuto() error {
+	return p.send(PTZCommand{Op: PTZOpWhiteBalanceAuto})
+}
+
+// Use the indoor white balance preset
+func (p *PTZ) WhiteBalanceIndoor() error {
+	return p.send(PTZCommand{Op: PTZOpWhiteBalanceIndoor})
+}
+
+// Use the outdoor white balance preset
+func (p *PTZ) WhiteBalanceOutdoor() error {
+	return p.send(PTZCommand{Op: PTZOpWhiteBalanceOutdoor})
+}
+
+// Set the white balance once from the current picture, and keep it
+func (p *PTZ) WhiteBalanceOneShot() error {
+	return p.send(PTZCommand{Op: PTZOpWhiteBalanceOneShot})
+}
+
+// Set the white balance manually, red and blue from 0.0 to 1.0
+func (p *PTZ) WhiteBalanceManual(red float32, blue float32) error {
+	return p.send(PTZCommand{Op: PTZOpWhiteBalanceManual, Values: [3]float32{red, blue}})
+}
+
+// Let the camera set the exposure automatically
+func (p *PTZ) ExposureAuto() error {
+	return p.send(PTZCommand{Op: PTZOpExposureAuto})
+}
+
+// Set the exposure manually, from 0.0 (dark) to 1.0 (light)
+func (p *PTZ) ExposureManual(level float32) error {
+	return p.send(PTZCommand{Op: PTZOpExposureManual, Values: [3]float32{level}})
+}
+
+// Set the iris, gain and shutter speed manually, each from 0.0 to 1.0
+func (p *PTZ) ExposureManualV2(iris float32, gain float32, shutterSpeed float32) error {
+	return p.send(PTZCommand{Op: PTZOpExposureManualV2, Values: [3]float32{iris, gain, shutterSpeed}})
+}
+
+// The metadata message the SDK sends to the source for a PTZ command
+func (c PTZCommand) XML() string {
+	f := func(v float32) string { return strconv.FormatFloat(float64(v), 'f', -1, 32) }
+	v := c.Values
+
+	switch c.Op {
+	case PTZOpZoom:
+		return fmt.Sprintf(`<ntk_ptz_zoom zoom="%s"/>`, f(v[0]))
+	case PTZOpZoomSpeed:
+		return fmt.Sprintf(`<ntk_ptz_zoom_speed zoom_speed="%s"/>`, f(v[0]))
+	case PTZOpPanTilt:
+		return fmt.Sprintf(`<ntk_ptz_pan_tilt pan="%s" tilt="%s"/>`, f(v[0]), f(v[1]))
+	case PTZOpPanTiltSpeed:
+		return fmt.Sprintf(`<ntk_ptz_pan_tilt_speed pan_speed="%s" tilt_speed="%s"/>`, f(v[0]), f(v[1]))
+	case PTZOpStorePreset:
+		return fmt.Sprintf(`<ntk_ptz_store_preset index="%d"/>`, c.Preset)
+	case PTZOpRecallPreset:
+		return fmt.Sprintf(`<ntk_ptz_recall_preset index="%d" speed="%s"/>`, c.Preset, f(v[0]))
+	case PTZOpAutoFocus:
+		return `<ntk_ptz_focus mode="auto"/>`
+	case PTZOpFocus:
+		return fmt.Sprintf(`<ntk_ptz_focus mode="manual" distance="%s"/>`, f(v[0]))
+	case PTZOpFocusSpeed:
+		return fmt.Sprintf(`<ntk_ptz_focus_speed distance="%s"/>`, f(v[0]))
+	case PTZOpWhiteBalanceAuto:
+		return `<ntk_ptz_white_balance mode="auto"/>`
+	case PTZOpWhiteBalanceIndoor:
+		return `<ntk_ptz_white_balance mode="indoor"/>`
+	case PTZOpWhiteBalanceOutdoor:
+		return `<ntk_ptz_white_balance mode="outdoor"/>`
+	case PTZOpWhiteBalanceOneShot:
+		return `<ntk_ptz_white_balance mode="one_shot"/>`
+	case PTZOpWhiteBalanceManual:
+		return fmt.Sprintf(`<ntk_ptz_white_balance mode="manual" red="%s" blue="%s"/>`, f(v[0]), f(v[1]))
+	case PTZOpExposureAuto:
+		return `<ntk_ptz_exposure mode="auto"/>`
+	case PTZOpExposureManual:
+		return fmt.Sprintf(`<ntk_ptz_exposure mode="manual" value="%s"/>`, f(v[0]))
+	case PTZOpExposureManualV2:
+		return fmt.Sprintf(`<ntk_ptz_exposure_v2 mode="manual" iris="%s" gain="%s" shutter_speed="%s"/>`, f(v[0]), f(v[1]), f(v[2]))
+	}
+
+	return ""
+}
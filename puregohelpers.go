@@ -1,6 +1,9 @@
 package gondi
 
-import "unsafe"
+import (
+	"runtime"
+	"unsafe"
+)
 
 func goString(c uintptr) string {
 	// We take the address and then dereference it to trick go vet from creating a possible misuse of unsafe.Pointer
@@ -30,3 +33,42 @@ func cString(name string) *byte {
 	copy(b, name)
 	return &b[0]
 }
+
+// pinned keeps the Go memory referenced by the structs handed to the NDI library in place until released. The cgo
+// rules, which purego follows, only allow C to hold pointers to pinned Go memory, both for pointers stored inside
+// the structs passed to a call and for memory the SDK keeps using after the call returns.
+type pinned struct {
+	pinner runtime.Pinner
+}
+
+// Pin every non nil pointer
+func (p *pinned) pin(ptrs ...unsafe.Pointer) {
+	for _, ptr := range ptrs {
+		if ptr != nil {
+			p.pinner.Pin(ptr)
+		}
+	}
+}
+
+// Copy the string to a pinned NUL terminated buffer
+func (p *pinned) cString(s string) *byte {
+	b := cString(s)
+	p.pin(unsafe.Pointer(b))
+
+	return b
+}
+
+// Unpin everything, the memory must not be used by the NDI library anymore
+func (p *pinned) release() {
+	p.pinner.Unpin()
+}
+
+// Pin a video frame and the memory it points to
+func (p *pinned) videoFrame(frame *VideoFrameV2) {
+	p.pin(unsafe.Pointer(frame), unsafe.Pointer(frame.Data), unsafe.Pointer(frame.Metadata))
+}
+
+// Pin a metadata frame and the memory it points to
+func (p *pinned) metadataFrame(frame *MetadataFrame) {
+	p.pin(unsafe.Pointer(frame), unsafe.Pointer(frame.Data))
+}
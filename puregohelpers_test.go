@@ -0,0 +1,46 @@
+package gondi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestPinned(t *testing.T) {
+	var p pinned
+	defer p.release()
+
+	name := p.cString("camera")
+	if got := goString(uintptr(unsafe.Pointer(name))); got != "camera" {
+		t.Errorf("cString round trip gave %q", got)
+	}
+
+	// Frames may point to the same memory twice, to memory outside the Go heap, or to nothing
+	data := make([]byte, 16)
+	frame := &VideoFrameV2{Data: &data[0], Metadata: cString("<static/>\x00")}
+	p.videoFrame(frame)
+	p.videoFrame(frame)
+	p.metadataFrame(&MetadataFrame{})
+}
+
+func TestAsyncPinsReleased(t *testing.T) {
+	sender, _ := newLoopbackPair(t, "pins")
+
+	// The alpha frame is sent asynchronously too, its image is pinned until the next frame
+	SendAlphaFrame(sender)
+	if sender.synced == nil {
+		t.Fatal("the sender does not hold the pins of the alpha frame")
+	}
+
+	// The pins of an asynchronous frame belong to its sender until the next frame, or Destroy
+	data := make([]byte, 16*16*4)
+	frame := NewVideoFrameV2()
+	frame.Xres, frame.Yres, frame.LineStride, frame.Data = 16, 16, 16*4, &data[0]
+	sender.SendVideoFrameAsync(frame)
+	if sender.synced == nil {
+		t.Fatal("the sender does not hold the pins of the frame")
+	}
+	sender.Destroy()
+	if sender.synced != nil {
+		t.Error("the pins of the frame are still held after Destroy")
+	}
+}
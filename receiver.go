@@ -0,0 +1,287 @@
+package gondi
+
+import (
+	"context"
+	"sync"
+	"time"
+	"unsafe"
+)
+
+// How long a Receiver waits for a frame before checking if its context is done
+const receiverPollMs = 100
+
+// Options for NewReceiver
+type ReceiverOption func(*receiverConfig)
+
+type receiverConfig struct {
+	copyFrames bool
+	queueSize  int
+}
+
+// Copy video and audio to Go memory and free the NDI buffers right away. Frames then stay valid until garbage
+// collected, and calling Release is optional.
+func WithCopyFrames() ReceiverOption {
+	return func(c *receiverConfig) {
+		c.copyFrames = true
+	}
+}
+
+// Number of frames of each type waiting on the channels before new frames are dropped, 4 by default
+func WithQueueSize(size int) ReceiverOption {
+	return func(c *receiverConfig) {
+		c.queueSize = size
+	}
+}
+
+// A video frame delivered by a Receiver. The data belongs to the NDI library until Release is called, unless the
+// receiver copies frames.
+type VideoFrame struct {
+	VideoFrameV2
+	release func()
+	once    sync.Once
+}
+
+// Give the buffer of the frame back to the NDI library, the frame must not be used afterwards. Calling it more than
+// once does nothing.
+func (f *VideoFrame) Release() {
+	f.once.Do(f.release)
+}
+
+// An audio frame delivered by a Receiver, as planar float32. The data belongs to the NDI library until Release is
+// called, unless the receiver copies frames.
+type AudioFrame struct {
+	AudioFrameV2
+	release func()
+	once    sync.Once
+}
+
+// Give the buffer of the frame back to the NDI library, the frame must not be used afterwards. Calling it more than
+// once does nothing.
+func (f *AudioFrame) Release() {
+	f.once.Do(f.release)
+}
+
+// A metadata frame delivered by a Receiver, it is always copied so there is nothing to release
+type Metadata struct {
+	Data     string
+	Timecode int64
+}
+
+// Delivered by a Receiver when the connection or the capabilities of the source changed
+type StatusChange struct {
+	Time time.Time
+}
+
+// Receiver captures from a RecvInstance in a goroutine and delivers the frames on channels, until its context is done.
+//
+// Each channel holds a few frames, see WithQueueSize. When the consumer of a channel falls behind, new frames of that
+// type are released and dropped, so read every channel or pass the matching bandwidth when creating the receiver.
+//
+// Once stopped the channels are closed, undelivered frames are released, and the RecvInstance is destroyed as soon as
+// every delivered frame has been released. Stop receivers before calling Shutdown.
+type Receiver struct {
+	recv   *RecvInstance
+	config receiverConfig
+
+	video    chan *VideoFrame
+	audio    chan *AudioFrame
+	metadata chan *Metadata
+	status   chan StatusChange
+	done     chan struct{}
+
+	// Frames given out and not released yet, the instance is destroyed when it drops to 0 after stopping
+	mu          sync.Mutex
+	outstanding int
+	stopped     bool
+}
+
+// Create a receiver with the settings and start capturing until the context is done.
+func NewReceiver(ctx context.Context, settings *NewRecvInstanceSettings, opts ...ReceiverOption) (*Receiver, error) {
+	config := receiverConfig{queueSize: 4}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	recv, err := NewRecvInstance(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Receiver{
+		recv:     recv,
+		config:   config,
+		video:    make(chan *VideoFrame, config.queueSize),
+		audio:    make(chan *AudioFrame, config.queueSize),
+		metadata: make(chan *Metadata, config.queueSize),
+		status:   make(chan StatusChange, config.queueSize),
+		done:     make(chan struct{}),
+	}
+	go r.run(ctx)
+
+	return r, nil
+}
+
+// The underlying instance, for tally, PTZ and sending metadata. Don't capture from it or destroy it.
+func (r *Receiver) Instance() *RecvInstance {
+	return r.recv
+}
+
+// Video frames, closed when the receiver stops
+func (r *Receiver) Video() <-chan *VideoFrame {
+	return r.video
+}
+
+// Audio frames, closed when the receiver stops
+func (r *Receiver) Audio() <-chan *AudioFrame {
+	return r.audio
+}
+
+// Metadata frames, closed when the receiver stops
+func (r *Receiver) Metadata() <-chan *Metadata {
+	return r.metadata
+}
+
+// Status changes, closed when the receiver stops
+func (r *Receiver) Status() <-chan StatusChange {
+	return r.status
+}
+
+// Closed once the receiver stopped capturing
+func (r *Receiver) Done() <-chan struct{} {
+	return r.done
+}
+
+func (r *Receiver) run(ctx context.Context) {
+	defer close(r.done)
+
+	vf, af, mf := &VideoFrameV2{}, &AudioFrameV2{}, &MetadataFrame{}
+	for ctx.Err() == nil {
+		switch r.recv.CaptureV2(vf, af, mf, receiverPollMs) {
+		case FrameTypeVideo:
+			frame := r.newVideo(vf)
+			select {
+			case r.video <- frame:
+			default:
+				frame.Release()
+			}
+			vf = &VideoFrameV2{}
+		case FrameTypeAudio:
+			frame := r.newAudio(af)
+			select {
+			case r.audio <- frame:
+			default:
+				frame.Release()
+			}
+			af = &AudioFrameV2{}
+		case FrameTypeMetadata:
+			metadata := &Metadata{mf.GetData(), mf.Timecode}
+			r.recv.FreeMetadata(mf)
+			select {
+			case r.metadata <- metadata:
+			default:
+			}
+		case FrameTypeStatusChange:
+			select {
+			case r.status <- StatusChange{time.Now()}:
+			default:
+			}
+		case FrameTypeError:
+			// The connection was lost, the SDK reconnects on its own
+			select {
+			case <-ctx.Done():
+			case <-time.After(receiverPollMs * time.Millisecond):
+			}
+		}
+	}
+
+	r.stop()
+}
+
+// Close the channels, release what nobody received, and destroy the instance if nothing is outstanding
+func (r *Receiver) stop() {
+	close(r.video)
+	close(r.audio)
+	close(r.metadata)
+	close(r.status)
+	for frame := range r.video {
+		frame.Release()
+	}
+	for frame := range r.audio {
+		frame.Release()
+	}
+
+	r.mu.Lock()
+	r.stopped = true
+	destroy := r.outstanding == 0
+	r.mu.Unlock()
+
+	if destroy {
+		r.recv.Destroy()
+	}
+}
+
+// Track a frame handed out, returning the function releasing it
+func (r *Receiver) hold(free func(b Backend)) func() {
+	r.mu.Lock()
+	r.outstanding++
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		r.outstanding--
+		destroy := r.stopped && r.outstanding == 0
+		r.mu.Unlock()
+
+		// The instance may have been destroyed by Shutdown, which can't happen while freeing
+		whileTracked(r.recv, free)
+		if destroy {
+			r.recv.Destroy()
+		}
+	}
+}
+
+func (r *Receiver) newVideo(vf *VideoFrameV2) *VideoFrame {
+	frame := &VideoFrame{VideoFrameV2: *vf}
+	if !r.config.copyFrames {
+		frame.release = r.hold(func(b Backend) { b.RecvFreeVideoV2(r.recv.ndiInstance, vf) })
+		return frame
+	}
+
+	data, stride := copyVideoData(vf)
+	frame.LineStride = stride
+	frame.Data = nil
+	if len(data) > 0 {
+		frame.Data = &data[0]
+	}
+	frame.Metadata = nil
+	if vf.Metadata != nil {
+		frame.Metadata = cString(goString(uintptr(unsafe.Pointer(vf.Metadata))))
+	}
+	r.recv.FreeVideoV2(vf)
+	frame.release = func() {}
+
+	return frame
+}
+
+func (r *Receiver) newAudio(af *AudioFrameV2) *AudioFrame {
+	frame := &AudioFrame{AudioFrameV2: *af}
+	if !r.config.copyFrames {
+		frame.release = r.hold(func(b Backend) { b.RecvFreeAudioV2(r.recv.ndiInstance, af) })
+		return frame
+	}
+
+	data := copyPlanarAudio(af.Data, af.NumChannels, af.NumSamples, af.ChannelStride)
+	frame.ChannelStride = af.NumSamples * 4
+	frame.Data = nil
+	if len(data) > 0 {
+		frame.Data = &data[0]
+	}
+	frame.Metadata = nil
+	if af.Metadata != nil {
+		frame.Metadata = cString(goString(uintptr(unsafe.Pointer(af.Metadata))))
+	}
+	r.recv.FreeAudioV2(af)
+	frame.release = func() {}
+
+	return frame
+}
@@ -0,0 +1,83 @@
+package gondi
+
+import (
+	"context"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestReceiver(t *testing.T) {
+	for _, copyFrames := range []bool{false, true} {
+		useTestBackend(t, NewLoopbackBackend())
+
+		sender, err := NewSendInstance("receiver", "", false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var opts []ReceiverOption
+		if copyFrames {
+			opts = append(opts, WithCopyFrames())
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		receiver, err := NewReceiver(ctx, &NewRecvInstanceSettings{
+			SourceToConnectTo: NewSource("LOOPBACK (receiver)", ""),
+			Bandwidth:         RecvBandwidthHighest,
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-receiver.Status():
+		case <-time.After(time.Second):
+			t.Fatal("no status change on connection")
+		}
+
+		pixels := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+		frame := NewVideoFrameV2()
+		frame.FourCC = FourCCTypeBGRA
+		frame.Xres = 2
+		frame.Yres = 1
+		frame.Data = &pixels[0]
+		sender.SendVideoFrame(frame)
+		sender.SendMetadataFrame(NewMetadataFrame(`<hello/>`))
+
+		var video *VideoFrame
+		select {
+		case video = <-receiver.Video():
+		case <-time.After(time.Second):
+			t.Fatal("no video frame")
+		}
+		if data := unsafe.Slice(video.Data, 8); video.Xres != 2 || data[7] != 8 {
+			t.Errorf("received a %dx%d frame with %v", video.Xres, video.Yres, data)
+		}
+
+		select {
+		case metadata := <-receiver.Metadata():
+			if metadata.Data != `<hello/>` {
+				t.Errorf("received metadata %q", metadata.Data)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no metadata frame")
+		}
+
+		// The instance stays alive until the frame is released
+		cancel()
+		<-receiver.Done()
+		if _, ok := <-receiver.Video(); ok {
+			t.Error("video channel not closed")
+		}
+		if alive := isTracked(receiver.Instance()); alive == copyFrames {
+			t.Errorf("instance alive %v with an unreleased frame, copying %v", alive, copyFrames)
+		}
+		video.Release()
+		video.Release()
+		if isTracked(receiver.Instance()) {
+			t.Error("instance not destroyed after releasing every frame")
+		}
+
+		sender.Destroy()
+	}
+}
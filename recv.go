@@ -6,43 +6,19 @@ import (
 
 // Allocate a new Receiver, using a NewRecvInstanceSetting struct as parameters
 func NewRecvInstance(settings *NewRecvInstanceSettings) (*RecvInstance, error) {
-	assertLibrary()
-
-	var name *byte
-	if settings.Name == "" {
-		name = nil
-	} else {
-		name = cString(settings.Name)
-	}
-
-	intSettings := &recvCreateSettings{}
-	if settings.SourceToConnectTo != nil {
-		intSettings = &recvCreateSettings{
-			sourceToConnectTo: *settings.SourceToConnectTo,
-			colorFormat:       settings.ColorFormat,
-			bandwidth:         settings.Bandwidth,
-			allowVideoFields:  settings.AllowVideoFields,
-			name:              name,
-		}
-	} else {
-		intSettings = &recvCreateSettings{
-			sourceToConnectTo: Source{},
-			colorFormat:       settings.ColorFormat,
-			bandwidth:         settings.Bandwidth,
-			allowVideoFields:  settings.AllowVideoFields,
-			name:              name,
-		}
-	}
+	b := currentBackend()
 
 	inst := &RecvInstance{
-		ndiInstance:    0,
-		createSettings: intSettings,
+		ndiInstance: b.RecvCreateV3(settings),
 	}
-
-	inst.ndiInstance = ndilib_recv_create_v3(intSettings)
 	if inst.ndiInstance == 0 {
 		return nil, errors.New("unable to create receiver instance")
 	}
+	inst.ptz = &PTZ{recv: inst}
+	trackInstance(inst, func() {
+		inst.destroyFrameSyncs(b)
+		b.RecvDestroy(inst.ndiInstance)
+	})
 
 	return inst, nil
 }
@@ -52,36 +28,43 @@ func NewRecvInstance(settings *NewRecvInstanceSettings) (*RecvInstance, error) {
 // This call can be called on separate threads, so it is possible to have a separate thread for each of video, audio and metadata.
 // This function will return the type of frame that was received, or gondi.FrameTypeNone if no frame was received within the specified timeout.
 func (p *RecvInstance) CaptureV2(vf *VideoFrameV2, af *AudioFrameV2, mf *MetadataFrame, timeoutMs uint32) FrameType {
-	assertLibrary()
+	ft := currentBackend().RecvCaptureV2(p.ndiInstance, vf, af, mf, timeoutMs)
+	p.captured(ft)
 
-	return FrameType(ndilib_recv_capture_v2(p.ndiInstance, vf, af, mf, timeoutMs))
+	return ft
 }
 
 // This will allow you to receive video, audio and metadata frames from the source you are connected to.
 // Any of the frame pointers can be nil, in which case that type of frame will not be captured.
 // This call can be called on separate threads, so it is possible to have a separate thread for each of video, audio and metadata.
 // This function will return the type of frame that was received, or gondi.FrameTypeNone if no frame was received within the specified timeout.
-func (p *RecvInstance) CaptureV3(vf *VideoFrameV2, af *AudioFrameV3, mf *MetadataFrame, timeoutMs uint32) FrameType {
-	assertLibrary()
+// Returns ErrNotSupported if the NDI library does not support v3 audio frames.
+func (p *RecvInstance) CaptureV3(vf *VideoFrameV2, af *AudioFrameV3, mf *MetadataFrame, timeoutMs uint32) (FrameType, error) {
+	ft, err := currentBackend().RecvCaptureV3(p.ndiInstance, vf, af, mf, timeoutMs)
+	p.captured(ft)
+
+	return ft, err
+}
 
-	return FrameType(ndilib_recv_capture_v3(p.ndiInstance, vf, af, mf, timeoutMs))
+// The capabilities of the source, like PTZ support, are only known again after a status change
+func (p *RecvInstance) captured(ft FrameType) {
+	if ft == FrameTypeStatusChange {
+		p.ptz.invalidate()
+	}
 }
 
 // Connect
 func (p *RecvInstance) Connect(source *Source) {
-	assertLibrary()
-
-	ndilib_recv_connect(p.ndiInstance, source)
+	currentBackend().RecvConnect(p.ndiInstance, source)
 }
 
 // Get the current amount of total and dropped video, audio and metadata frames. This can be used to determine if
 // you have been calling instace.CaptureV2() fast enough to keep up with the incoming stream.
 func (p *RecvInstance) GetPerformance() (total *RecvPerformance, dropped *RecvPerformance) {
-	assertLibrary()
 	total = &RecvPerformance{}
 	dropped = &RecvPerformance{}
 
-	ndilib_recv_get_performance(p.ndiInstance, total, dropped)
+	currentBackend().RecvGetPerformance(p.ndiInstance, total, dropped)
 
 	return total, dropped
 }
@@ -89,54 +72,57 @@ func (p *RecvInstance) GetPerformance() (total *RecvPerformance, dropped *RecvPe
 // Set the up-stream tally notifications. This returns FALSE if we are not currently connected to anything. That
 // said, the moment that we do connect to something it will automatically be sent the tally state.
 func (p *RecvInstance) SetTally(program bool, preview bool) bool {
-	assertLibrary()
 	tally := &Tally{program, preview}
 
-	return ndilib_recv_set_tally(p.ndiInstance, tally)
+	return currentBackend().RecvSetTally(p.ndiInstance, tally)
 }
 
 // This function will send a meta frame to the source that we are connected too. This returns FALSE if we are
 // not currently connected to anything.
 func (p *RecvInstance) SendMetadata(metadata *MetadataFrame) bool {
-	assertLibrary()
-
-	return ndilib_recv_send_metadata(p.ndiInstance, metadata)
+	return currentBackend().RecvSendMetadata(p.ndiInstance, metadata)
 }
 
 // Add a connection metadata string to the list of what is sent on each new connection. If someone is already connected then
 // this frame will be sent to them immediately.
 func (p *RecvInstance) AddConnectionMetadata(metadata *MetadataFrame) {
-	assertLibrary()
-
-	ndilib_recv_add_connection_metadata(p.ndiInstance, metadata)
+	currentBackend().RecvAddConnectionMetadata(p.ndiInstance, metadata)
 }
 
 // Connection based metadata is data that is sent automatically each time a new connection is received. You queue all of these
 // up and they are sent on each connection. To reset them you need to clear them all and set them up again.
 func (p *RecvInstance) ClearConnectionMetadata() {
-	assertLibrary()
-
-	ndilib_recv_clear_connection_metadata(p.ndiInstance)
+	currentBackend().RecvClearConnectionMetadata(p.ndiInstance)
 }
 
 // Free the buffers returned by capture for metadata
 func (p *RecvInstance) FreeMetadata(metadata *MetadataFrame) {
-	ndilib_recv_free_metadata(p.ndiInstance, metadata)
+	currentBackend().RecvFreeMetadata(p.ndiInstance, metadata)
 }
 
 // Free the buffers returned by capture for video
 func (p *RecvInstance) FreeVideoV2(vf *VideoFrameV2) {
-	ndilib_recv_free_video_v2(p.ndiInstance, vf)
+	currentBackend().RecvFreeVideoV2(p.ndiInstance, vf)
 }
 
 // Free the buffers returned by capture for audio
 func (p *RecvInstance) FreeAudioV2(af *AudioFrameV2) {
-	ndilib_recv_free_audio_v2(p.ndiInstance, af)
+	currentBackend().RecvFreeAudioV2(p.ndiInstance, af)
 }
 
-// Destroy a receiver instance
+// Free the buffers returned by CaptureV3 for audio
+// Returns ErrNotSupported if the NDI library does not support v3 audio frames.
+func (p *RecvInstance) FreeAudioV3(af *AudioFrameV3) error {
+	return currentBackend().RecvFreeAudioV3(p.ndiInstance, af)
+}
+
+// Destroy a receiver instance. Calling it again, or after Shutdown destroyed the instance, does nothing.
 func (p *RecvInstance) Destroy() {
-	assertLibrary()
+	if !untrackInstance(p) {
+		return
+	}
+	b := currentBackend()
 
-	ndilib_recv_destroy(p.ndiInstance)
+	p.destroyFrameSyncs(b)
+	b.RecvDestroy(p.ndiInstance)
 }
@@ -2,29 +2,24 @@ package gondi
 
 import (
 	"errors"
-	"unsafe"
 )
 
 // Setup a routed destination, specified by name and groups.
 // The groups property may be empty, and it will use the default from NDI access manager.
+// Returns ErrNotSupported if the NDI library does not support routing.
 func NewRoutingInstance(name string, groups string) (*RoutingInstance, error) {
-	assertLibrary()
+	b := currentBackend()
 
-	settings := &routingCreateSettings{
-		name:   cString(name),
-		groups: cString(groups),
+	inst, err := b.RoutingCreate(name, groups)
+	if err != nil {
+		return nil, err
 	}
-
-	if groups == "" {
-		settings.groups = nil
-	}
-
-	inst := ndilib_routing_create(uintptr(unsafe.Pointer(settings)))
 	if inst == 0 {
 		return nil, errors.New("unable to create routing instance")
 	}
 
-	instance := &RoutingInstance{inst, settings, name, groups}
+	instance := &RoutingInstance{inst, name, groups}
+	trackInstance(instance, func() { b.RoutingDestroy(inst) })
 
 	return instance, nil
 }
@@ -41,21 +36,18 @@ func (p *RoutingInstance) Groups() string {
 
 // Change the source this routing instance is connected to.
 func (p *RoutingInstance) Change(source *Source) {
-	assertLibrary()
-
-	ndilib_routing_change(p.ndiInstance, uintptr(unsafe.Pointer(source)))
+	currentBackend().RoutingChange(p.ndiInstance, source)
 }
 
 // Clear the current source this routing instance is connected to. Should return black to watchers.
 func (p *RoutingInstance) Clear() {
-	assertLibrary()
-
-	ndilib_routing_clear(p.ndiInstance)
+	currentBackend().RoutingClear(p.ndiInstance)
 }
 
-// Destroy this routing instance.
+// Destroy this routing instance. Calling it again, or after Shutdown destroyed the instance, does nothing.
 func (p *RoutingInstance) Destroy() {
-	assertLibrary()
-
-	ndilib_routing_destroy(p.ndiInstance)
+	if !untrackInstance(p) {
+		return
+	}
+	currentBackend().RoutingDestroy(p.ndiInstance)
 }
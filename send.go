@@ -5,28 +5,51 @@ import (
 )
 
 // Set up a sender instance using the specified name and string.
+// The groups may be empty, and it will use the default from NDI access manager. See NewSender for more options.
 // Syncronous calls will block on either audio or video frames, or both, depending on the clockVideo and clockAudio parameters, to make sure that the frames are sent at the correct time.
 func NewSendInstance(name string, groups string, clockVideo bool, clockAudio bool) (*SendInstance, error) {
-	assertLibrary()
+	b := currentBackend()
 
-	settings := &sendCreateSettings{cString(name), cString(groups), clockVideo, clockAudio}
-	instance := ndilib_send_create(settings)
+	instance := b.SendCreate(name, groups, clockVideo, clockAudio)
 	if instance == 0 {
 		return nil, errors.New("unable to create send instance")
 	}
 
-	return &SendInstance{instance, settings}, nil
+	inst := &SendInstance{ndiInstance: instance, name: name}
+	trackInstance(inst, func() {
+		b.SendDestroy(instance)
+		inst.synchronized(nil)
+	})
+
+	return inst, nil
 }
 
 // Remember to call Destroy() on the instance when you are done with it. This will free up resources and unregister the sender.
+// Calling it again, or after Shutdown destroyed the instance, does nothing.
 func (p *SendInstance) Destroy() error {
-	assertLibrary()
-
-	ndilib_send_destroy(p.ndiInstance)
+	if !untrackInstance(p) {
+		return nil
+	}
+	currentBackend().SendDestroy(p.ndiInstance)
+	p.synchronized(nil)
+	p.logf("destroyed sender %q", p.name)
 
 	return nil
 }
 
+// A synchronizing event happened, so the frame sent asynchronously before is not in use anymore. The next one calls
+// synced, which may be nil.
+func (p *SendInstance) synchronized(synced func()) {
+	p.mu.Lock()
+	previous := p.synced
+	p.synced = synced
+	p.mu.Unlock()
+
+	if previous != nil {
+		previous()
+	}
+}
+
 // Allocate a new NDI audio frame object
 func NewAudioFrameV2() *AudioFrameV2 {
 	af := &AudioFrameV2{}
@@ -43,7 +66,7 @@ func NewAudioFrameV2() *AudioFrameV2 {
 	return af
 }
 
-// Allocate a new NDI audio frame object
+// Allocate a new NDI audio frame object, holding planar float32 audio by default
 func NewAudioFrameV3() *AudioFrameV3 {
 	af := AudioFrameV3{}
 
@@ -51,6 +74,7 @@ func NewAudioFrameV3() *AudioFrameV3 {
 	af.NumChannels = 0
 	af.NumSamples = 0
 	af.Timecode = SendTimecodeSynthesize
+	af.FourCC = FourCCAudioTypeFLTP
 	af.Data = nil
 	af.ChannelStride = 0
 	af.Metadata = nil
@@ -60,17 +84,17 @@ func NewAudioFrameV3() *AudioFrameV3 {
 }
 
 // Allocate a new NDI audio frame object with preallocated data for
-// holding numChannels * numSamples samples.
+// holding numChannels * numSamples samples of tightly packed planar audio.
 func NewAudioFrameV2Preallocated(numChannels int32, numSamples int32) *AudioFrameV2 {
 	af := AudioFrameV2{}
-	data := make([]float32, numChannels*numSamples)
+	data := make([]float32, max(numChannels*numSamples, 1))
 
 	af.SampleRate = 0
-	af.NumChannels = 0
-	af.NumSamples = 0
+	af.NumChannels = numChannels
+	af.NumSamples = numSamples
 	af.Timecode = SendTimecodeSynthesize
 	af.Data = &data[0]
-	af.ChannelStride = 0
+	af.ChannelStride = numSamples * 4
 	af.Metadata = nil
 	af.Timestamp = SendTimecodeEmpty
 
@@ -99,9 +123,8 @@ func NewVideoFrameV2() *VideoFrameV2 {
 
 // Send a video frame. This call is syncronous and will block until the frame has been sent if you specified clockVideo=true in NewNDISendInstance().
 func (p *SendInstance) SendVideoFrame(frame *VideoFrameV2) {
-	assertLibrary()
-
-	ndilib_send_send_video_v2(p.ndiInstance, frame)
+	currentBackend().SendVideoV2(p.ndiInstance, frame)
+	p.synchronized(nil)
 }
 
 // Send video asynchronously, this call will return immediately, and you need to keep the video frame memory resident until a
@@ -111,96 +134,109 @@ func (p *SendInstance) SendVideoFrame(frame *VideoFrameV2) {
 // - A call to frame.SendVideoFrameAsync() with a different video frame
 // - A call to frame.SendVideoFrame(nil)
 // - A call to frame.Destroy()
+// See NewVideoRing to have the memory managed for you.
 func (p *SendInstance) SendVideoFrameAsync(frame *VideoFrameV2) {
-	assertLibrary()
+	p.sendVideoAsync(frame, nil)
+}
 
-	ndilib_send_send_video_async_v2(p.ndiInstance, frame)
+// Send a frame asynchronously, keeping it and its memory pinned until the next synchronizing event, which then calls
+// synced. A nil frame only synchronizes.
+func (p *SendInstance) sendVideoAsync(frame *VideoFrameV2, synced func()) {
+	if frame == nil {
+		currentBackend().SendVideoAsyncV2(p.ndiInstance, nil)
+		p.synchronized(synced)
+		return
+	}
+
+	pins := &pinned{}
+	pins.videoFrame(frame)
+	currentBackend().SendVideoAsyncV2(p.ndiInstance, frame)
+	p.synchronized(func() {
+		pins.release()
+		if synced != nil {
+			synced()
+		}
+	})
+}
+
+// Wait until the frame sent asynchronously is not in use anymore, its memory can be reused afterwards.
+func (p *SendInstance) FlushVideoAsync() {
+	p.SendVideoFrameAsync(nil)
 }
 
 // Send a metadata frame
 func (p *SendInstance) SendMetadataFrame(frame *MetadataFrame) {
-	assertLibrary()
-
-	ndilib_send_send_metadata(p.ndiInstance, frame)
+	currentBackend().SendMetadata(p.ndiInstance, frame)
 }
 
 // This method lets you receive metadata from the other end of the connection.
 // Remember that there might be multiple connections to your sender instance.
 func (p *SendInstance) Capture(metadata *MetadataFrame, timeoutMs uint32) FrameType {
-	assertLibrary()
+	return currentBackend().SendCapture(p.ndiInstance, metadata, timeoutMs)
+}
 
-	return FrameType(ndilib_send_capture(p.ndiInstance, metadata, timeoutMs))
+// Free the buffers of a metadata frame returned by Capture
+func (p *SendInstance) FreeMetadata(metadata *MetadataFrame) {
+	currentBackend().SendFreeMetadata(p.ndiInstance, metadata)
 }
 
 // Add a connection metadata string to the list of what is sent on each new connection. If someone is already connected then
 // this string will be sent to them immediately.
 func (p *SendInstance) AddConnectionMetadata(metadata *MetadataFrame) {
-	assertLibrary()
-
-	ndilib_send_add_connection_metadata(p.ndiInstance, metadata)
+	currentBackend().SendAddConnectionMetadata(p.ndiInstance, metadata)
 }
 
 // Connection based metadata is data that is sent automatically each time a new connection is received. You queue all of these
 // up and they are sent on each connection. To reset them you need to clear them all and set them up again.
 func (p *SendInstance) ClearConnectionMetadata() {
-	assertLibrary()
-
-	ndilib_send_clear_connection_metadata(p.ndiInstance)
+	currentBackend().SendClearConnectionMetadata(p.ndiInstance)
 }
 
 // Get the current number of receivers connected to this source. This can be used to avoid even rendering when nothing is connected to the video source.
 // which can significantly improve the efficiency if you want to make a lot of sources available on the network. If you specify a timeout that is not
 // 0 then it will wait until there are connections for this amount of time.
-func (p *SendInstance) GetNumberOfConnections(timeoutMs uint32) int32 {
-	assertLibrary()
-
-	return ndilib_send_get_no_connections(p.ndiInstance, timeoutMs)
+// Returns ErrNotSupported if the NDI library does not export NDIlib_send_get_no_connections.
+func (p *SendInstance) GetNumberOfConnections(timeoutMs uint32) (int32, error) {
+	return currentBackend().SendGetNoConnections(p.ndiInstance, timeoutMs)
 }
 
 // Determine the current tally sate. If you specify a timeout then it will wait until it has changed, otherwise it will simply poll it
 // and return the current tally immediately. The boolean return value is whether anything has actually changed (true) or whether it timed out (false)
 func (p *SendInstance) GetTally(timeoutMs uint32) (*Tally, bool) {
-	assertLibrary()
 	tally := &Tally{}
 
-	changed := ndilib_send_get_tally(p.ndiInstance, tally, timeoutMs)
+	changed := currentBackend().SendGetTally(p.ndiInstance, tally, timeoutMs)
 
 	return tally, changed
 }
 
 // Send an audio frame. This call is syncronous and will block until the frame has been sent, if you specified clockAudio=true in NewNDISendInstance().
 func (p *SendInstance) SendAudioFrame(frame *AudioFrameV2) {
-	assertLibrary()
-
-	ndilib_send_send_audio_v2(p.ndiInstance, frame)
+	currentBackend().SendAudioV2(p.ndiInstance, frame)
 }
 
 // Send an audio frame. This call is syncronous and will block until the frame has been sent, if you specified clockAudio=true in NewNDISendInstance().
-func (p *SendInstance) SendAudioFrameV3(frame *AudioFrameV3) {
-	assertLibrary()
-
-	ndilib_send_send_audio_v3(p.ndiInstance, frame)
+// Returns ErrNotSupported if the NDI library does not support v3 audio frames.
+func (p *SendInstance) SendAudioFrameV3(frame *AudioFrameV3) error {
+	return currentBackend().SendAudioV3(p.ndiInstance, frame)
 }
 
 // Send an audio frame. This call is syncronous and will block until the frame has been sent, if you specified clockAudio=true in NewNDISendInstance().
-func (p *SendInstance) SendAudioFrame16s(frame *AudioFrameV3) {
-	assertLibrary()
-
-	ndilib_util_send_send_audio_interleaved_16s(p.ndiInstance, frame)
+// Returns ErrNotSupported if the NDI library does not export NDIlib_util_send_send_audio_interleaved_16s.
+func (p *SendInstance) SendAudioFrame16s(frame *AudioFrameV3) error {
+	return currentBackend().SendAudioInterleaved16s(p.ndiInstance, frame)
 }
 
 // Send an audio frame. This call is syncronous and will block until the frame has been sent, if you specified clockAudio=true in NewNDISendInstance().
-func (p *SendInstance) SendAudioFrame32f(frame *AudioFrameV3) {
-	assertLibrary()
-
-	ndilib_util_send_send_audio_interleaved_32f(p.ndiInstance, frame)
+// Returns ErrNotSupported if the NDI library does not export NDIlib_util_send_send_audio_interleaved_32f.
+func (p *SendInstance) SendAudioFrame32f(frame *AudioFrameV3) error {
+	return currentBackend().SendAudioInterleaved32f(p.ndiInstance, frame)
 }
 
 // This will assign a new fail-over source for this video source. What this means is that if this video source was to fail
 // any receivers would automatically switch over to use this source, unless this source then came back online. You can specify
 // nil to clear the source.
-func (p *SendInstance) SetFailover(source *Source) {
-	assertLibrary()
-
-	ndilib_send_set_failover(p.ndiInstance, source)
+// Returns ErrNotSupported if the NDI library does not export NDIlib_send_set_failover.
+func (p *SendInstance) SetFailover(source *Source) error {
+	return currentBackend().SendSetFailover(p.ndiInstance, source)
 }
@@ -0,0 +1,159 @@
+package gondi
+
+import (
+	"errors"
+	"image"
+	"sync"
+
+	"github.com/benitogf/gondi/convert"
+)
+
+// Options for SendImage, the zero value works for most images
+type ImageOptions struct {
+	// FourCC to send, picked from the image when zero: RGBA, RGBX or BGRA for images in those layouts, UYVY for the
+	// views of UYVY frames, and otherwise UYVY for opaque images and UYVA for images with alpha
+	FourCC FourCCType
+
+	// Frame rate, 30000/1001 when zero
+	FrameRateN, FrameRateD int32
+
+	// Picture aspect ratio, the one of the image with square pixels when zero
+	PictureAspectRatio float32
+
+	// Timecode in 100ns intervals, synthesized when zero
+	Timecode int64
+
+	// Send asynchronously. The image is copied to a buffer that is kept until the next synchronizing event, so the
+	// image can be changed as soon as SendImage returns.
+	Async bool
+
+	// Matrix and range used when converting to YCbCr
+	Convert convert.Options
+}
+
+// Buffers of converted images, reused once sent
+var imageBuffers sync.Pool
+
+func getImageBuffer(size int) []byte {
+	if buffer, ok := imageBuffers.Get().(*[]byte); ok && cap(*buffer) >= size {
+		return (*buffer)[:size]
+	}
+	return make([]byte, size)
+}
+
+func putImageBuffer(buffer []byte) {
+	imageBuffers.Put(&buffer)
+}
+
+// Get the frame an image already is, sharing its memory
+func nativeFrame(img image.Image) *convert.Frame {
+	var fourCC convert.FourCC
+	var pix []byte
+	stride := 0
+	switch img := img.(type) {
+	case *image.NRGBA:
+		fourCC, pix, stride = convert.FourCCRGBA, img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y):], img.Stride
+	case *image.RGBA:
+		// Premultiplied alpha is only the same as NDI straight alpha when opaque
+		if !img.Opaque() {
+			return nil
+		}
+		fourCC, pix, stride = convert.FourCCRGBX, img.Pix[img.PixOffset(img.Rect.Min.X, img.Rect.Min.Y):], img.Stride
+	case *convert.BGRA:
+		fourCC, pix, stride = convert.FourCCBGRA, img.Pix, img.Stride
+	case *convert.UYVY:
+		fourCC, pix, stride = convert.FourCCUYVY, img.Pix, img.Stride
+	default:
+		return nil
+	}
+
+	frame := &convert.Frame{FourCC: fourCC, Width: img.Bounds().Dx(), Height: img.Bounds().Dy(), Stride: stride, Data: pix}
+	if size, err := convert.FrameSize(fourCC, frame.Width, frame.Height, stride); err != nil || len(pix) < size {
+		return nil
+	}
+
+	return frame
+}
+
+// Send any image as a video frame, converting it when needed, see ImageOptions. Images in a layout NDI supports are
+// sent without copying when sending synchronously. Nil options use the defaults.
+func (p *SendInstance) SendImage(img image.Image, opts *ImageOptions) error {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	if width <= 0 || height <= 0 {
+		return errors.New("unable to send an empty image")
+	}
+
+	frame := nativeFrame(img)
+	fourCC := convert.FourCC(opts.FourCC)
+	if fourCC == (convert.FourCC{}) {
+		switch {
+		case frame != nil:
+			fourCC = frame.FourCC
+		case isOpaque(img):
+			fourCC = convert.FourCCUYVY
+		default:
+			fourCC = convert.FourCCUYVA
+		}
+	}
+
+	var buffer []byte
+	if frame == nil || frame.FourCC != fourCC || opts.Async {
+		size, err := convert.FrameSize(fourCC, width, height, 0)
+		if err != nil {
+			return err
+		}
+		buffer = getImageBuffer(size)
+		converted := &convert.Frame{FourCC: fourCC, Width: width, Height: height, Data: buffer}
+		if frame != nil {
+			err = convert.Convert(converted, frame, &opts.Convert)
+		} else {
+			err = convert.FromImage(converted, img, &opts.Convert)
+		}
+		if err != nil {
+			putImageBuffer(buffer)
+			return err
+		}
+		converted.Stride = convert.DefaultStride(fourCC, width)
+		frame = converted
+	}
+
+	vf := NewVideoFrameV2()
+	vf.FourCC = FourCCType(frame.FourCC)
+	vf.Xres, vf.Yres = int32(width), int32(height)
+	vf.LineStride = int32(frame.Stride)
+	vf.FrameRateN, vf.FrameRateD = 30000, 1001
+	if opts.FrameRateN > 0 && opts.FrameRateD > 0 {
+		vf.FrameRateN, vf.FrameRateD = opts.FrameRateN, opts.FrameRateD
+	}
+	vf.PictureAspectRatio = float32(width) / float32(height)
+	if opts.PictureAspectRatio > 0 {
+		vf.PictureAspectRatio = opts.PictureAspectRatio
+	}
+	if opts.Timecode != 0 {
+		vf.Timecode = opts.Timecode
+	}
+	vf.Data = &frame.Data[0]
+
+	if !opts.Async {
+		p.SendVideoFrame(vf)
+		if buffer != nil {
+			putImageBuffer(buffer)
+		}
+		return nil
+	}
+
+	p.sendVideoAsync(vf, func() { putImageBuffer(buffer) })
+
+	return nil
+}
+
+// Is every pixel of the image opaque, when the image can tell
+func isOpaque(img image.Image) bool {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok {
+		return opaque.Opaque()
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package gondi
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"unsafe"
+)
+
+func TestSendImage(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "images")
+
+	receive := func() *VideoFrameV2 {
+		t.Helper()
+		vf := &VideoFrameV2{}
+		for {
+			switch ft := receiver.CaptureV2(vf, nil, nil, 1000); ft {
+			case FrameTypeVideo:
+				return vf
+			case FrameTypeStatusChange:
+			default:
+				t.Fatalf("captured %d, want video", ft)
+			}
+		}
+	}
+
+	opaque := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+	for i := range opaque.Pix {
+		opaque.Pix[i] = 255
+	}
+	opaque.SetNRGBA(1, 2, color.NRGBA{10, 20, 30, 40})
+	gray := image.NewGray(image.Rect(0, 0, 6, 2))
+	translucent := image.NewRGBA(image.Rect(0, 0, 4, 4))
+
+	tests := []struct {
+		img    image.Image
+		opts   *ImageOptions
+		fourCC FourCCType
+	}{
+		{opaque, nil, FourCCTypeRGBA},
+		{opaque.SubImage(image.Rect(1, 1, 5, 3)), &ImageOptions{Async: true}, FourCCTypeRGBA},
+		{opaque, &ImageOptions{FourCC: FourCCTypeBGRA}, FourCCTypeBGRA},
+		{gray, &ImageOptions{FrameRateN: 50, FrameRateD: 1}, FourCCTypeUYVY},
+		{translucent, nil, FourCCTypeUYVA},
+	}
+	for i, test := range tests {
+		if err := sender.SendImage(test.img, test.opts); err != nil {
+			t.Fatal(err)
+		}
+		vf := receive()
+		bounds := test.img.Bounds()
+		if vf.FourCC != test.fourCC || int(vf.Xres) != bounds.Dx() || int(vf.Yres) != bounds.Dy() {
+			t.Errorf("%d: received %s %dx%d, want %s %dx%d", i, vf.FourCC[:], vf.Xres, vf.Yres, test.fourCC[:], bounds.Dx(), bounds.Dy())
+		}
+		if vf.PictureAspectRatio != float32(bounds.Dx())/float32(bounds.Dy()) {
+			t.Errorf("%d: aspect ratio %f", i, vf.PictureAspectRatio)
+		}
+		if test.opts != nil && test.opts.FrameRateN == 50 && vf.FrameRateN != 50 {
+			t.Errorf("%d: frame rate %d/%d", i, vf.FrameRateN, vf.FrameRateD)
+		}
+
+		// The pixel set in the opaque image is where it should be
+		if test.img != gray && test.img != translucent {
+			img, err := vf.Image()
+			if err != nil {
+				t.Fatal(err)
+			}
+			x, y := 1-bounds.Min.X, 2-bounds.Min.Y
+			if got := color.NRGBAModel.Convert(img.At(x, y)); got != (color.NRGBA{10, 20, 30, 40}) {
+				t.Errorf("%d: pixel is %v", i, got)
+			}
+		}
+		receiver.FreeVideoV2(vf)
+	}
+
+	// An async send keeps its own copy
+	opaque.SetNRGBA(0, 0, color.NRGBA{1, 2, 3, 255})
+	if err := sender.SendImage(opaque, &ImageOptions{Async: true}); err != nil {
+		t.Fatal(err)
+	}
+	opaque.SetNRGBA(0, 0, color.NRGBA{255, 255, 255, 255})
+	vf := receive()
+	if data := unsafe.Slice(vf.Data, 4); data[0] != 1 {
+		t.Errorf("async frame changed with the image: %v", data)
+	}
+	receiver.FreeVideoV2(vf)
+
+	if err := sender.SendImage(image.NewNRGBA(image.Rect(0, 0, 0, 0)), nil); err == nil {
+		t.Error("empty image sent")
+	}
+}
@@ -0,0 +1,193 @@
+package gondi
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Configuration of a sender for NewSenderFromOptions, it can be loaded from a configuration file.
+type SendOptions struct {
+	// The name of the source on the network, it is shown as "MACHINE (Name)"
+	Name string `json:"name"`
+
+	// Comma separated groups the source is part of, empty for the default groups of NDI Access Manager
+	Groups string `json:"groups,omitempty"`
+
+	// Rate limit video or audio frames to their frame rate or sample rate, see NewSendInstance
+	ClockVideo bool `json:"clockVideo,omitempty"`
+	ClockAudio bool `json:"clockAudio,omitempty"`
+
+	// Source receivers switch to when this sender goes away, nil for none
+	Failover *Source `json:"-"`
+
+	// Name and address of the failover source in configuration files, used when Failover is nil, see NewSource
+	FailoverName    string `json:"failoverName,omitempty"`
+	FailoverAddress string `json:"failoverAddress,omitempty"`
+
+	// XML metadata sent to every receiver when it connects, like ndi_product or ndi_capabilities
+	ConnectionMetadata []string `json:"connectionMetadata,omitempty"`
+
+	// Receives the diagnostic messages of the sender, nil to discard them
+	Logger Logger `json:"-"`
+}
+
+// Options for NewSender
+type SendOption func(*SendOptions)
+
+// Put the source in these comma separated groups instead of the default ones
+func WithGroups(groups string) SendOption {
+	return func(o *SendOptions) {
+		o.Groups = groups
+	}
+}
+
+// Rate limit video frames to their frame rate, and audio frames to their sample rate
+func WithClocking(video bool, audio bool) SendOption {
+	return func(o *SendOptions) {
+		o.ClockVideo = video
+		o.ClockAudio = audio
+	}
+}
+
+// Have receivers switch to this source when the sender goes away
+func WithFailover(source *Source) SendOption {
+	return func(o *SendOptions) {
+		o.Failover = source
+	}
+}
+
+// Send this XML to every receiver when it connects, can be given several times
+func WithConnectionMetadata(data ...string) SendOption {
+	return func(o *SendOptions) {
+		o.ConnectionMetadata = append(o.ConnectionMetadata, data...)
+	}
+}
+
+// Log the diagnostic messages of the sender
+func WithSendLogger(logger Logger) SendOption {
+	return func(o *SendOptions) {
+		o.Logger = logger
+	}
+}
+
+// Check the options, returning an error wrapping ErrInvalidOptions for the first problem found
+func (o *SendOptions) Validate() error {
+	if o.Name == "" {
+		return fmt.Errorf("%w: the sender name is empty", ErrInvalidOptions)
+	}
+	for _, value := range []string{o.Name, o.Groups, o.FailoverName, o.FailoverAddress} {
+		if !utf8.ValidString(value) || strings.ContainsRune(value, 0) {
+			return fmt.Errorf("%w: %q is not a valid UTF-8 string", ErrInvalidOptions, value)
+		}
+	}
+	if o.Groups != "" {
+		for _, group := range strings.Split(o.Groups, ",") {
+			if strings.TrimSpace(group) == "" {
+				return fmt.Errorf("%w: the sender groups %q contain an empty group", ErrInvalidOptions, o.Groups)
+			}
+		}
+	}
+	if o.Failover != nil && o.Failover.Name() == "" && o.Failover.Address() == "" {
+		return fmt.Errorf("%w: the failover source has neither name nor address", ErrInvalidOptions)
+	}
+	for _, data := range o.ConnectionMetadata {
+		if err := checkXML(data); err != nil {
+			return fmt.Errorf("%w: connection metadata %q: %v", ErrInvalidOptions, data, err)
+		}
+	}
+
+	return nil
+}
+
+// Create a sender named name, configured by the options.
+func NewSender(name string, opts ...SendOption) (*SendInstance, error) {
+	options := SendOptions{Name: name}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return NewSenderFromOptions(options)
+}
+
+// Create a sender from its configuration. The options are validated first, and nothing is created when they are
+// invalid. Returns ErrNotSupported if a failover is set and the NDI library does not support it.
+func NewSenderFromOptions(options SendOptions) (*SendInstance, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	sender, err := NewSendInstance(options.Name, options.Groups, options.ClockVideo, options.ClockAudio)
+	if err != nil {
+		return nil, err
+	}
+	sender.logger = options.Logger
+
+	if failover := options.failover(); failover != nil {
+		if err := sender.SetFailover(failover); err != nil {
+			sender.Destroy()
+			return nil, err
+		}
+	}
+	for _, data := range options.ConnectionMetadata {
+		sender.AddConnectionMetadata(NewMetadataFrame(data))
+	}
+	sender.logf("created sender %q in groups %q", options.Name, options.Groups)
+
+	return sender, nil
+}
+
+// Get the failover source, from Failover or else from its name and address, nil when there is none
+func (o *SendOptions) failover() *Source {
+	if o.Failover != nil {
+		return o.Failover
+	}
+	if o.FailoverName == "" && o.FailoverAddress == "" {
+		return nil
+	}
+
+	return NewSource(o.FailoverName, o.FailoverAddress)
+}
+
+// Log a message if the sender has a logger
+func (p *SendInstance) logf(format string, v ...any) {
+	if p.logger != nil {
+		p.logger.Printf(format, v...)
+	}
+}
+
+// Check that data is a well formed XML document with a single root element, which is what NDI metadata must be
+func checkXML(data string) error {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	depth, roots := 0, 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				roots++
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && strings.TrimSpace(string(token)) != "" {
+				return errors.New("text outside of the root element")
+			}
+		}
+	}
+	if roots != 1 {
+		return fmt.Errorf("%d root elements, want 1", roots)
+	}
+
+	return nil
+}
@@ -0,0 +1,105 @@
+package gondi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Printf(format string, v ...any) {
+	l.lines = append(l.lines, format)
+}
+
+func TestSendOptionsValidate(t *testing.T) {
+	tests := []struct {
+		options SendOptions
+		valid   bool
+	}{
+		{SendOptions{Name: "camera"}, true},
+		{SendOptions{Name: "camera", Groups: "studio,public", ConnectionMetadata: []string{`<ndi_product long_name="x"/>`}}, true},
+		{SendOptions{}, false},
+		{SendOptions{Name: "cam\x00era"}, false},
+		{SendOptions{Name: "camera", Groups: "studio,,public"}, false},
+		{SendOptions{Name: "camera", Failover: &Source{}}, false},
+		{SendOptions{Name: "camera", FailoverName: "BACKUP (camera\x00)"}, false},
+		{SendOptions{Name: "camera", ConnectionMetadata: []string{`<unclosed>`}}, false},
+		{SendOptions{Name: "camera", ConnectionMetadata: []string{`<a/><b/>`}}, false},
+	}
+	for _, tt := range tests {
+		err := tt.options.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%+v is invalid: %v", tt.options, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidOptions) {
+			t.Errorf("%+v returned %v, want ErrInvalidOptions", tt.options, err)
+		}
+	}
+}
+
+func TestSendOptionsJSON(t *testing.T) {
+	var options SendOptions
+	config := `{"name": "camera", "groups": "studio", "failoverName": "BACKUP (camera)", "failoverAddress": "10.0.0.2:5961"}`
+	if err := json.Unmarshal([]byte(config), &options); err != nil {
+		t.Fatal(err)
+	}
+	if err := options.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if failover := options.failover(); failover == nil || failover.Name() != "BACKUP (camera)" || failover.Address() != "10.0.0.2:5961" {
+		t.Errorf("failover is %+v", failover)
+	}
+
+	// A Failover set in code wins over the configuration
+	options.Failover = NewSource("MAIN (camera)", "")
+	if options.failover().Name() != "MAIN (camera)" {
+		t.Errorf("failover is %+v", options.failover())
+	}
+}
+
+func TestNewSender(t *testing.T) {
+	useTestBackend(t, NewLoopbackBackend())
+
+	if _, err := NewSender(""); !errors.Is(err, ErrInvalidOptions) {
+		t.Fatalf("NewSender without a name returned %v", err)
+	}
+
+	logger := &testLogger{}
+	sender, err := NewSender("options",
+		WithGroups("studio"),
+		WithConnectionMetadata(`<ndi_capabilities ntk_ptz="true"/>`),
+		WithSendLogger(logger),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Destroy()
+
+	// Only receivers in the same group see the sender, and they get the connection metadata
+	finder, _ := NewFindInstance(true, "studio", "")
+	defer finder.Destroy()
+	finder.WaitForSources(1000)
+	sources := finder.GetCurrentSources()
+	if len(sources) != 1 {
+		t.Fatalf("found %d sources in the studio group, want 1", len(sources))
+	}
+	receiver, _ := NewRecvInstance(&NewRecvInstanceSettings{SourceToConnectTo: sources[0]})
+	defer receiver.Destroy()
+	mf := &MetadataFrame{}
+	for ft := receiver.CaptureV2(nil, nil, mf, 1000); ft != FrameTypeMetadata; ft = receiver.CaptureV2(nil, nil, mf, 1000) {
+		if ft == FrameTypeNone {
+			t.Fatal("no connection metadata received")
+		}
+	}
+	if !strings.Contains(mf.GetData(), "ndi_capabilities") {
+		t.Errorf("received %q", mf.GetData())
+	}
+
+	if len(logger.lines) == 0 {
+		t.Error("nothing was logged")
+	}
+}
@@ -0,0 +1,202 @@
+package gondi
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// How long the send watcher waits for a tally change before checking the connections and its context
+const sendWatcherPollMs = 50
+
+// Kind of change reported by a SendWatcher
+type SendEventType int
+
+const (
+	// The program or preview tally changed
+	SendTallyChanged SendEventType = iota
+	// The first receiver connected
+	SendConnected
+	// The last receiver disconnected
+	SendDisconnected
+)
+
+func (t SendEventType) String() string {
+	switch t {
+	case SendTallyChanged:
+		return "tally changed"
+	case SendConnected:
+		return "connected"
+	case SendDisconnected:
+		return "disconnected"
+	}
+
+	return "unknown"
+}
+
+// A change of a sender reported by a SendWatcher, with the state after the change
+type SendEvent struct {
+	Type        SendEventType
+	Tally       Tally
+	Connections int
+}
+
+// SendWatcher follows the tally and the connections of a SendInstance in a goroutine, so applications can show the
+// tally and stop rendering while nobody is connected.
+//
+// Events are passed to the callback given to NewSendWatcher, or on the Events channel when the callback is nil. Events
+// that don't fit in the channel are dropped, so Tally, Connected and WaitConnected stay current even when nobody reads
+// it. The watcher stops when its context is done, after which the Events channel is closed. The sender must not be destroyed
+// before the watcher stopped, see Done.
+//
+// When the NDI library can't count connections, the sender is considered connected and no connection events are sent.
+type SendWatcher struct {
+	sender   *SendInstance
+	callback func(SendEvent)
+	events   chan SendEvent
+	done     chan struct{}
+
+	mu          sync.Mutex
+	tally       Tally
+	connections int
+	countable   bool
+	connected   chan struct{}
+}
+
+// Start watching a sender until the context is done. The callback, if not nil, is called from the goroutine of the
+// watcher, so it should return quickly.
+func NewSendWatcher(ctx context.Context, sender *SendInstance, callback func(SendEvent)) *SendWatcher {
+	w := &SendWatcher{
+		sender:    sender,
+		callback:  callback,
+		events:    make(chan SendEvent, 16),
+		done:      make(chan struct{}),
+		countable: true,
+		connected: make(chan struct{}),
+	}
+	go w.run(ctx)
+
+	return w
+}
+
+// Channel the events are sent on when the watcher has no callback, new events are dropped while it is full. It is
+// closed when the watcher stops.
+func (w *SendWatcher) Events() <-chan SendEvent {
+	return w.events
+}
+
+// Closed once the watcher stopped and does not use the sender anymore
+func (w *SendWatcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// Get the last tally seen
+func (w *SendWatcher) Tally() Tally {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.tally
+}
+
+// Get the last number of connections seen
+func (w *SendWatcher) Connections() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.connections
+}
+
+// Is at least one receiver connected
+func (w *SendWatcher) Connected() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return !w.countable || w.connections > 0
+}
+
+// Block until at least one receiver is connected, or the context is done. Use it in the render loop to skip
+// rendering while nobody is watching.
+func (w *SendWatcher) WaitConnected(ctx context.Context) error {
+	w.mu.Lock()
+	connected := w.connected
+	if !w.countable || w.connections > 0 {
+		w.mu.Unlock()
+		return nil
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-connected:
+		return nil
+	case <-w.done:
+		return errors.New("send watcher stopped")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *SendWatcher) run(ctx context.Context) {
+	defer close(w.done)
+	defer close(w.events)
+
+	for ctx.Err() == nil {
+		if tally, changed := w.sender.GetTally(sendWatcherPollMs); changed {
+			w.mu.Lock()
+			different := *tally != w.tally
+			w.tally = *tally
+			event := SendEvent{SendTallyChanged, w.tally, w.connections}
+			w.mu.Unlock()
+
+			if different {
+				w.emit(event)
+			}
+		}
+
+		if event, ok := w.updateConnections(); ok {
+			w.emit(event)
+		}
+	}
+}
+
+// Count the connections, returning an event when the sender went from 0 to some connections or back
+func (w *SendWatcher) updateConnections() (SendEvent, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if !w.countable {
+		return SendEvent{}, false
+	}
+	count, err := w.sender.GetNumberOfConnections(0)
+	if err != nil {
+		w.countable = false
+		close(w.connected)
+		w.sender.logf("sender %q can't count its connections: %v", w.sender.name, err)
+		return SendEvent{}, false
+	}
+
+	previous := w.connections
+	w.connections = int(count)
+	switch {
+	case previous == 0 && w.connections > 0:
+		close(w.connected)
+		return SendEvent{SendConnected, w.tally, w.connections}, true
+	case previous > 0 && w.connections == 0:
+		w.connected = make(chan struct{})
+		return SendEvent{SendDisconnected, w.tally, w.connections}, true
+	}
+
+	return SendEvent{}, false
+}
+
+func (w *SendWatcher) emit(event SendEvent) {
+	if w.callback != nil {
+		w.callback(event)
+		return
+	}
+
+	select {
+	case w.events <- event:
+	default:
+		w.sender.logf("sender %q watcher events channel full, dropped %v", w.sender.name, event.Type)
+	}
+}
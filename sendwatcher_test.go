@@ -0,0 +1,108 @@
+package gondi
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSendWatcher(t *testing.T) {
+	useTestBackend(t, NewLoopbackBackend())
+
+	sender, err := NewSendInstance("watched", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Destroy()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher := NewSendWatcher(ctx, sender, nil)
+	if watcher.Connected() {
+		t.Error("connected before any receiver was created")
+	}
+
+	// Wait for an event of the given type, skipping the others
+	wait := func(want SendEventType) SendEvent {
+		t.Helper()
+		timeout := time.After(time.Second)
+		for {
+			select {
+			case event := <-watcher.Events():
+				if event.Type == want {
+					return event
+				}
+			case <-timeout:
+				t.Fatalf("no %v event", want)
+			}
+		}
+	}
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	if err := watcher.WaitConnected(waitCtx); err == nil {
+		t.Error("WaitConnected returned without connections")
+	}
+	waitCancel()
+
+	receiver, _ := NewRecvInstance(&NewRecvInstanceSettings{SourceToConnectTo: NewSource("LOOPBACK (watched)", "")})
+	if event := wait(SendConnected); event.Connections != 1 {
+		t.Errorf("connected with %d connections", event.Connections)
+	}
+	if err := watcher.WaitConnected(ctx); err != nil {
+		t.Error(err)
+	}
+
+	receiver.SetTally(true, false)
+	if event := wait(SendTallyChanged); !event.Tally.Program || event.Tally.Preview {
+		t.Errorf("tally is %+v", event.Tally)
+	}
+
+	receiver.Destroy()
+	wait(SendDisconnected)
+	if watcher.Connected() {
+		t.Error("connected after the receiver was destroyed")
+	}
+
+	cancel()
+	<-watcher.Done()
+}
+
+func TestSendWatcherUndrained(t *testing.T) {
+	useTestBackend(t, NewLoopbackBackend())
+
+	sender, err := NewSendInstance("undrained", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Destroy()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := NewSendWatcher(ctx, sender, nil)
+	defer func() {
+		cancel()
+		<-watcher.Done()
+	}()
+
+	// Wait for the watcher to see the connection state, never reading Events
+	waitConnected := func(want bool) {
+		t.Helper()
+		for timeout := time.After(time.Second); watcher.Connected() != want; {
+			select {
+			case <-timeout:
+				t.Fatalf("connected is not %v", want)
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+
+	// More changes than the channel holds
+	for i := 0; i < 10; i++ {
+		receiver, err := NewRecvInstance(&NewRecvInstanceSettings{SourceToConnectTo: NewSource("LOOPBACK (undrained)", "")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitConnected(true)
+		receiver.Destroy()
+		waitConnected(false)
+	}
+}
@@ -0,0 +1,106 @@
+package gondi
+
+import (
+	"net/url"
+	"strings"
+	"unsafe"
+)
+
+// Create a source from its full NDI name, like "MACHINE (Source name)", and its address. Either can be empty, the
+// SDK connects by name when there is one and uses the address otherwise.
+func NewSource(name string, address string) *Source {
+	return &Source{name, address}
+}
+
+// Name of the source, like "MACHINE (Source name)"
+func (s *Source) Name() string {
+	return s.name
+}
+
+// Address of the source, an ip:port or an URL depending on the NDI version of the sender
+func (s *Source) Address() string {
+	return s.address
+}
+
+// Set the name and address of the source object
+func (s *Source) Set(name string, address string) {
+	s.name = name
+	s.address = address
+}
+
+// Name of the machine running the source, the part of the name before the parenthesis
+func (s *Source) MachineName() string {
+	machine, _ := splitSourceName(s.name)
+	return machine
+}
+
+// Name of the source on its machine, the part of the name between the parenthesis
+func (s *Source) SourceName() string {
+	_, source := splitSourceName(s.name)
+	return source
+}
+
+// Address of the source as URL. Plain ip:port addresses are returned with the ndi scheme, nil is returned when there
+// is no address or it can't be parsed.
+func (s *Source) URL() *url.URL {
+	if s.address == "" {
+		return nil
+	}
+	address := s.address
+	if !strings.Contains(address, "://") {
+		address = "ndi://" + address
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil
+	}
+
+	return u
+}
+
+// Same as Name
+func (s *Source) String() string {
+	return s.name
+}
+
+// Split "MACHINE (Source name)" in its parts. The source name may contain parenthesis itself, so the name is split
+// at the first opening one and the last closing one. Names that don't follow the pattern are taken as machine name.
+func splitSourceName(name string) (machine string, source string) {
+	open := strings.Index(name, "(")
+	if open < 0 || !strings.HasSuffix(name, ")") {
+		return strings.TrimSpace(name), ""
+	}
+
+	return strings.TrimSpace(name[:open]), name[open+1 : len(name)-1]
+}
+
+// Convert to the C representation, pinned until p is released. Empty fields are passed as NULL, which is what the
+// SDK expects for a missing name or address.
+func (s *Source) c(p *pinned) *cSource {
+	if s == nil {
+		return nil
+	}
+	cs := &cSource{}
+	if s.name != "" {
+		cs.name = p.cString(s.name)
+	}
+	if s.address != "" {
+		cs.address = p.cString(s.address)
+	}
+	p.pin(unsafe.Pointer(cs))
+
+	return cs
+}
+
+// Copy a source owned by the SDK to Go memory
+func (cs *cSource) goSource() *Source {
+	s := &Source{}
+	if cs.name != nil {
+		s.name = goString(uintptr(unsafe.Pointer(cs.name)))
+	}
+	if cs.address != nil {
+		s.address = goString(uintptr(unsafe.Pointer(cs.address)))
+	}
+
+	return s
+}
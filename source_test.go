@@ -0,0 +1,48 @@
+package gondi
+
+import "testing"
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		name, address       string
+		machine, sourceName string
+		url                 string
+	}{
+		{"STUDIO-PC (Camera 1)", "192.168.1.10:5961", "STUDIO-PC", "Camera 1", "ndi://192.168.1.10:5961"},
+		{"STUDIO-PC (Remote (backup))", "", "STUDIO-PC", "Remote (backup)", ""},
+		{"no parenthesis", "http://10.0.0.1/stream", "no parenthesis", "", "http://10.0.0.1/stream"},
+	}
+	for _, tt := range tests {
+		s := NewSource(tt.name, tt.address)
+		if s.MachineName() != tt.machine || s.SourceName() != tt.sourceName {
+			t.Errorf("%q split in %q and %q", tt.name, s.MachineName(), s.SourceName())
+		}
+		u := s.URL()
+		if (u == nil && tt.url != "") || (u != nil && u.String() != tt.url) {
+			t.Errorf("%q has URL %v, want %q", tt.address, u, tt.url)
+		}
+		var p pinned
+		c := s.c(&p).goSource()
+		p.release()
+		if *c != *s {
+			t.Errorf("C round trip returned %+v, want %+v", c, s)
+		}
+	}
+}
+
+// Sources must stay usable after the finder that returned them is gone
+func TestSourceOutlivesFinder(t *testing.T) {
+	newLoopbackPair(t, "outlive")
+
+	finder, err := NewFindInstance(true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	finder.WaitForSources(1000)
+	sources := finder.GetCurrentSources()
+	finder.Destroy()
+
+	if len(sources) != 1 || sources[0].SourceName() != "outlive" || sources[0].MachineName() != loopbackMachineName {
+		t.Fatalf("sources are %v", sources)
+	}
+}
@@ -1,6 +1,9 @@
 package gondi
 
-import "math"
+import (
+	"math"
+	"sync"
+)
 
 type VideoFrameV2 struct {
 	// The resolution of this frame.
@@ -87,6 +90,30 @@ var (
 	// UYVY 4:2:2 buffer. Immediately following this in memory is a
 	// alpha channel buffer.
 	FourCCTypeUYVA FourCCType = [4]byte{'U', 'Y', 'V', 'A'}
+	// YCbCr color space using 4:2:2 in 16bpp.
+	// In memory this is a semi-planar format. This is identical to a 16bpp version of the NV16 format.
+	// The first buffer is a 16bpp luminance buffer.
+	// Immediately after this is an interleaved buffer of 16bpp Cb, Cr pairs.
+	FourCCTypeP216 FourCCType = [4]byte{'P', '2', '1', '6'}
+	// YCbCr color space with an alpha channel, using 4:2:2:4.
+	// In memory this is a semi-planar format.
+	// The first buffer is a 16bpp luminance buffer.
+	// Immediately after this is an interleaved buffer of 16bpp Cb, Cr pairs.
+	// Immediately after is a single buffer of 16bpp alpha channel.
+	FourCCTypePA16 FourCCType = [4]byte{'P', 'A', '1', '6'}
+	// Planar 8bit 4:2:0 video format.
+	// The first buffer is an 8bpp luminance buffer.
+	// Immediately following this is a 8bpp Cr buffer.
+	// Immediately following this is a 8bpp Cb buffer.
+	FourCCTypeYV12 FourCCType = [4]byte{'Y', 'V', '1', '2'}
+	// The first buffer is an 8bpp luminance buffer.
+	// Immediately following this is a 8bpp Cb buffer.
+	// Immediately following this is a 8bpp Cr buffer.
+	FourCCTypeI420 FourCCType = [4]byte{'I', '4', '2', '0'}
+	// Planar 8bit 4:2:0 video format.
+	// The first buffer is an 8bpp luminance buffer.
+	// Immediately following this is in interleaved buffer of 8bpp Cb, Cr pairs
+	FourCCTypeNV12 FourCCType = [4]byte{'N', 'V', '1', '2'}
 )
 
 const (
@@ -123,6 +150,7 @@ type AudioFrameV2 struct {
 	Timestamp int64
 }
 
+// Audio frame matching NDIlib_audio_frame_v3_t, which adds a FourCC describing the format of the data.
 type AudioFrameV3 struct {
 	//The sample-rate of this buffer.
 	SampleRate int32
@@ -136,10 +164,14 @@ type AudioFrameV3 struct {
 	// The timecode of this frame in 100-nanosecond intervals.
 	Timecode int64
 
-	// The audio data as int16 samples
+	// What FourCC describing the type of data for this frame.
+	FourCC FourCCAudioType
+
+	// The audio data, for FourCCAudioTypeFLTP these are planar float32 samples.
 	Data *byte
 
-	// The inter channel stride of the audio channels, in bytes.
+	// The inter channel stride of the audio channels, in bytes, for uncompressed FourCCs. For compressed FourCCs this
+	// is the size of the data in bytes instead, see DataSize and SetDataSize.
 	ChannelStride int32
 
 	// Per frame metadata for this frame. This is a NULL terminated UTF8 string that should be in XML format.
@@ -152,6 +184,33 @@ type AudioFrameV3 struct {
 	Timestamp int64
 }
 
+// Audio FourCC, describing the format of the data of an AudioFrameV3
+type FourCCAudioType [4]byte
+
+var (
+	// Planar 32bit floating point. Be sure to specify the channel stride.
+	FourCCAudioTypeFLTP FourCCAudioType = [4]byte{'F', 'L', 'T', 'p'}
+)
+
+// Interleaved 16bit audio as expected by NDIlib_util_send_send_audio_interleaved_16s
+type audioFrameInterleaved16s struct {
+	sampleRate     int32
+	numChannels    int32
+	numSamples     int32
+	timecode       int64
+	referenceLevel int32
+	data           *int16
+}
+
+// Interleaved 32bit floating point audio as expected by NDIlib_util_send_send_audio_interleaved_32f
+type audioFrameInterleaved32f struct {
+	sampleRate  int32
+	numChannels int32
+	numSamples  int32
+	timecode    int64
+	data        *float32
+}
+
 /* Borrowed from ndi-go/ndi.go */
 type RecvColorFormat int32
 
@@ -205,7 +264,15 @@ type Tally struct {
 	Preview bool
 }
 
+// Source is a NDI source, as found by a FindInstance or created with NewSource. It only holds Go memory, so it stays
+// valid after the finder that returned it is destroyed, and can be kept and compared freely.
 type Source struct {
+	name    string
+	address string
+}
+
+// NDIlib_source_t, the C representation of a Source
+type cSource struct {
 	name    *byte
 	address *byte
 }
@@ -245,7 +312,7 @@ type NewRecvInstanceSettings struct {
 }
 
 type recvCreateSettings struct {
-	sourceToConnectTo Source
+	sourceToConnectTo cSource
 	colorFormat       RecvColorFormat
 	bandwidth         RecvBandwidth
 	allowVideoFields  bool
@@ -290,26 +357,34 @@ type sendCreateSettings struct {
 
 // Sender instance struct
 type SendInstance struct {
-	ndiInstance    uintptr
-	createSettings *sendCreateSettings
+	ndiInstance uintptr
+
+	// Called on the next synchronizing event, or Destroy, to unpin the frame sent asynchronously and recycle its buffer
+	mu     sync.Mutex
+	synced func()
+
+	name   string
+	logger Logger
 }
 
 // Finder instance struct
 type FindInstance struct {
-	ndiInstance    uintptr
-	createSettings *findCreateSettings
+	ndiInstance uintptr
 }
 
 // Receiver instance struct
 type RecvInstance struct {
-	ndiInstance    uintptr
-	createSettings *recvCreateSettings
+	ndiInstance uintptr
+	ptz         *PTZ
+
+	// Frame synchronizers created on this receiver, destroyed together with it
+	mu         sync.Mutex
+	frameSyncs map[*FrameSync]struct{}
 }
 
 // ROuting instance struct
 type RoutingInstance struct {
-	ndiInstance    uintptr
-	createSettings *routingCreateSettings
-	name           string
-	groups         string
+	ndiInstance uintptr
+	name        string
+	groups      string
 }
@@ -0,0 +1,44 @@
+package gondi
+
+import (
+	"testing"
+	"unsafe"
+)
+
+// The offsets are those of the structs in Processing.NDI.structs.h and Processing.NDI.utilities.h on 64bit platforms
+func TestStructLayout(t *testing.T) {
+	var v2 AudioFrameV2
+	var v3 AudioFrameV3
+	var i16 audioFrameInterleaved16s
+	var i32 audioFrameInterleaved32f
+
+	tests := []struct {
+		name      string
+		got, want uintptr
+	}{
+		{"VideoFrameV2", unsafe.Sizeof(VideoFrameV2{}), 72},
+		{"MetadataFrame", unsafe.Sizeof(MetadataFrame{}), 24},
+		{"cSource", unsafe.Sizeof(cSource{}), 16},
+
+		{"AudioFrameV2.Timecode", unsafe.Offsetof(v2.Timecode), 16},
+		{"AudioFrameV2.Data", unsafe.Offsetof(v2.Data), 24},
+		{"AudioFrameV2", unsafe.Sizeof(v2), 56},
+
+		{"AudioFrameV3.Timecode", unsafe.Offsetof(v3.Timecode), 16},
+		{"AudioFrameV3.FourCC", unsafe.Offsetof(v3.FourCC), 24},
+		{"AudioFrameV3.Data", unsafe.Offsetof(v3.Data), 32},
+		{"AudioFrameV3.ChannelStride", unsafe.Offsetof(v3.ChannelStride), 40},
+		{"AudioFrameV3.Metadata", unsafe.Offsetof(v3.Metadata), 48},
+		{"AudioFrameV3.Timestamp", unsafe.Offsetof(v3.Timestamp), 56},
+		{"AudioFrameV3", unsafe.Sizeof(v3), 64},
+
+		{"audioFrameInterleaved16s.referenceLevel", unsafe.Offsetof(i16.referenceLevel), 24},
+		{"audioFrameInterleaved16s.data", unsafe.Offsetof(i16.data), 32},
+		{"audioFrameInterleaved32f.data", unsafe.Offsetof(i32.data), 24},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s is %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package gondi
+
+import (
+	"image/draw"
+	"unsafe"
+
+	"github.com/benitogf/gondi/convert"
+)
+
+// Get the line stride used when the frame does not set one, and the size of the frame data in bytes, including the
+// planes following the first one, like the alpha plane of UYVA frames.
+func videoFrameLayout(fourCC FourCCType, xres int32, yres int32, lineStride int32) (int32, int) {
+	stride := lineStride
+	planes := int32(0)
+	switch fourCC {
+	case FourCCTypeUYVY:
+		if stride == 0 {
+			stride = xres * 2
+		}
+	case FourCCTypeUYVA:
+		if stride == 0 {
+			stride = xres * 2
+		}
+		// The alpha plane has half the stride
+		planes = stride / 2 * yres
+	case FourCCTypeP216, FourCCTypePA16:
+		if stride == 0 {
+			stride = xres * 2
+		}
+		// The CbCr plane, and the alpha plane, have the stride of the Y plane
+		planes = stride * yres
+		if fourCC == FourCCTypePA16 {
+			planes *= 2
+		}
+	case FourCCTypeNV12:
+		if stride == 0 {
+			stride = xres
+		}
+		planes = stride * ((yres + 1) / 2)
+	case FourCCTypeI420, FourCCTypeYV12:
+		if stride == 0 {
+			stride = xres
+		}
+		planes = stride / 2 * ((yres + 1) / 2) * 2
+	default:
+		if stride == 0 {
+			stride = xres * 4
+		}
+	}
+
+	return stride, int(stride*yres + planes)
+}
+
+// Copy the visible part of a video frame, including the planes following the first one, and return it with its line stride.
+func copyVideoData(frame *VideoFrameV2) ([]byte, int32) {
+	if frame.Data == nil || frame.Xres <= 0 || frame.Yres <= 0 {
+		return nil, frame.LineStride
+	}
+
+	stride, size := videoFrameLayout(frame.FourCC, frame.Xres, frame.Yres, frame.LineStride)
+	data := make([]byte, size)
+	copy(data, unsafe.Slice(frame.Data, len(data)))
+
+	return data, stride
+}
+
+// Get the frame for the convert package, using the data of the frame without copying it
+func (p *VideoFrameV2) ConvertFrame() *convert.Frame {
+	frame := &convert.Frame{
+		FourCC: convert.FourCC(p.FourCC),
+		Width:  int(p.Xres),
+		Height: int(p.Yres),
+		Stride: int(p.LineStride),
+	}
+	if p.Data != nil && p.Xres > 0 && p.Yres > 0 {
+		_, size := videoFrameLayout(p.FourCC, p.Xres, p.Yres, p.LineStride)
+		frame.Data = unsafe.Slice(p.Data, size)
+	}
+
+	return frame
+}
+
+// Get an image using the data of the frame without copying it, so image/draw, image/jpeg and other Go imaging code
+// work on frames directly. RGBA and RGBX frames are a *image.NRGBA, BGRA and BGRX a *convert.BGRA and UYVY a
+// *convert.UYVY, see convert.Frame.View. Convert the other FourCCs with the convert package.
+//
+// The image is only valid as long as the frame data is, until the frame is freed for a received frame.
+func (p *VideoFrameV2) Image() (draw.Image, error) {
+	return p.ConvertFrame().View(nil)
+}
@@ -0,0 +1,41 @@
+package gondi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"testing"
+)
+
+func TestVideoFrameImage(t *testing.T) {
+	// Large enough for the NV12 planes as well
+	data := make([]byte, 64*4*16*2)
+	frame := NewVideoFrameV2()
+	frame.FourCC = FourCCTypeBGRA
+	frame.Xres, frame.Yres, frame.LineStride = 48, 16, 64*4
+	frame.Data = &data[0]
+
+	img, err := frame.Image()
+	if err != nil {
+		t.Fatal(err)
+	}
+	draw.Draw(img, image.Rect(0, 0, 48, 16), &image.Uniform{color.NRGBA{10, 20, 30, 255}}, image.Point{}, draw.Src)
+
+	// The picture is drawn in the frame data, in BGRA order and with its stride
+	if want := []byte{30, 20, 10, 255}; !bytes.Equal(data[15*64*4+47*4:15*64*4+48*4], want) {
+		t.Errorf("last pixel is %v, want %v", data[15*64*4+47*4:15*64*4+48*4], want)
+	}
+	if data[48*4] != 0 {
+		t.Error("padding written")
+	}
+	if err := jpeg.Encode(&bytes.Buffer{}, img, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	frame.FourCC = FourCCTypeNV12
+	if _, err := frame.Image(); err == nil {
+		t.Error("NV12 frame viewed as an image")
+	}
+}
@@ -0,0 +1,155 @@
+package gondi
+
+import (
+	"errors"
+	"image/draw"
+	"sync"
+)
+
+// A buffer of a VideoRing. Write the picture to Data, the fields of Frame may be changed as long as the picture fits.
+type VideoBuffer struct {
+	Frame VideoFrameV2
+	Data  []byte
+
+	ring *VideoRing
+
+	// Handed out by Next, and sent but still referenced by the NDI library
+	inUse   bool
+	sending bool
+}
+
+// Get the data of the buffer as an image to draw the frame on, see VideoFrameV2.Image
+func (b *VideoBuffer) Image() (draw.Image, error) {
+	frame := b.Frame.ConvertFrame()
+	frame.Data = b.Data
+
+	return frame.View(nil)
+}
+
+// VideoRing sends video asynchronously from a ring of buffers it owns. Next hands out a buffer that the NDI library
+// no longer references, and Submit sends it asynchronously. A buffer is only handed out again after a synchronizing
+// event on the sender, which for a ring of at least two buffers is the Submit of the following frame.
+//
+//	ring, _ := gondi.NewVideoRing(sender, 3, gondi.FourCCTypeBGRA, 1920, 1080, 30000, 1001)
+//	for {
+//		buffer := ring.Next()
+//		draw(buffer.Data)
+//		ring.Submit(buffer)
+//	}
+//	ring.Close()
+type VideoRing struct {
+	sender  *SendInstance
+	buffers []*VideoBuffer
+	closed  bool
+
+	mu   sync.Mutex
+	free *sync.Cond
+}
+
+// Allocate a ring of size buffers for frames of the given format, size must be at least 2.
+func NewVideoRing(sender *SendInstance, size int, fourCC FourCCType, xres int32, yres int32, frameRateN int32, frameRateD int32) (*VideoRing, error) {
+	if size < 2 {
+		return nil, errors.New("a video ring needs at least 2 buffers")
+	}
+	if xres <= 0 || yres <= 0 {
+		return nil, errors.New("invalid video resolution")
+	}
+
+	ring := &VideoRing{sender: sender}
+	ring.free = sync.NewCond(&ring.mu)
+	stride, length := videoFrameLayout(fourCC, xres, yres, 0)
+	for i := 0; i < size; i++ {
+		buffer := &VideoBuffer{Frame: *NewVideoFrameV2(), Data: make([]byte, length), ring: ring}
+		buffer.Frame.FourCC = fourCC
+		buffer.Frame.Xres = xres
+		buffer.Frame.Yres = yres
+		buffer.Frame.FrameRateN = frameRateN
+		buffer.Frame.FrameRateD = frameRateD
+		buffer.Frame.LineStride = stride
+		ring.buffers = append(ring.buffers, buffer)
+	}
+
+	return ring, nil
+}
+
+// Get a buffer to write the next frame to. It blocks while every buffer is either being written or still referenced
+// by the NDI library, which only happens when holding more than size-1 buffers at once. Returns nil once closed.
+func (r *VideoRing) Next() *VideoBuffer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for !r.closed {
+		for _, buffer := range r.buffers {
+			if !buffer.inUse && !buffer.sending {
+				buffer.inUse = true
+				return buffer
+			}
+		}
+		r.free.Wait()
+	}
+
+	return nil
+}
+
+// Take back a buffer handed out by Next, it fails for buffers of another ring, already given back, or when the ring is
+// closed.
+func (r *VideoRing) take(buffer *VideoBuffer, sending bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if buffer == nil || buffer.ring != r || !buffer.inUse {
+		return errors.New("unable to take back a buffer not handed out by this ring")
+	}
+	if r.closed {
+		return errors.New("video ring closed")
+	}
+	buffer.inUse, buffer.sending = false, sending
+
+	return nil
+}
+
+// Send the buffer asynchronously. The buffer must not be touched anymore, it is recycled by the ring once the NDI
+// library is done with it.
+func (r *VideoRing) Submit(buffer *VideoBuffer) error {
+	if err := r.take(buffer, true); err != nil {
+		return err
+	}
+
+	frame := buffer.Frame
+	frame.Data = nil
+	if len(buffer.Data) > 0 {
+		frame.Data = &buffer.Data[0]
+	}
+	r.sender.sendVideoAsync(&frame, func() { r.recycle(buffer) })
+
+	return nil
+}
+
+// Give back a buffer from Next without sending it
+func (r *VideoRing) Discard(buffer *VideoBuffer) error {
+	if err := r.take(buffer, false); err != nil {
+		return err
+	}
+	r.free.Broadcast()
+
+	return nil
+}
+
+func (r *VideoRing) recycle(buffer *VideoBuffer) {
+	r.mu.Lock()
+	buffer.sending = false
+	r.mu.Unlock()
+	r.free.Broadcast()
+}
+
+// Wait until the NDI library is done with the last frame sent and drop the buffers, before destroying the sender. Next
+// returns nil afterwards, and buffers still held can't be submitted anymore.
+func (r *VideoRing) Close() {
+	r.sender.FlushVideoAsync()
+
+	r.mu.Lock()
+	r.closed = true
+	r.buffers = nil
+	r.mu.Unlock()
+	r.free.Broadcast()
+}
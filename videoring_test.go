@@ -0,0 +1,82 @@
+package gondi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVideoRing(t *testing.T) {
+	sender, receiver := newLoopbackPair(t, "ring")
+
+	ring, err := NewVideoRing(sender, 2, FourCCTypeUYVY, 4, 2, 30000, 1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := ring.Next()
+	if len(first.Data) != 4*2*2 || first.Frame.LineStride != 8 {
+		t.Fatalf("buffer holds %d bytes with stride %d", len(first.Data), first.Frame.LineStride)
+	}
+	first.Data[0] = 1
+	ring.Submit(first)
+
+	second := ring.Next()
+	if second == first {
+		t.Fatal("the buffer in flight was handed out again")
+	}
+
+	// The first buffer is still referenced and the second is held, so there is nothing to hand out
+	next := make(chan *VideoBuffer)
+	go func() { next <- ring.Next() }()
+	select {
+	case <-next:
+		t.Fatal("Next returned while every buffer was in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	second.Data[0] = 2
+	if err := ring.Submit(second); err != nil {
+		t.Fatal(err)
+	}
+	if err := ring.Submit(second); err == nil {
+		t.Error("a buffer was submitted twice")
+	}
+	select {
+	case buffer := <-next:
+		if buffer != first {
+			t.Error("the first buffer was not recycled after the second was sent")
+		}
+		ring.Discard(buffer)
+	case <-time.After(time.Second):
+		t.Fatal("Next did not return after a synchronizing event")
+	}
+
+	for _, want := range []byte{1, 2} {
+		frame := NewVideoFrameV2()
+		if ft := receiver.CaptureV2(frame, nil, nil, 1000); ft != FrameTypeVideo {
+			t.Fatalf("capture returned %d, want video", ft)
+		}
+		if *frame.Data != want {
+			t.Errorf("received frame starting with %d, want %d", *frame.Data, want)
+		}
+		receiver.FreeVideoV2(frame)
+	}
+
+	other, _ := NewVideoRing(sender, 2, FourCCTypeUYVY, 4, 2, 30000, 1001)
+	if err := ring.Submit(other.Next()); err == nil {
+		t.Error("a buffer of another ring was submitted")
+	}
+
+	held := ring.Next()
+	ring.Close()
+	if err := ring.Submit(held); err == nil {
+		t.Error("a buffer was submitted after Close")
+	}
+	if ring.Next() != nil {
+		t.Error("Next returned a buffer after Close")
+	}
+
+	if _, err := NewVideoRing(sender, 1, FourCCTypeUYVY, 4, 2, 30000, 1001); err == nil {
+		t.Error("a ring of one buffer was created")
+	}
+}
@@ -0,0 +1,168 @@
+package gondi
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// How long the watcher waits for source changes before checking if its context is done
+const watcherPollMs = 100
+
+// Kind of change reported by a Watcher
+type SourceEventType int
+
+const (
+	// A source appeared on the network
+	SourceAdded SourceEventType = iota
+	// A source is no longer on the network
+	SourceRemoved
+	// A source with the same name is now reachable at another address
+	SourceAddressChanged
+)
+
+func (t SourceEventType) String() string {
+	switch t {
+	case SourceAdded:
+		return "added"
+	case SourceRemoved:
+		return "removed"
+	case SourceAddressChanged:
+		return "address changed"
+	}
+
+	return "unknown"
+}
+
+// A change in the sources seen by a Watcher
+type SourceEvent struct {
+	Type SourceEventType
+
+	// The source as it is now, or as it was last seen for SourceRemoved
+	Source *Source
+
+	// The address the source had before, for SourceAddressChanged
+	PreviousAddress string
+}
+
+// Watcher follows the sources of a FindInstance in a goroutine, and reports every source that is added, removed or
+// changes address. Sources are identified by their name.
+//
+// Events are passed to the callback given to NewWatcher, or on the Events channel when the callback is nil. Events
+// that don't fit in the channel are dropped, Sources stays current even when nobody reads it. The watcher stops when its context is done, after which the Events channel is closed. The finder must not be destroyed
+// before the watcher stopped, see Done.
+type Watcher struct {
+	finder   *FindInstance
+	callback func(SourceEvent)
+	events   chan SourceEvent
+	done     chan struct{}
+
+	mu      sync.Mutex
+	sources map[string]*Source
+}
+
+// Start watching the sources of a finder until the context is done. The callback, if not nil, is called from the
+// goroutine of the watcher, so it should return quickly.
+func NewWatcher(ctx context.Context, finder *FindInstance, callback func(SourceEvent)) *Watcher {
+	w := &Watcher{
+		finder:   finder,
+		callback: callback,
+		events:   make(chan SourceEvent, 64),
+		done:     make(chan struct{}),
+		sources:  map[string]*Source{},
+	}
+	go w.run(ctx)
+
+	return w
+}
+
+// Channel the events are sent on when the watcher has no callback, new events are dropped while it is full. It is
+// closed when the watcher stops.
+func (w *Watcher) Events() <-chan SourceEvent {
+	return w.events
+}
+
+// Closed once the watcher stopped and does not use the finder anymore
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// Get the sources currently known to the watcher, sorted by name
+func (w *Watcher) Sources() []*Source {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sources := make([]*Source, 0, len(w.sources))
+	for _, source := range w.sources {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name() < sources[j].Name() })
+
+	return sources
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+	defer close(w.events)
+
+	// The finder may already know sources, so the first list is taken without waiting for a change
+	changed := true
+	for ctx.Err() == nil {
+		if changed {
+			w.mu.Lock()
+			events := diffSources(w.sources, w.finder.GetCurrentSources())
+			w.mu.Unlock()
+
+			for _, event := range events {
+				w.emit(event)
+			}
+		}
+		changed = w.finder.WaitForSources(watcherPollMs)
+	}
+}
+
+func (w *Watcher) emit(event SourceEvent) {
+	if w.callback != nil {
+		w.callback(event)
+		return
+	}
+
+	select {
+	case w.events <- event:
+	default:
+	}
+}
+
+// Update known to the current list of sources, returning what changed. Removals come first, then additions and
+// address changes, each sorted by name.
+func diffSources(known map[string]*Source, current []*Source) []SourceEvent {
+	events := []SourceEvent{}
+	seen := map[string]bool{}
+	for _, source := range current {
+		seen[source.Name()] = true
+	}
+
+	for name, source := range known {
+		if !seen[name] {
+			events = append(events, SourceEvent{Type: SourceRemoved, Source: source})
+			delete(known, name)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Source.Name() < events[j].Source.Name() })
+
+	removed := len(events)
+	for _, source := range current {
+		previous, ok := known[source.Name()]
+		switch {
+		case !ok:
+			events = append(events, SourceEvent{Type: SourceAdded, Source: source})
+		case previous.Address() != source.Address():
+			events = append(events, SourceEvent{Type: SourceAddressChanged, Source: source, PreviousAddress: previous.Address()})
+		}
+		known[source.Name()] = source
+	}
+	changes := events[removed:]
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Source.Name() < changes[j].Source.Name() })
+
+	return events
+}
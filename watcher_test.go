@@ -0,0 +1,132 @@
+package gondi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDiffSources(t *testing.T) {
+	known := map[string]*Source{}
+	events := diffSources(known, []*Source{NewSource("A (b)", "1:1"), NewSource("A (a)", "1:2")})
+	if len(events) != 2 || events[0].Type != SourceAdded || events[0].Source.Name() != "A (a)" {
+		t.Fatalf("first diff is %+v", events)
+	}
+
+	events = diffSources(known, []*Source{NewSource("A (b)", "1:3"), NewSource("A (c)", "1:4")})
+	want := []SourceEvent{
+		{Type: SourceRemoved, Source: NewSource("A (a)", "1:2")},
+		{Type: SourceAddressChanged, Source: NewSource("A (b)", "1:3"), PreviousAddress: "1:1"},
+		{Type: SourceAdded, Source: NewSource("A (c)", "1:4")},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("second diff is %+v", events)
+	}
+	for i := range want {
+		if events[i].Type != want[i].Type || *events[i].Source != *want[i].Source || events[i].PreviousAddress != want[i].PreviousAddress {
+			t.Errorf("event %d is %+v, want %+v", i, events[i], want[i])
+		}
+	}
+
+	if events := diffSources(known, []*Source{NewSource("A (b)", "1:3"), NewSource("A (c)", "1:4")}); len(events) != 0 {
+		t.Errorf("unchanged sources reported %+v", events)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	useTestBackend(t, NewLoopbackBackend())
+
+	finder, err := NewFindInstance(true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer finder.Destroy()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := NewWatcher(ctx, finder, nil)
+
+	next := func() SourceEvent {
+		t.Helper()
+		select {
+		case event := <-watcher.Events():
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no source event")
+		}
+		return SourceEvent{}
+	}
+
+	sender, err := NewSendInstance("watched", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := next(); event.Type != SourceAdded || event.Source.SourceName() != "watched" {
+		t.Errorf("got %v event for %s, want watched added", event.Type, event.Source)
+	}
+	if sources := watcher.Sources(); len(sources) != 1 {
+		t.Errorf("watcher knows %v", sources)
+	}
+
+	sender.Destroy()
+	if event := next(); event.Type != SourceRemoved || event.Source.SourceName() != "watched" {
+		t.Errorf("got %v event for %s, want watched removed", event.Type, event.Source)
+	}
+
+	cancel()
+	select {
+	case <-watcher.Done():
+	case <-time.After(time.Second):
+		t.Fatal("watcher did not stop")
+	}
+	if _, ok := <-watcher.Events(); ok {
+		t.Error("events channel not closed")
+	}
+}
+
+func TestWatcherUndrained(t *testing.T) {
+	useTestBackend(t, NewLoopbackBackend())
+
+	finder, err := NewFindInstance(true, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer finder.Destroy()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watcher := NewWatcher(ctx, finder, nil)
+	defer func() {
+		cancel()
+		<-watcher.Done()
+	}()
+
+	// Wait for the watcher to know the number of sources, never reading Events
+	waitSources := func(want int) {
+		t.Helper()
+		for timeout := time.After(time.Second); len(watcher.Sources()) != want; {
+			select {
+			case <-timeout:
+				t.Fatalf("watcher knows %d sources, want %d", len(watcher.Sources()), want)
+			case <-time.After(time.Millisecond):
+			}
+		}
+	}
+
+	// More sources than the channel holds events
+	for i := 0; i < 70; i++ {
+		sender, err := NewSendInstance(fmt.Sprintf("undrained %d", i), "", false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sender.Destroy()
+	}
+	waitSources(70)
+
+	sender, err := NewSendInstance("undrained last", "", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitSources(71)
+	sender.Destroy()
+	waitSources(70)
+}
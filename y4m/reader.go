@@ -0,0 +1,180 @@
+package y4m
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/convert"
+)
+
+// Longest header line accepted, real headers are well under 100 bytes
+const maxHeaderLength = 4096
+
+// Options for NewReader
+type ReaderOption func(*Reader)
+
+// Return frames in this FourCC instead of the one of the stream, see ReadFrame
+func WithFourCC(fourCC gondi.FourCCType) ReaderOption {
+	return func(r *Reader) {
+		r.fourCC = fourCC
+	}
+}
+
+// Convert frames to RGB with this matrix and range, when WithFourCC asks for RGB. By default the range is the one of
+// the header.
+func WithReadConvert(opts convert.Options) ReaderOption {
+	return func(r *Reader) {
+		r.convert = &opts
+	}
+}
+
+// Reader reads the frames of a YUV4MPEG2 stream
+type Reader struct {
+	r       *bufio.Reader
+	header  *Header
+	fourCC  gondi.FourCCType
+	convert *convert.Options
+	planes  []byte
+}
+
+// Create a reader, reading the header of the stream
+func NewReader(r io.Reader, opts ...ReaderOption) (*Reader, error) {
+	reader := &Reader{r: bufio.NewReader(r)}
+	for _, opt := range opts {
+		opt(reader)
+	}
+
+	line, err := reader.readLine()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read y4m header: %w", err)
+	}
+	if reader.header, err = parseHeader(line); err != nil {
+		return nil, err
+	}
+	reader.planes = make([]byte, reader.header.frameSize())
+	if reader.convert == nil {
+		reader.convert = &convert.Options{Range: reader.header.Range}
+	}
+
+	return reader, nil
+}
+
+// The header of the stream
+func (r *Reader) Header() *Header {
+	return r.header
+}
+
+// Read a line, without its newline
+func (r *Reader) readLine() (string, error) {
+	var line strings.Builder
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return line.String(), err
+		}
+		if b == '\n' {
+			return line.String(), nil
+		}
+		if line.Len() >= maxHeaderLength {
+			return "", errors.New("line too long")
+		}
+		line.WriteByte(b)
+	}
+}
+
+// Read the next frame, or io.EOF at the end of the stream. Frames are I420 for 4:2:0 streams and UYVY for the others,
+// unless WithFourCC asks for another FourCC. Each frame has its own data, so frames can be kept and sent
+// asynchronously.
+func (r *Reader) ReadFrame() (*gondi.VideoFrameV2, error) {
+	line, err := r.readLine()
+	if err == io.EOF && line == "" {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read y4m frame: %w", err)
+	}
+	if !strings.HasPrefix(line, frameMagic) {
+		return nil, errors.New("unable to read y4m frame: FRAME missing")
+	}
+	if _, err := io.ReadFull(r.r, r.planes); err != nil {
+		return nil, fmt.Errorf("unable to read y4m frame: %w", io.ErrUnexpectedEOF)
+	}
+
+	frame := r.nativeFrame()
+	if r.fourCC != (gondi.FourCCType{}) && convert.FourCC(r.fourCC) != frame.FourCC {
+		converted, err := convert.NewFrame(convert.FourCC(r.fourCC), frame.Width, frame.Height)
+		if err != nil {
+			return nil, err
+		}
+		if err := convert.Convert(converted, frame, r.convert); err != nil {
+			return nil, err
+		}
+		frame = converted
+	}
+
+	h := r.header
+	vf := gondi.NewVideoFrameV2()
+	vf.FourCC = gondi.FourCCType(frame.FourCC)
+	vf.Xres, vf.Yres = int32(h.Width), int32(h.Height)
+	vf.LineStride = int32(frame.Stride)
+	vf.FrameRateN, vf.FrameRateD = h.FrameRateN, h.FrameRateD
+	vf.FrameFormatType = h.FrameFormat
+	vf.PictureAspectRatio = h.PictureAspectRatio
+	vf.Data = &frame.Data[0]
+
+	return vf, nil
+}
+
+// Get the planes just read as an I420 or UYVY frame
+func (r *Reader) nativeFrame() *convert.Frame {
+	h := r.header
+	width, height := h.Width, h.Height
+	cw, ch := h.Chroma.planeSize(width, height)
+	y, cb, cr := r.planes[:width*height], r.planes[width*height:width*height+cw*ch], r.planes[width*height+cw*ch:]
+
+	if h.Chroma == Chroma420 {
+		frame, _ := convert.NewFrame(convert.FourCCI420, width, height)
+		chromaStride := frame.Stride / 2
+		for line := 0; line < height; line++ {
+			copy(frame.Data[line*frame.Stride:], y[line*width:(line+1)*width])
+		}
+		for plane, data := range [][]byte{cb, cr} {
+			start := frame.Stride*height + plane*chromaStride*ch
+			for line := 0; line < ch; line++ {
+				copy(frame.Data[start+line*chromaStride:], data[line*cw:(line+1)*cw])
+			}
+		}
+		return frame
+	}
+
+	// 4:4:4 chroma is averaged over pairs of pixels, mono has none
+	frame, _ := convert.NewFrame(convert.FourCCUYVY, width, height)
+	for line := 0; line < height; line++ {
+		row := frame.Data[line*frame.Stride:]
+		for x := 0; x < width; x++ {
+			row[x*2+1] = y[line*width+x]
+		}
+		for x := 0; x < (width+1)/2; x++ {
+			u, v := byte(128), byte(128)
+			switch h.Chroma {
+			case Chroma422:
+				u, v = cb[line*cw+x], cr[line*cw+x]
+			case Chroma444:
+				right := min(x*2+1, width-1)
+				u = byte((int(cb[line*cw+x*2]) + int(cb[line*cw+right]) + 1) / 2)
+				v = byte((int(cr[line*cw+x*2]) + int(cr[line*cw+right]) + 1) / 2)
+			}
+			row[x*4], row[x*4+2] = u, v
+		}
+		// The last pixel of an odd line has no neighbor, repeat its luma
+		if width%2 == 1 {
+			row[width*2+1] = row[width*2-1]
+		}
+	}
+
+	return frame
+}
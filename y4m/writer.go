@@ -0,0 +1,193 @@
+package y4m
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/convert"
+)
+
+// Options for NewWriter
+type WriterOption func(*Writer)
+
+// Write the stream with this chroma subsampling, Chroma420 or Chroma422. Chroma420 by default, interleaved frames
+// need Chroma422 as 4:2:0 would mix the chroma of both fields.
+func WithChroma(chroma Chroma) WriterOption {
+	return func(w *Writer) {
+		w.chroma = chroma
+	}
+}
+
+// Convert frames that are not YCbCr with this matrix and range. The range is written in the header, so YCbCr frames
+// copied as they are should have the same range.
+func WithConvert(opts convert.Options) WriterOption {
+	return func(w *Writer) {
+		w.convert = opts
+	}
+}
+
+// Writer writes frames as a YUV4MPEG2 stream. The header is written with the first frame, taking the size, frame
+// rate, interlacing and aspect ratio from it, and every following frame must have the same size.
+//
+// UYVY frames written as 4:2:2 and I420 frames written as 4:2:0 are copied as they are, other frames are converted.
+// Alpha is dropped.
+type Writer struct {
+	w       *bufio.Writer
+	chroma  Chroma
+	convert convert.Options
+	header  *Header
+
+	// Frame the converted frames are written to, reused
+	converted *convert.Frame
+}
+
+// Create a writer, each frame is flushed to w once written
+func NewWriter(w io.Writer, opts ...WriterOption) *Writer {
+	writer := &Writer{w: bufio.NewWriter(w)}
+	for _, opt := range opts {
+		opt(writer)
+	}
+
+	return writer
+}
+
+// The header written, nil before the first frame
+func (w *Writer) Header() *Header {
+	return w.header
+}
+
+// Write a frame, and the header before the first one
+func (w *Writer) WriteFrame(vf *gondi.VideoFrameV2) error {
+	if w.chroma != Chroma420 && w.chroma != Chroma422 {
+		return fmt.Errorf("unable to write y4m with %s chroma", w.chroma)
+	}
+	if w.header == nil {
+		if err := w.writeHeader(vf); err != nil {
+			return err
+		}
+	}
+	if int(vf.Xres) != w.header.Width || int(vf.Yres) != w.header.Height {
+		return fmt.Errorf("unable to write a %dx%d frame in a %dx%d y4m stream", vf.Xres, vf.Yres, w.header.Width, w.header.Height)
+	}
+
+	frame, err := w.frame(vf)
+	if err != nil {
+		return err
+	}
+
+	// Errors stick to the buffered writer, Flush returns them
+	w.w.WriteString(frameMagic + "\n")
+	if frame.FourCC == convert.FourCCUYVY {
+		w.writeUYVY(frame)
+	} else {
+		w.writeI420(frame)
+	}
+
+	return w.w.Flush()
+}
+
+func (w *Writer) writeHeader(vf *gondi.VideoFrameV2) error {
+	if vf.FrameRateN <= 0 || vf.FrameRateD <= 0 {
+		return errors.New("unable to write y4m without frame rate")
+	}
+	if vf.FrameFormatType != gondi.FrameFormatProgressive && vf.FrameFormatType != gondi.FrameFormatInterleaved {
+		return errors.New("unable to write single fields to y4m")
+	}
+	if vf.FrameFormatType == gondi.FrameFormatInterleaved && w.chroma == Chroma420 {
+		return errors.New("unable to write interleaved frames to 4:2:0 y4m, use WithChroma(Chroma422)")
+	}
+
+	header := &Header{
+		Width:              int(vf.Xres),
+		Height:             int(vf.Yres),
+		FrameRateN:         vf.FrameRateN,
+		FrameRateD:         vf.FrameRateD,
+		FrameFormat:        vf.FrameFormatType,
+		PictureAspectRatio: vf.PictureAspectRatio,
+		Chroma:             w.chroma,
+		Range:              w.convert.Range,
+	}
+	if header.Width <= 0 || header.Height <= 0 {
+		return errors.New("unable to write an empty frame to y4m")
+	}
+	if _, err := w.w.WriteString(header.String()); err != nil {
+		return err
+	}
+	w.header = header
+
+	return nil
+}
+
+// Get the frame in the FourCC of the chroma, converting it when needed
+func (w *Writer) frame(vf *gondi.VideoFrameV2) (*convert.Frame, error) {
+	fourCC := convert.FourCCI420
+	if w.chroma == Chroma422 {
+		fourCC = convert.FourCCUYVY
+	}
+
+	src := vf.ConvertFrame()
+	if src.Data == nil {
+		return nil, errors.New("unable to write a frame without data to y4m")
+	}
+	if src.FourCC == fourCC {
+		if src.Stride == 0 {
+			src.Stride = convert.DefaultStride(fourCC, src.Width)
+		}
+		return src, nil
+	}
+
+	if w.converted == nil {
+		converted, err := convert.NewFrame(fourCC, src.Width, src.Height)
+		if err != nil {
+			return nil, err
+		}
+		w.converted = converted
+	}
+	if err := convert.Convert(w.converted, src, &w.convert); err != nil {
+		return nil, err
+	}
+
+	return w.converted, nil
+}
+
+// Write the planes of a UYVY frame
+func (w *Writer) writeUYVY(frame *convert.Frame) {
+	width, height := frame.Width, frame.Height
+	cw := (width + 1) / 2
+	line := make([]byte, width)
+	for y := 0; y < height; y++ {
+		row := frame.Data[y*frame.Stride:]
+		for x := 0; x < width; x++ {
+			line[x] = row[x*2+1]
+		}
+		w.w.Write(line)
+	}
+	for _, offset := range []int{0, 2} {
+		for y := 0; y < height; y++ {
+			row := frame.Data[y*frame.Stride:]
+			for x := 0; x < cw; x++ {
+				line[x] = row[x*4+offset]
+			}
+			w.w.Write(line[:cw])
+		}
+	}
+}
+
+// Write the planes of an I420 frame, whose chroma lines are half the stride of the luma ones
+func (w *Writer) writeI420(frame *convert.Frame) {
+	width, height := frame.Width, frame.Height
+	cw, ch := (width+1)/2, (height+1)/2
+	for y := 0; y < height; y++ {
+		w.w.Write(frame.Data[y*frame.Stride : y*frame.Stride+width])
+	}
+	chromaStride := frame.Stride / 2
+	for plane := 0; plane < 2; plane++ {
+		start := frame.Stride*height + plane*chromaStride*ch
+		for y := 0; y < ch; y++ {
+			w.w.Write(frame.Data[start+y*chromaStride : start+y*chromaStride+cw])
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Package y4m writes NDI video frames as YUV4MPEG2 streams and reads them back, in pure Go, so frames can be piped
+// to and from encoders and other tools:
+//
+//	ffmpeg -i in.y4m ...    (frames written by a Writer to stdout)
+//	ffmpeg ... -f yuv4mpegpipe - | sender   (frames read by a Reader from stdin)
+//
+// Only 8 bit streams are supported.
+package y4m
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/convert"
+)
+
+// The first bytes of a stream, and of each frame
+const (
+	streamMagic = "YUV4MPEG2"
+	frameMagic  = "FRAME"
+)
+
+// Largest width or height read, so a bad header can't make the reader allocate gigabytes
+const maxDimension = 16384
+
+// Chroma subsampling of a stream
+type Chroma int
+
+const (
+	// Chroma at half the resolution in both directions, the default of YUV4MPEG2 and what most encoders expect
+	Chroma420 Chroma = iota
+	// Chroma at half the horizontal resolution, like UYVY frames
+	Chroma422
+	// Chroma at full resolution, only read
+	Chroma444
+	// Luma only, only read
+	ChromaMono
+)
+
+// The C parameter of the chroma
+func (c Chroma) String() string {
+	switch c {
+	case Chroma420:
+		return "420jpeg"
+	case Chroma422:
+		return "422"
+	case Chroma444:
+		return "444"
+	case ChromaMono:
+		return "mono"
+	}
+
+	return "unknown"
+}
+
+// Get the size of the chroma planes for a picture
+func (c Chroma) planeSize(width int, height int) (int, int) {
+	switch c {
+	case Chroma420:
+		return (width + 1) / 2, (height + 1) / 2
+	case Chroma422:
+		return (width + 1) / 2, height
+	case Chroma444:
+		return width, height
+	}
+
+	return 0, 0
+}
+
+// Header of a stream, every frame has the same size
+type Header struct {
+	Width  int
+	Height int
+
+	FrameRateN int32
+	FrameRateD int32
+
+	// FrameFormatProgressive or FrameFormatInterleaved, both top and bottom field first streams are interleaved
+	FrameFormat gondi.FrameFormat
+
+	// Width over height of the picture, 0 when the stream doesn't tell
+	PictureAspectRatio float32
+
+	Chroma Chroma
+
+	// Range of the YCbCr codes, the XCOLORRANGE parameter. Streams without it are video range.
+	Range convert.Range
+}
+
+// Get the size of a frame in bytes
+func (h *Header) frameSize() int {
+	cw, ch := h.Chroma.planeSize(h.Width, h.Height)
+	return h.Width*h.Height + 2*cw*ch
+}
+
+func (h *Header) String() string {
+	interlace := "p"
+	if h.FrameFormat == gondi.FrameFormatInterleaved {
+		interlace = "t"
+	}
+	aspect := "0:0"
+	if h.PictureAspectRatio > 0 {
+		n, d := rational(float64(h.PictureAspectRatio) * float64(h.Height) / float64(h.Width))
+		aspect = fmt.Sprintf("%d:%d", n, d)
+	}
+
+	colorRange := "LIMITED"
+	if h.Range == convert.FullRange {
+		colorRange = "FULL"
+	}
+
+	return fmt.Sprintf("%s W%d H%d F%d:%d I%s A%s C%s XCOLORRANGE=%s\n",
+		streamMagic, h.Width, h.Height, h.FrameRateN, h.FrameRateD, interlace, aspect, h.Chroma, colorRange)
+}
+
+// Parse the header line of a stream, without its newline
+func parseHeader(line string) (*Header, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || fields[0] != streamMagic {
+		return nil, errors.New("unable to read y4m: not a YUV4MPEG2 stream")
+	}
+
+	h := &Header{FrameFormat: gondi.FrameFormatProgressive}
+	pixelN, pixelD := 0, 0
+	for _, field := range fields[1:] {
+		value := field[1:]
+		var err error
+		switch field[0] {
+		case 'W':
+			h.Width, err = strconv.Atoi(value)
+		case 'H':
+			h.Height, err = strconv.Atoi(value)
+		case 'F':
+			var n, d int
+			n, d, err = parseRatio(value)
+			if err == nil && (n <= 0 || d <= 0 || n > math.MaxInt32 || d > math.MaxInt32) {
+				err = errors.New("invalid frame rate")
+			}
+			h.FrameRateN, h.FrameRateD = int32(n), int32(d)
+		case 'A':
+			pixelN, pixelD, err = parseRatio(value)
+		case 'I':
+			if value == "t" || value == "b" {
+				h.FrameFormat = gondi.FrameFormatInterleaved
+			}
+		case 'C':
+			switch {
+			case value == "420" || value == "420jpeg" || value == "420mpeg2" || value == "420paldv":
+				h.Chroma = Chroma420
+			case value == "422":
+				h.Chroma = Chroma422
+			case value == "444":
+				h.Chroma = Chroma444
+			case value == "mono":
+				h.Chroma = ChromaMono
+			default:
+				return nil, fmt.Errorf("unable to read y4m: colorspace %s is not supported", value)
+			}
+		case 'X':
+			if value == "COLORRANGE=FULL" {
+				h.Range = convert.FullRange
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read y4m: invalid parameter %s", field)
+		}
+	}
+
+	if h.Width <= 0 || h.Height <= 0 || h.Width > maxDimension || h.Height > maxDimension {
+		return nil, fmt.Errorf("unable to read y4m: invalid size %dx%d", h.Width, h.Height)
+	}
+	if pixelN > 0 && pixelD > 0 {
+		h.PictureAspectRatio = float32(float64(h.Width*pixelN) / float64(h.Height*pixelD))
+	}
+
+	return h, nil
+}
+
+func parseRatio(value string) (int, int, error) {
+	n, d, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, 0, errors.New("not a ratio")
+	}
+	numerator, err := strconv.Atoi(n)
+	if err != nil {
+		return 0, 0, err
+	}
+	denominator, err := strconv.Atoi(d)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return numerator, denominator, nil
+}
+
+// Get the closest fraction with a denominator up to 1000, by continued fractions
+func rational(x float64) (int, int) {
+	n0, d0, n1, d1 := 0, 1, 1, 0
+	for v := x; ; {
+		a := int(math.Floor(v))
+		n2, d2 := a*n1+n0, a*d1+d0
+		if d2 > 1000 {
+			break
+		}
+		n0, d0, n1, d1 = n1, d1, n2, d2
+		if v-float64(a) < 1e-9 {
+			break
+		}
+		v = 1 / (v - float64(a))
+	}
+	if d1 == 0 {
+		return 1, 1
+	}
+
+	return n1, d1
+}
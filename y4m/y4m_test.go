@@ -0,0 +1,225 @@
+package y4m
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/benitogf/gondi"
+	"github.com/benitogf/gondi/convert"
+)
+
+// A frame with data that changes from pixel to pixel
+func patternFrame(fourCC gondi.FourCCType, width int, height int) *gondi.VideoFrameV2 {
+	frame, _ := convert.NewFrame(convert.FourCC(fourCC), width, height)
+	for i := range frame.Data {
+		frame.Data[i] = byte(16 + i*7%220)
+	}
+	vf := gondi.NewVideoFrameV2()
+	vf.FourCC = fourCC
+	vf.Xres, vf.Yres, vf.LineStride = int32(width), int32(height), int32(frame.Stride)
+	vf.FrameRateN, vf.FrameRateD = 30000, 1001
+	vf.Data = &frame.Data[0]
+	return vf
+}
+
+func frameData(vf *gondi.VideoFrameV2) []byte {
+	size, _ := convert.FrameSize(convert.FourCC(vf.FourCC), int(vf.Xres), int(vf.Yres), int(vf.LineStride))
+	return unsafe.Slice(vf.Data, size)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		fourCC gondi.FourCCType
+		chroma Chroma
+		width  int
+	}{
+		{gondi.FourCCTypeUYVY, Chroma422, 64},
+		{gondi.FourCCTypeI420, Chroma420, 64},
+		{gondi.FourCCTypeI420, Chroma420, 33},
+	} {
+		var stream bytes.Buffer
+		w := NewWriter(&stream, WithChroma(test.chroma))
+		frames := []*gondi.VideoFrameV2{patternFrame(test.fourCC, test.width, 18), patternFrame(test.fourCC, test.width, 18)}
+		for _, vf := range frames {
+			if err := w.WriteFrame(vf); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		written := stream.String()
+		r, err := NewReader(&stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *r.Header() != *w.Header() {
+			t.Errorf("header read is %+v, want %+v", r.Header(), w.Header())
+		}
+		var again bytes.Buffer
+		rewriter := NewWriter(&again, WithChroma(test.chroma))
+		for _, want := range frames {
+			vf, err := r.ReadFrame()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if vf.FourCC != test.fourCC || vf.Xres != want.Xres || vf.FrameRateN != 30000 || vf.FrameRateD != 1001 {
+				t.Errorf("%s %d frame read is %s %dx%d at %d/%d", test.fourCC, test.width, vf.FourCC, vf.Xres, vf.Yres, vf.FrameRateN, vf.FrameRateD)
+			}
+			// Padding at the end of the lines of odd widths is not kept
+			if test.width%2 == 0 && !bytes.Equal(frameData(vf), frameData(want)) {
+				t.Errorf("%s %d frame changed", test.fourCC, test.width)
+			}
+			if err := rewriter.WriteFrame(vf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if again.String() != written {
+			t.Errorf("%s %d stream changed when written again", test.fourCC, test.width)
+		}
+		if _, err := r.ReadFrame(); err != io.EOF {
+			t.Errorf("end of stream is %v", err)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	var stream bytes.Buffer
+	vf := patternFrame(gondi.FourCCTypeBGRA, 32, 16)
+	if err := NewWriter(&stream).WriteFrame(vf); err != nil {
+		t.Fatal(err)
+	}
+	if want := 32*16*3/2 + len("FRAME\n"); stream.Len() != len(w420Header(32, 16))+want {
+		t.Errorf("stream is %d bytes", stream.Len())
+	}
+
+	r, err := NewReader(&stream, WithFourCC(gondi.FourCCTypeBGRA))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, err := r.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.FourCC != gondi.FourCCTypeBGRA || read.LineStride != 32*4 {
+		t.Fatalf("frame read is %s with a stride of %d", read.FourCC, read.LineStride)
+	}
+	// Subsampled chroma changes colors, but not by much on average
+	var difference int
+	for i, b := range frameData(read) {
+		if i%4 == 3 {
+			continue
+		}
+		difference += max(int(b)-int(frameData(vf)[i]), int(frameData(vf)[i])-int(b))
+	}
+	if average := float64(difference) / (32 * 16 * 3); average > 40 {
+		t.Errorf("average difference is %.1f", average)
+	}
+}
+
+func w420Header(width int, height int) string {
+	return (&Header{Width: width, Height: height, FrameRateN: 30000, FrameRateD: 1001}).String()
+}
+
+func TestHeader(t *testing.T) {
+	h, err := parseHeader("YUV4MPEG2 W720 H576 F25:1 It A16:15 C420mpeg2 XYSCSS=420MPEG2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Width != 720 || h.Height != 576 || h.FrameRateN != 25 || h.FrameRateD != 1 ||
+		h.FrameFormat != gondi.FrameFormatInterleaved || h.Chroma != Chroma420 {
+		t.Errorf("header is %+v", h)
+	}
+	if h.PictureAspectRatio < 1.333 || h.PictureAspectRatio > 1.334 {
+		t.Errorf("aspect ratio is %f, want 4:3", h.PictureAspectRatio)
+	}
+	if s := h.String(); !strings.Contains(s, " It A16:15 ") || !strings.HasSuffix(s, " XCOLORRANGE=LIMITED\n") {
+		t.Errorf("header written is %q", s)
+	}
+
+	if h, err = parseHeader("YUV4MPEG2 W16 H16 F25:1 XCOLORRANGE=FULL"); err != nil || h.Range != convert.FullRange {
+		t.Errorf("full range header parsed as %+v, %v", h, err)
+	}
+
+	for _, line := range []string{
+		"YUV4MPEG W1 H1", "YUV4MPEG2 W0 H10", "YUV4MPEG2 W10 H10 C420p10", "YUV4MPEG2 Wx H10",
+		"YUV4MPEG2 W100000 H100000 F25:1", "YUV4MPEG2 W16 H16 F0:1", "YUV4MPEG2 W16 H16 F25:0",
+	} {
+		if _, err := parseHeader(line); err == nil {
+			t.Errorf("%q parsed", line)
+		}
+	}
+}
+
+func TestReadFormats(t *testing.T) {
+	// A 2x1 4:4:4 frame and a mono one
+	r, err := NewReader(strings.NewReader("YUV4MPEG2 W2 H1 F25:1 C444\nFRAME\n\x10\x20\x40\x60\x80\xa0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vf, err := r.ReadFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := frameData(vf); vf.FourCC != gondi.FourCCTypeUYVY || !bytes.Equal(got, []byte{0x50, 0x10, 0x90, 0x20}) {
+		t.Errorf("4:4:4 frame read is %s % x", vf.FourCC, got)
+	}
+
+	r, err = NewReader(strings.NewReader("YUV4MPEG2 W2 H1 F25:1 Cmono\nFRAME\n\x10\x20"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf, err = r.ReadFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if got := frameData(vf); !bytes.Equal(got, []byte{0x80, 0x10, 0x80, 0x20}) {
+		t.Errorf("mono frame read is % x", got)
+	}
+
+	// Truncated frames are errors, not the end of the stream
+	r, _ = NewReader(strings.NewReader("YUV4MPEG2 W2 H1 F25:1 Cmono\nFRAME\n\x10"))
+	if _, err := r.ReadFrame(); err == nil || err == io.EOF {
+		t.Errorf("truncated frame read with %v", err)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	w := NewWriter(io.Discard)
+	if err := w.WriteFrame(patternFrame(gondi.FourCCTypeUYVY, 16, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteFrame(patternFrame(gondi.FourCCTypeUYVY, 32, 16)); err == nil {
+		t.Error("frame of another size written")
+	}
+
+	vf := patternFrame(gondi.FourCCTypeUYVY, 16, 16)
+	vf.FrameRateN = 0
+	if err := NewWriter(io.Discard).WriteFrame(vf); err == nil {
+		t.Error("frame without frame rate written")
+	}
+	if err := NewWriter(io.Discard, WithChroma(Chroma444)).WriteFrame(patternFrame(gondi.FourCCTypeUYVY, 16, 16)); err == nil {
+		t.Error("4:4:4 stream written")
+	}
+
+	// 4:2:0 would mix the fields of interleaved frames
+	vf = patternFrame(gondi.FourCCTypeUYVY, 16, 16)
+	vf.FrameFormatType = gondi.FrameFormatInterleaved
+	if err := NewWriter(io.Discard).WriteFrame(vf); err == nil {
+		t.Error("interleaved frame written as 4:2:0")
+	}
+	if err := NewWriter(io.Discard, WithChroma(Chroma422)).WriteFrame(vf); err != nil {
+		t.Errorf("interleaved frame not written as 4:2:2: %v", err)
+	}
+
+	// The header has the range frames are converted with
+	var buf bytes.Buffer
+	w = NewWriter(&buf, WithConvert(convert.Options{Range: convert.FullRange}))
+	if err := w.WriteFrame(patternFrame(gondi.FourCCTypeBGRA, 16, 16)); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(&buf)
+	if err != nil || r.Header().Range != convert.FullRange {
+		t.Errorf("full range stream read with %v", err)
+	}
+}